@@ -5,6 +5,7 @@ import (
 	"os"
 	"perfect-day/internal/api/server"
 	"perfect-day/pkg/config"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +17,17 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// splitList parses a comma-separated environment value.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func main() {
 	// Load .env file if it exists
 	godotenv.Load()
@@ -24,6 +36,21 @@ func main() {
 	cfg := &config.Config{
 		DataDir:            getEnvOrDefault("DATA_DIR", "./.perfect-day"),
 		GooglePlacesAPIKey: os.Getenv("GOOGLE_PLACES_API_KEY"),
+		StorageBackend:     os.Getenv("STORAGE_BACKEND"),
+		TrashRetention:     os.Getenv("TRASH_RETENTION"),
+		AdminUsers:         splitList(os.Getenv("ADMIN_USERS")),
+		OIDCIssuer:         os.Getenv("OIDC_ISSUER"),
+		OIDCClientID:       os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret:   os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:    os.Getenv("OIDC_REDIRECT_URL"),
+		RateLimit:          os.Getenv("RATE_LIMIT"),
+		AuthRateLimit:      os.Getenv("AUTH_RATE_LIMIT"),
+		PlacesRateLimit:    os.Getenv("PLACES_RATE_LIMIT"),
+		TrustedProxies:     splitList(os.Getenv("TRUSTED_PROXIES")),
+		AllowedOrigins:     splitList(os.Getenv("ALLOWED_ORIGINS")),
+		// Cookies are Secure unless plain HTTP is asked for explicitly
+		CookieSecure:       os.Getenv("COOKIE_SECURE") != "false",
+		CookieSameSite:     os.Getenv("COOKIE_SAMESITE"),
 	}
 
 	// Create and start server
@@ -0,0 +1,240 @@
+// Package apierror defines the errors the API reports to clients. Every
+// error has a typed code, which clients can rely on, that determines its
+// HTTP status.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Code identifies the kind of an error for clients.
+type Code string
+
+// Generic error codes.
+const (
+	CodeValidation         Code = "VALIDATION_ERROR"
+	CodeUnauthorized       Code = "UNAUTHORIZED"
+	CodeForbidden          Code = "FORBIDDEN"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeConflict           Code = "CONFLICT"
+	CodePreconditionFailed Code = "PRECONDITION_FAILED"
+	CodeRateLimited        Code = "RATE_LIMITED"
+	CodeStorage            Code = "STORAGE_ERROR"
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeNotSupported       Code = "NOT_SUPPORTED"
+	CodeUnsupportedMedia   Code = "UNSUPPORTED_MEDIA_TYPE"
+)
+
+// Error codes of specific conditions, more precise than the generic ones.
+const (
+	CodeMissingQuery          Code = "MISSING_QUERY"
+	CodeMissingUsername       Code = "MISSING_USERNAME"
+	CodeInvalidRevision       Code = "INVALID_REVISION"
+	CodeInvalidCursor         Code = "INVALID_CURSOR"
+	CodeInvalidState          Code = "INVALID_STATE"
+	CodeInvalidCredentials    Code = "INVALID_CREDENTIALS"
+	CodeOIDCLoginFailed       Code = "OIDC_LOGIN_FAILED"
+	CodePasswordSetupRequired Code = "PASSWORD_SETUP_REQUIRED"
+	CodeSessionRequired       Code = "SESSION_REQUIRED"
+	CodeInsufficientScope     Code = "INSUFFICIENT_SCOPE"
+	CodeCSRFTokenInvalid      Code = "CSRF_TOKEN_INVALID"
+	CodeUserNotFound          Code = "USER_NOT_FOUND"
+	CodeRevisionNotFound      Code = "REVISION_NOT_FOUND"
+	CodeActivityNotFound      Code = "ACTIVITY_NOT_FOUND"
+	CodeUserExists            Code = "USER_EXISTS"
+	CodeNotDeleted            Code = "NOT_DELETED"
+	CodeLoginLocked           Code = "LOGIN_LOCKED"
+	CodeBackupFailed          Code = "BACKUP_FAILED"
+	CodeOIDCUnavailable       Code = "OIDC_UNAVAILABLE"
+	CodePatchTestFailed       Code = "PATCH_TEST_FAILED"
+)
+
+var codeStatus = map[Code]int{
+	CodeValidation:            http.StatusBadRequest,
+	CodeMissingQuery:          http.StatusBadRequest,
+	CodeMissingUsername:       http.StatusBadRequest,
+	CodeInvalidRevision:       http.StatusBadRequest,
+	CodeInvalidCursor:         http.StatusBadRequest,
+	CodeInvalidState:          http.StatusBadRequest,
+	CodeUnauthorized:          http.StatusUnauthorized,
+	CodeInvalidCredentials:    http.StatusUnauthorized,
+	CodeOIDCLoginFailed:       http.StatusUnauthorized,
+	CodeForbidden:             http.StatusForbidden,
+	CodePasswordSetupRequired: http.StatusForbidden,
+	CodeSessionRequired:       http.StatusForbidden,
+	CodeInsufficientScope:     http.StatusForbidden,
+	CodeCSRFTokenInvalid:      http.StatusForbidden,
+	CodeNotFound:              http.StatusNotFound,
+	CodeUserNotFound:          http.StatusNotFound,
+	CodeRevisionNotFound:      http.StatusNotFound,
+	CodeActivityNotFound:      http.StatusNotFound,
+	CodeConflict:              http.StatusConflict,
+	CodeUserExists:            http.StatusConflict,
+	CodeNotDeleted:            http.StatusConflict,
+	CodePatchTestFailed:       http.StatusConflict,
+	CodePreconditionFailed:    http.StatusPreconditionFailed,
+	CodeUnsupportedMedia:      http.StatusUnsupportedMediaType,
+	CodeRateLimited:           http.StatusTooManyRequests,
+	CodeLoginLocked:           http.StatusTooManyRequests,
+	CodeStorage:               http.StatusInternalServerError,
+	CodeInternal:              http.StatusInternalServerError,
+	CodeBackupFailed:          http.StatusInternalServerError,
+	CodeNotSupported:          http.StatusNotImplemented,
+	CodeOIDCUnavailable:       http.StatusBadGateway,
+}
+
+// Status returns the HTTP status of errors with the code.
+func (c Code) Status() int {
+	if status, ok := codeStatus[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError describes what is wrong with one field of a request. Field is
+// the field's JSON path, e.g. "activities[0].name", and is empty for
+// problems with the request as a whole.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error is an error reported to a client.
+type Error struct {
+	Code    Code         `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Status returns the HTTP status of the error.
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Validation reports an invalid request. Without details, the message is
+// about the request as a whole.
+func Validation(message string, details ...FieldError) *Error {
+	return &Error{Code: CodeValidation, Message: message, Details: details}
+}
+
+// Invalid reports an invalid field.
+func Invalid(field, message string) *Error {
+	return Validation("Invalid request data", FieldError{Field: field, Message: message})
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+func Unauthorized() *Error {
+	return New(CodeUnauthorized, "Not authenticated")
+}
+
+// Storage reports that the storage backend failed; message says what the
+// server was doing.
+func Storage(message string) *Error {
+	return New(CodeStorage, message)
+}
+
+// FromBinding turns an error from binding a request body into a validation
+// error with a detail for each invalid field.
+func FromBinding(err error) *Error {
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+
+	switch {
+	case errors.As(err, &validationErrors):
+		details := make([]FieldError, 0, len(validationErrors))
+		for _, fieldError := range validationErrors {
+			details = append(details, FieldError{
+				Field:   fieldPath(fieldError.Namespace()),
+				Message: validationMessage(fieldError),
+			})
+		}
+		return Validation("Invalid request data", details...)
+	case errors.As(err, &typeError):
+		return Invalid(typeError.Field, "must be a "+jsonType(typeError.Type))
+	case errors.As(err, &syntaxError):
+		return Validation("Request body is not valid JSON")
+	default:
+		return Validation("Invalid request data: " + err.Error())
+	}
+}
+
+// fieldPath drops the request type from a validator namespace such as
+// "CreatePerfectDayRequest.activities[0].name".
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+func validationMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fieldError.Param()
+	case "max":
+		return "must be at most " + fieldError.Param()
+	case "oneof":
+		return "must be one of " + fieldError.Param()
+	default:
+		return "failed the " + fieldError.Tag() + " check"
+	}
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// Validation errors name fields by their JSON names, as clients know them.
+func init() {
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
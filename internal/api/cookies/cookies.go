@@ -0,0 +1,85 @@
+// Package cookies sets the API's cookies with the security attributes the
+// server is configured with.
+package cookies
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Names of the API's cookies.
+const (
+	// Session holds the session token. Scripts cannot read it.
+	Session = "session_id"
+	// CSRF holds the CSRF token of the session, for the browser client to
+	// send back in the X-CSRF-Token header. Scripts can read it.
+	CSRF = "csrf_token"
+	// OIDCState binds a single sign-on login to the browser that started it.
+	OIDCState = "oidc_state"
+)
+
+// Policy is the Secure and SameSite attributes of every cookie.
+type Policy struct {
+	// Secure keeps cookies off plain HTTP; it should be set whenever the
+	// API is served over HTTPS.
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// NewPolicy validates the cookie settings. sameSite is "lax" (the
+// default), "strict" or "none", which browsers only accept with secure.
+func NewPolicy(secure bool, sameSite string) (Policy, error) {
+	policy := Policy{Secure: secure}
+
+	switch strings.ToLower(sameSite) {
+	case "", "lax":
+		policy.SameSite = http.SameSiteLaxMode
+	case "strict":
+		policy.SameSite = http.SameSiteStrictMode
+	case "none":
+		if !secure {
+			return Policy{}, fmt.Errorf("SameSite=None cookies must be secure")
+		}
+		policy.SameSite = http.SameSiteNoneMode
+	default:
+		return Policy{}, fmt.Errorf("invalid SameSite mode %q (expected lax, strict or none)", sameSite)
+	}
+
+	return policy, nil
+}
+
+// Set sets a cookie for maxAge seconds. HttpOnly cookies cannot be read by
+// scripts.
+func (p Policy) Set(c *gin.Context, name, value, path string, maxAge int, httpOnly bool) {
+	p.set(c, name, value, path, maxAge, httpOnly, p.SameSite)
+}
+
+// SetLax sets a cookie like Set but never stricter than SameSite=Lax, for
+// cookies that must survive a redirect back from another site.
+func (p Policy) SetLax(c *gin.Context, name, value, path string, maxAge int) {
+	sameSite := p.SameSite
+	if sameSite == http.SameSiteStrictMode {
+		sameSite = http.SameSiteLaxMode
+	}
+	p.set(c, name, value, path, maxAge, true, sameSite)
+}
+
+// Clear removes a cookie.
+func (p Policy) Clear(c *gin.Context, name, path string) {
+	p.set(c, name, "", path, -1, true, p.SameSite)
+}
+
+func (p Policy) set(c *gin.Context, name, value, path string, maxAge int, httpOnly bool, sameSite http.SameSite) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		Secure:   p.Secure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite,
+	})
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"perfect-day/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reorderActivitiesAction is the custom method of POST
+// /perfect-days/:id/activities:reorder, which gin routes as the :action of
+// /perfect-days/:id/:action.
+const reorderActivitiesAction = "activities:reorder"
+
+type ReorderActivitiesRequest struct {
+	// ActivityIDs lists the ID of every activity of the perfect day in the
+	// new order.
+	ActivityIDs []string `json:"activity_ids" binding:"required"`
+}
+
+// ListActivities lists the activities of a perfect day in their order.
+func (h *Handlers) ListActivities(c *gin.Context) {
+	// Access was checked by the PerfectDayAccess middleware
+	perfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+
+	c.Header("ETag", perfectDayETag(perfectDay))
+	response.JSON(c, http.StatusOK, perfectDay.Activities)
+}
+
+func (h *Handlers) GetActivity(c *gin.Context) {
+	perfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+
+	activity, err := perfectDay.FindActivity(c.Param("activity_id"))
+	if err != nil {
+		respondActivityNotFound(c)
+		return
+	}
+
+	c.Header("ETag", perfectDayETag(perfectDay))
+	response.JSON(c, http.StatusOK, activity)
+}
+
+// CreateActivity adds an activity at the end of a perfect day.
+func (h *Handlers) CreateActivity(c *gin.Context) {
+	var req CreateActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	perfectDay := editablePerfectDay(c)
+	if perfectDay == nil {
+		return
+	}
+
+	location := createLocationFromRequest(req.Location)
+	activity, err := models.NewActivity(utils.GenerateID(), req.Name, *location, req.StartTime, req.Duration, req.Description, req.Commentary)
+	if err != nil {
+		response.Error(c, apierror.Validation("Invalid activity: "+err.Error()))
+		return
+	}
+	perfectDay.AddActivity(*activity)
+
+	if !h.saveActivities(c, perfectDay) {
+		return
+	}
+	response.JSON(c, http.StatusCreated, activity)
+}
+
+// UpdateActivity replaces an activity, which keeps its ID, creation time and
+// position.
+func (h *Handlers) UpdateActivity(c *gin.Context) {
+	var req CreateActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	perfectDay := editablePerfectDay(c)
+	if perfectDay == nil {
+		return
+	}
+
+	activity, err := perfectDay.UpdateActivity(c.Param("activity_id"), models.Activity{
+		Name:        req.Name,
+		Location:    *createLocationFromRequest(req.Location),
+		StartTime:   req.StartTime,
+		Duration:    req.Duration,
+		Description: req.Description,
+		Commentary:  req.Commentary,
+	})
+	if errors.Is(err, models.ErrActivityNotFound) {
+		respondActivityNotFound(c)
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Validation("Invalid activity: "+err.Error()))
+		return
+	}
+
+	if !h.saveActivities(c, perfectDay) {
+		return
+	}
+	response.JSON(c, http.StatusOK, activity)
+}
+
+func (h *Handlers) DeleteActivity(c *gin.Context) {
+	perfectDay := editablePerfectDay(c)
+	if perfectDay == nil {
+		return
+	}
+
+	if err := perfectDay.RemoveActivity(c.Param("activity_id")); err != nil {
+		respondActivityNotFound(c)
+		return
+	}
+
+	if !h.saveActivities(c, perfectDay) {
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ReorderActivities puts the activities of a perfect day in the order of
+// the IDs in the request, which must list every activity once.
+func (h *Handlers) ReorderActivities(c *gin.Context) {
+	var req ReorderActivitiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	perfectDay := editablePerfectDay(c)
+	if perfectDay == nil {
+		return
+	}
+
+	if err := perfectDay.ReorderActivities(req.ActivityIDs); err != nil {
+		response.Error(c, apierror.Invalid("activity_ids", err.Error()))
+		return
+	}
+
+	if !h.saveActivities(c, perfectDay) {
+		return
+	}
+	response.JSON(c, http.StatusOK, perfectDay.Activities)
+}
+
+// perfectDayActions are the custom methods of a perfect day by name.
+var perfectDayActions = map[string]func(h *Handlers, c *gin.Context){
+	reorderActivitiesAction: (*Handlers).ReorderActivities,
+}
+
+// PerfectDayAction dispatches the custom methods of a perfect day, such as
+// POST /perfect-days/:id/activities:reorder. Gin cannot route a literal
+// colon inside a path segment, so they share the /:id/:action route.
+func (h *Handlers) PerfectDayAction(c *gin.Context) {
+	action, ok := perfectDayActions[c.Param("action")]
+	if !ok {
+		response.Error(c, apierror.NotFound("Not found"))
+		return
+	}
+	action(h, c)
+}
+
+// KnownPerfectDayAction responds 404 to custom methods PerfectDayAction
+// does not know. It goes before the access checks of the shared route, so
+// an unknown method never reveals whether the perfect day exists.
+func KnownPerfectDayAction(c *gin.Context) {
+	if _, ok := perfectDayActions[c.Param("action")]; !ok {
+		response.Error(c, apierror.NotFound("Not found"))
+		return
+	}
+	c.Next()
+}
+
+// editablePerfectDay returns a copy of the perfect day the PerfectDayAccess
+// middleware loaded, for changing its activities, after checking If-Match.
+// It returns nil after responding when the precondition fails.
+func editablePerfectDay(c *gin.Context) *models.PerfectDay {
+	existingPerfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+	if !checkIfMatch(c, existingPerfectDay) {
+		return nil
+	}
+
+	perfectDay := *existingPerfectDay
+	perfectDay.Activities = append([]models.Activity(nil), existingPerfectDay.Activities...)
+	return &perfectDay
+}
+
+// saveActivities saves a perfect day whose activities changed. It returns
+// false after responding when the save fails.
+func (h *Handlers) saveActivities(c *gin.Context, perfectDay *models.PerfectDay) bool {
+	perfectDay.UpdatedBy = c.GetString("username")
+	if err := h.Storage.PerfectDayStorage.Save(perfectDay); err != nil {
+		if errors.Is(err, storage.ErrRevisionConflict) {
+			respondRevisionConflict(c)
+			return false
+		}
+		response.Error(c, apierror.Storage("Failed to save perfect day"))
+		return false
+	}
+
+	h.audit(c, models.AuditPerfectDayUpdate, models.AuditTarget("perfect_day", perfectDay.ID))
+	c.Header("ETag", perfectDayETag(perfectDay))
+	return true
+}
+
+func respondActivityNotFound(c *gin.Context) {
+	response.Error(c, apierror.New(apierror.CodeActivityNotFound, "Activity not found"))
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/backup"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BackupDataDir streams a backup archive of the server's data directory,
+// the same archive `perfect-day backup create` writes.
+func (h *Handlers) BackupDataDir(c *gin.Context) {
+	dataDir := h.Storage.GetDataDir()
+	if dataDir == "" {
+		response.Error(c, apierror.New(apierror.CodeNotSupported, "The storage backend has no data directory to back up"))
+		return
+	}
+
+	// Build the archive before sending anything so a failure can still be
+	// reported as an error response instead of a truncated download.
+	archive, err := os.CreateTemp("", "perfect-day-backup-*.tar.gz")
+	if err != nil {
+		respondBackupError(c)
+		return
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if _, err := backup.Create(archive, dataDir); err != nil {
+		respondBackupError(c)
+		return
+	}
+
+	info, err := archive.Stat()
+	if err != nil {
+		respondBackupError(c)
+		return
+	}
+	if _, err := archive.Seek(0, 0); err != nil {
+		respondBackupError(c)
+		return
+	}
+
+	filename := fmt.Sprintf("perfect-day-backup-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+	c.DataFromReader(http.StatusOK, info.Size(), "application/gzip", archive, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, filename),
+	})
+}
+
+func respondBackupError(c *gin.Context) {
+	response.Error(c, apierror.New(apierror.CodeBackupFailed, "Failed to create backup"))
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ListUsers lists every user with the role they act with.
+func (h *Handlers) ListUsers(c *gin.Context) {
+	users, err := h.Storage.UserStorage.List()
+	if err != nil {
+		respondAdminStorageError(c, "Failed to list users")
+		return
+	}
+
+	data := make([]UserProfile, 0, len(users))
+	for _, user := range users {
+		entry := userResponse(user)
+		entry.Role = h.Policy.Role(user)
+		data = append(data, entry)
+	}
+
+	response.JSON(c, http.StatusOK, data)
+}
+
+// SetUserRole stores a new role for a user.
+func (h *Handlers) SetUserRole(c *gin.Context) {
+	var req SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+	if err := models.ValidateRole(req.Role); err != nil {
+		response.Error(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	user, err := h.Storage.UserStorage.Load(c.Param("username"))
+	if err != nil {
+		respondUserNotFound(c)
+		return
+	}
+
+	user.Role = req.Role
+	if err := h.Storage.UserStorage.Save(user); err != nil {
+		respondAdminStorageError(c, "Failed to save user")
+		return
+	}
+
+	h.auditAs(c, c.GetString("username"), models.AuditUserRoleChange, models.AuditTarget("user", user.Username), "role: "+req.Role)
+	data := userResponse(user)
+	data.Role = h.Policy.Role(user)
+	response.JSON(c, http.StatusOK, data)
+}
+
+// ListTrash lists the perfect days in every user's trash, oldest deletion
+// first.
+func (h *Handlers) ListTrash(c *gin.Context) {
+	deleted, err := storage.ListDeleted(h.Storage.PerfectDayStorage, "")
+	if err != nil {
+		respondAdminStorageError(c, "Failed to list the trash")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, gin.H{
+		"perfect_days": deleted,
+	})
+}
+
+// PurgePerfectDay deletes any perfect day for good, in the trash or not.
+func (h *Handlers) PurgePerfectDay(c *gin.Context) {
+	perfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+
+	if err := h.Storage.PerfectDayStorage.Delete(perfectDay.Username, perfectDay.ID, perfectDay.Revision); err != nil {
+		if errors.Is(err, storage.ErrRevisionConflict) {
+			respondRevisionConflict(c)
+			return
+		}
+		respondAdminStorageError(c, "Failed to delete perfect day")
+		return
+	}
+
+	h.audit(c, models.AuditPerfectDayPurge, models.AuditTarget("perfect_day", perfectDay.ID))
+	c.Status(http.StatusNoContent)
+}
+
+func respondAdminStorageError(c *gin.Context, message string) {
+	response.Error(c, apierror.Storage(message))
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAuditEvents caps the events returned by one audit query.
+const maxAuditEvents = 1000
+
+// audit records that the authenticated user did action to target.
+func (h *Handlers) audit(c *gin.Context, action, target string) {
+	h.auditAs(c, c.GetString("username"), action, target, "")
+}
+
+// auditAs records an audit event with the client's address and user agent.
+// Actions done with an API token name it in the detail. The action has
+// already happened when it is recorded, so a failure to record it is
+// logged rather than returned to the client.
+func (h *Handlers) auditAs(c *gin.Context, actor, action, target, detail string) {
+	if value, exists := c.Get("api_token"); exists && detail == "" {
+		detail = "api token " + value.(*models.APIToken).Name
+	}
+
+	event := &models.AuditEvent{
+		ID:        utils.GenerateID(),
+		Time:      time.Now().UTC(),
+		Actor:     actor,
+		Action:    action,
+		Target:    target,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Detail:    detail,
+	}
+	if err := h.Storage.AuditStorage.Append(event); err != nil {
+		log.Printf("Failed to record audit event %s by %s: %v", action, actor, err)
+	}
+}
+
+// ListAuditEvents returns the audit log, newest first, filtered by actor,
+// action and a from/to time range (RFC 3339 or YYYY-MM-DD).
+func (h *Handlers) ListAuditEvents(c *gin.Context) {
+	filter := models.AuditFilter{
+		Actor:  c.Query("actor"),
+		Action: c.Query("action"),
+		Limit:  100,
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(c.Query("from")); err != nil {
+		response.Error(c, apierror.Invalid("from", "must be an RFC 3339 time or a YYYY-MM-DD date"))
+		return
+	}
+	if filter.To, err = parseAuditTime(c.Query("to")); err != nil {
+		response.Error(c, apierror.Invalid("to", "must be an RFC 3339 time or a YYYY-MM-DD date"))
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxAuditEvents {
+			response.Error(c, apierror.Invalid("limit", "must be between 1 and "+strconv.Itoa(maxAuditEvents)))
+			return
+		}
+	}
+
+	events, err := h.Storage.AuditStorage.Query(filter)
+	if err != nil {
+		respondAdminStorageError(c, "Failed to read the audit log")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, gin.H{
+		"events": events,
+	})
+}
+
+// parseAuditTime parses a time bound of an audit query. A date means
+// midnight UTC at its start.
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
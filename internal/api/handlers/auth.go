@@ -1,7 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/cookies"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -9,102 +17,148 @@ import (
 
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
+	Password string `json:"password"`
+}
+
+type SignupRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Timezone string `json:"timezone"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+func (h *Handlers) Signup(c *gin.Context) {
+	var req SignupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+
+	user, session, err := h.AuthService.Signup(req.Username, req.Timezone, req.Password)
+	if errors.Is(err, auth.ErrUserExists) {
+		response.Error(c, apierror.New(apierror.CodeUserExists, "Username is already taken"))
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	h.auditAs(c, user.Username, models.AuditUserCreate, models.AuditTarget("user", user.Username), "")
+	h.respondSession(c, http.StatusCreated, user, session)
 }
 
 func (h *Handlers) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid request data",
-				"details": err.Error(),
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
-	user, session, err := h.AuthService.Login(req.Username)
+	user, session, err := h.AuthService.Login(req.Username, req.Password)
+	if err != nil {
+		// Anyone can fail to log in as anyone, so the name is only the target
+		h.auditAs(c, "", models.AuditLoginFailure, models.AuditTarget("user", req.Username), loginFailureReason(err))
+	}
+
+	var locked *auth.LoginLockedError
+	if errors.As(err, &locked) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		response.Error(c, apierror.New(apierror.CodeLoginLocked, "Too many failed logins, try again later"))
+		return
+	}
+	if errors.Is(err, auth.ErrPasswordSetupRequired) {
+		response.Error(c, apierror.New(apierror.CodePasswordSetupRequired, "This account has no password yet, set one with 'perfect-day login' on the server"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": gin.H{
-				"code":    "USER_NOT_FOUND",
-				"message": "User not found",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.New(apierror.CodeInvalidCredentials, "Invalid username or password"))
 		return
 	}
 
-	// Set session cookie
-	c.SetCookie("session_id", session.ID, 86400, "/", "", false, true)
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"user": gin.H{
-				"username":   user.Username,
-				"timezone":   user.Timezone,
-				"created_at": user.CreatedAt.Format(time.RFC3339),
-			},
-			"session": gin.H{
-				"id":         session.ID,
-				"expires_at": session.ExpiresAt.Format(time.RFC3339),
-			},
-		},
-		"meta": gin.H{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "0.1.0",
-		},
-	})
+	h.auditAs(c, user.Username, models.AuditLoginSuccess, models.AuditTarget("user", user.Username), "password")
+	h.respondSession(c, http.StatusOK, user, session)
 }
 
-func (h *Handlers) GetCurrentUser(c *gin.Context) {
-	sessionID, err := c.Cookie("session_id")
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": gin.H{
-				"code":    "UNAUTHORIZED",
-				"message": "Not authenticated",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+func (h *Handlers) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
-	user, err := h.AuthService.ValidateSession(sessionID)
+	token, _ := c.Cookie(cookies.Session)
+	err := h.AuthService.ChangePassword(c.GetString("username"), req.CurrentPassword, req.NewPassword, token)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		response.Error(c, apierror.New(apierror.CodeInvalidCredentials, "Current password is incorrect"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": gin.H{
-				"code":    "UNAUTHORIZED",
-				"message": "Not authenticated",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.Validation(err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"username":   user.Username,
-			"timezone":   user.Timezone,
-			"created_at": user.CreatedAt.Format(time.RFC3339),
-		},
-		"meta": gin.H{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "0.1.0",
+	h.audit(c, models.AuditPasswordChange, models.AuditTarget("user", c.GetString("username")))
+	response.JSON(c, http.StatusOK, gin.H{
+		"message": "Password changed",
+	})
+}
+
+// loginFailureReason describes why a login failed in the audit log.
+func loginFailureReason(err error) string {
+	var locked *auth.LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		return "account locked"
+	case errors.Is(err, auth.ErrPasswordSetupRequired):
+		return "password setup required"
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return "invalid credentials"
+	default:
+		return err.Error()
+	}
+}
+
+// LoginResponse describes the user and session of a successful login.
+type LoginResponse struct {
+	User    UserProfile  `json:"user"`
+	Session LoginSession `json:"session"`
+}
+
+type LoginSession struct {
+	ID        string `json:"id"`
+	ExpiresAt string `json:"expires_at" format:"date-time"`
+	CSRFToken string `json:"csrf_token"`
+}
+
+// respondSession sets the session cookies and describes the logged in user.
+// The CSRF token is also returned in the body, for clients that cannot read
+// cookies.
+func (h *Handlers) respondSession(c *gin.Context, status int, user *models.User, session *models.Session) {
+	csrfToken := auth.CSRFToken(session.Token)
+	h.Cookies.Set(c, cookies.Session, session.Token, "/", int(auth.SessionTTL.Seconds()), true)
+	h.Cookies.Set(c, cookies.CSRF, csrfToken, "/", int(auth.SessionTTL.Seconds()), false)
+
+	response.JSON(c, status, LoginResponse{
+		User: userResponse(user),
+		Session: LoginSession{
+			ID:        session.ID,
+			ExpiresAt: session.ExpiresAt.Format(time.RFC3339),
+			CSRFToken: csrfToken,
 		},
 	})
+}
+
+func (h *Handlers) GetCurrentUser(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	response.JSON(c, http.StatusOK, userResponse(user))
 }
\ No newline at end of file
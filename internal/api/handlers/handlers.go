@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"perfect-day/internal/api/cookies"
 	"perfect-day/pkg/auth"
+	"perfect-day/pkg/oidc"
 	"perfect-day/pkg/places"
 	"perfect-day/pkg/search"
 	"perfect-day/pkg/storage"
@@ -9,7 +11,11 @@ import (
 
 type Handlers struct {
 	AuthService   *auth.AuthService
+	Policy        *auth.Policy
+	// OIDC is nil unless single sign-on is configured.
+	OIDC          *oidc.Provider
 	Storage       *storage.Storage
 	PlacesService *places.PlacesService
 	SearchService *search.SearchService
+	Cookies       cookies.Policy
 }
\ No newline at end of file
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/cookies"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/oidc"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcCookiePath limits the state cookie to the single sign-on routes.
+const oidcCookiePath = "/api/v1/auth/oidc"
+
+// OIDCLogin sends the user to the OpenID Connect provider to log in.
+func (h *Handlers) OIDCLogin(c *gin.Context) {
+	authURL, state, err := h.OIDC.AuthCodeURL(c.Request.Context())
+	if err != nil {
+		response.Error(c, apierror.New(apierror.CodeOIDCUnavailable, "The identity provider cannot be reached"))
+		return
+	}
+
+	// The state cookie binds the login to this browser, so a login started
+	// by somebody else cannot be completed in it. The provider sends the
+	// user back from another site, so it cannot be SameSite=Strict.
+	h.Cookies.SetLax(c, cookies.OIDCState, state, oidcCookiePath, 600)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback completes a login when the provider sends the user back,
+// creating their account on the first login.
+func (h *Handlers) OIDCCallback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		h.auditAs(c, "", models.AuditLoginFailure, "", "single sign-on: "+providerError)
+		respondOIDCLoginFailed(c, providerError+": "+c.Query("error_description"))
+		return
+	}
+
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" {
+		response.Error(c, apierror.Validation("state and code are required"))
+		return
+	}
+
+	cookieState, _ := c.Cookie(cookies.OIDCState)
+	h.Cookies.Clear(c, cookies.OIDCState, oidcCookiePath)
+	if cookieState != state {
+		respondInvalidOIDCState(c)
+		return
+	}
+
+	claims, err := h.OIDC.Exchange(c.Request.Context(), state, code)
+	if errors.Is(err, oidc.ErrUnknownState) {
+		respondInvalidOIDCState(c)
+		return
+	}
+	if err != nil {
+		h.auditAs(c, "", models.AuditLoginFailure, "", "single sign-on: "+err.Error())
+		respondOIDCLoginFailed(c, err.Error())
+		return
+	}
+
+	user, session, err := h.AuthService.LoginWithOIDC(claims)
+	if err != nil {
+		response.Error(c, apierror.Storage("Failed to log in"))
+		return
+	}
+
+	h.auditAs(c, user.Username, models.AuditLoginSuccess, models.AuditTarget("user", user.Username), "single sign-on")
+	h.respondSession(c, http.StatusOK, user, session)
+}
+
+func respondInvalidOIDCState(c *gin.Context) {
+	response.Error(c, apierror.New(apierror.CodeInvalidState, "Login expired or was started elsewhere, please log in again"))
+}
+
+func respondOIDCLoginFailed(c *gin.Context, details string) {
+	response.Error(c, apierror.New(apierror.CodeOIDCLoginFailed, "Login with the identity provider failed: "+details))
+}
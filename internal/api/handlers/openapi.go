@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/openapi"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/jsonpatch"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/places"
+	"perfect-day/pkg/search"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Shared parameters of the routes described below.
+var (
+	pageParams = []openapi.Param{
+		{Name: "sort", Default: "created_at", Enum: []string{"date", "created_at", "title"}},
+		{Name: "order", Default: "desc", Enum: []string{"asc", "desc"}},
+		{Name: "limit", Type: "integer", Default: defaultPageSize, Description: "Results per page, at most " + strconv.Itoa(search.MaxLimit)},
+		{Name: "cursor", Description: "Continue from the next_cursor or prev_cursor of a page with the same sort and order"},
+		{Name: "offset", Type: "integer", Default: 0, Description: "Results to skip, without a cursor; cursors do not skip or repeat results when perfect days are added"},
+	}
+	ifMatchHeader = openapi.Param{
+		Name:        "If-Match",
+		Description: "ETag of the revision the change is based on; the change is rejected if the perfect day has changed since",
+	}
+)
+
+// APIRoutes describes every route SetupRoutes registers, for the OpenAPI
+// document. Paths are relative to /api/v1.
+var APIRoutes = []openapi.Route{
+	// Health
+	{Method: "GET", Path: "/health", Tag: "Health", Summary: "Check the server's health", Unlimited: true,
+		Response: openapi.Fields{"status": "", "uptime_seconds": 0, "version": "", "checks": openapi.Fields{"storage": "", "google_places": ""}}},
+	{Method: "GET", Path: "/version", Tag: "Health", Summary: "Show the server's version", Unlimited: true,
+		Response: openapi.Fields{"version": "", "build": "", "go_version": "", "built_at": ""}},
+	{Method: "GET", Path: "/openapi.json", Tag: "Health", Summary: "This OpenAPI document", Unlimited: true,
+		ContentType: "application/json"},
+	{Method: "GET", Path: "/docs", Tag: "Health", Summary: "Read this OpenAPI document in a browser", Unlimited: true,
+		ContentType: "text/html"},
+
+	// Authentication
+	{Method: "POST", Path: "/auth/signup", Tag: "Authentication", Summary: "Create an account and log in",
+		Request: SignupRequest{}, Status: http.StatusCreated, Response: LoginResponse{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeUserExists}},
+	{Method: "POST", Path: "/auth/login", Tag: "Authentication", Summary: "Log in with a password",
+		Description: "Sets the session_id and csrf_token cookies. Changes made with the session need the CSRF token in the X-CSRF-Token header.",
+		Request:     LoginRequest{}, Response: LoginResponse{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeInvalidCredentials, apierror.CodePasswordSetupRequired, apierror.CodeLoginLocked}},
+	{Method: "PUT", Path: "/auth/password", Tag: "Authentication", Summary: "Change the password", Auth: openapi.AuthSession,
+		Request: ChangePasswordRequest{}, Response: openapi.Fields{"message": ""},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeInvalidCredentials}},
+	{Method: "GET", Path: "/auth/me", Tag: "Authentication", Summary: "Show the authenticated user", Auth: openapi.AuthRequired,
+		Response: UserProfile{}},
+	{Method: "POST", Path: "/auth/logout", Tag: "Authentication", Summary: "End the current session", Auth: openapi.AuthSession,
+		Status: http.StatusNoContent, Errors: []apierror.Code{apierror.CodeStorage}},
+	{Method: "GET", Path: "/auth/sessions", Tag: "Authentication", Summary: "List the active sessions", Auth: openapi.AuthSession,
+		Response: []SessionResponse{}, Errors: []apierror.Code{apierror.CodeStorage}},
+	{Method: "DELETE", Path: "/auth/sessions", Tag: "Authentication", Summary: "Log out everywhere", Auth: openapi.AuthSession,
+		Response: openapi.Fields{"revoked": 0}, Errors: []apierror.Code{apierror.CodeStorage}},
+	{Method: "DELETE", Path: "/auth/sessions/:id", Tag: "Authentication", Summary: "End one session", Auth: openapi.AuthSession,
+		Status: http.StatusNoContent, Errors: []apierror.Code{apierror.CodeNotFound, apierror.CodeStorage}},
+	{Method: "GET", Path: "/auth/tokens", Tag: "Authentication", Summary: "List the personal API tokens", Auth: openapi.AuthSession,
+		Response: []TokenResponse{}, Errors: []apierror.Code{apierror.CodeStorage}},
+	{Method: "POST", Path: "/auth/tokens", Tag: "Authentication", Summary: "Create a personal API token", Auth: openapi.AuthSession,
+		Description: "The token is only part of this response. Send it as a bearer token in the Authorization header.",
+		Request:     CreateTokenRequest{}, Status: http.StatusCreated, Response: TokenResponse{},
+		Errors: []apierror.Code{apierror.CodeValidation}},
+	{Method: "DELETE", Path: "/auth/tokens/:id", Tag: "Authentication", Summary: "Revoke a personal API token", Auth: openapi.AuthSession,
+		Status: http.StatusNoContent, Errors: []apierror.Code{apierror.CodeNotFound, apierror.CodeStorage}},
+	{Method: "GET", Path: "/auth/oidc/login", Tag: "Authentication", Summary: "Log in with the identity provider",
+		Description: "Only available when single sign-on is configured. Redirects to the provider.",
+		Status:      http.StatusFound, Errors: []apierror.Code{apierror.CodeOIDCUnavailable}},
+	{Method: "GET", Path: "/auth/oidc/callback", Tag: "Authentication", Summary: "Complete a login with the identity provider",
+		Description: "Only available when single sign-on is configured. The provider sends the user here.",
+		Query: []openapi.Param{
+			{Name: "state", Description: "State of the login, from the provider"},
+			{Name: "code", Description: "Authorization code, from the provider"},
+			{Name: "error", Description: "Why the provider did not log the user in"},
+		},
+		Response: LoginResponse{},
+		Errors:   []apierror.Code{apierror.CodeValidation, apierror.CodeInvalidState, apierror.CodeOIDCLoginFailed}},
+
+	// Perfect days
+	{Method: "GET", Path: "/perfect-days", Tag: "Perfect days", Summary: "Search perfect days", Auth: openapi.AuthOptional,
+		Query: append([]openapi.Param{
+			{Name: "q", Description: "Text to search for"},
+			{Name: "user", Description: "Only perfect days of this user"},
+			{Name: "areas", Description: "Only perfect days in this area"},
+			{Name: "from", Description: "Only perfect days on or after this date (YYYY-MM-DD)"},
+			{Name: "to", Description: "Only perfect days on or before this date (YYYY-MM-DD)"},
+		}, pageParams...),
+		Response: openapi.Fields{"perfect_days": []*models.PerfectDay{}, "pagination": Pagination{}},
+		Errors:   []apierror.Code{apierror.CodeValidation, apierror.CodeInvalidCursor, apierror.CodeInternal}},
+	{Method: "POST", Path: "/perfect-days", Tag: "Perfect days", Summary: "Create a perfect day", Auth: openapi.AuthRequired,
+		Request: CreatePerfectDayRequest{}, Status: http.StatusCreated, Response: models.PerfectDay{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeStorage}},
+	{Method: "GET", Path: "/perfect-days/:id", Tag: "Perfect days", Summary: "Show a perfect day", Auth: openapi.AuthOptional,
+		Description: "The ETag header identifies the revision, for If-None-Match and for If-Match on changes.",
+		Headers:     []openapi.Param{{Name: "If-None-Match", Description: "Respond 304 Not Modified if the ETag still matches"}},
+		Response:    models.PerfectDay{},
+		Errors:      []apierror.Code{apierror.CodeNotFound}},
+	{Method: "PUT", Path: "/perfect-days/:id", Tag: "Perfect days", Summary: "Replace a perfect day", Auth: openapi.AuthRequired,
+		Headers: []openapi.Param{ifMatchHeader},
+		Request: CreatePerfectDayRequest{}, Response: models.PerfectDay{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeConflict, apierror.CodePreconditionFailed, apierror.CodeStorage}},
+	{Method: "PATCH", Path: "/perfect-days/:id", Tag: "Perfect days", Summary: "Change part of a perfect day", Auth: openapi.AuthRequired,
+		Description: "Takes an RFC 7396 merge patch (application/merge-patch+json or application/json) or an RFC 6902 JSON Patch (application/json-patch+json) " +
+			"of the perfect day as returned by GET. Activities keep their id and created_at while the patch keeps their id; areas follow the activities and the other fields the server sets are read-only.",
+		Headers: []openapi.Param{ifMatchHeader},
+		RequestTypes: map[string]interface{}{
+			mergePatchType: openapi.Fields{},
+			jsonPatchType:  []jsonpatch.Operation{},
+		},
+		Response: models.PerfectDay{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeConflict, apierror.CodePatchTestFailed,
+			apierror.CodePreconditionFailed, apierror.CodeUnsupportedMedia, apierror.CodeStorage}},
+	{Method: "DELETE", Path: "/perfect-days/:id", Tag: "Perfect days", Summary: "Move a perfect day to the trash", Auth: openapi.AuthRequired,
+		Description: "With hard=true the perfect day is deleted for good, which only admins may do.",
+		Query:       []openapi.Param{{Name: "hard", Type: "boolean", Default: false, Description: "Delete for good instead"}},
+		Headers:     []openapi.Param{ifMatchHeader},
+		Status:      http.StatusNoContent,
+		Errors:      []apierror.Code{apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeConflict, apierror.CodePreconditionFailed, apierror.CodeStorage}},
+	{Method: "POST", Path: "/perfect-days/:id/restore", Tag: "Perfect days", Summary: "Restore a perfect day from the trash", Auth: openapi.AuthRequired,
+		Response: models.PerfectDay{},
+		Errors:   []apierror.Code{apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeNotDeleted, apierror.CodeStorage}},
+	{Method: "GET", Path: "/perfect-days/:id/activities", Tag: "Perfect days", Summary: "List the activities of a perfect day", Auth: openapi.AuthOptional,
+		Description: "The ETag header identifies the revision of the perfect day, for If-Match on changes to its activities.",
+		Response:    []models.Activity{},
+		Errors:      []apierror.Code{apierror.CodeNotFound}},
+	{Method: "POST", Path: "/perfect-days/:id/activities", Tag: "Perfect days", Summary: "Add an activity to a perfect day", Auth: openapi.AuthRequired,
+		Description: "The activity is added at the end and gets an id it keeps while it is edited and reordered.",
+		Headers:     []openapi.Param{ifMatchHeader},
+		Request:     CreateActivityRequest{}, Status: http.StatusCreated, Response: models.Activity{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeConflict, apierror.CodePreconditionFailed, apierror.CodeStorage}},
+	{Method: "GET", Path: "/perfect-days/:id/activities/:activity_id", Tag: "Perfect days", Summary: "Show an activity of a perfect day", Auth: openapi.AuthOptional,
+		Response: models.Activity{},
+		Errors:   []apierror.Code{apierror.CodeNotFound, apierror.CodeActivityNotFound}},
+	{Method: "PUT", Path: "/perfect-days/:id/activities/:activity_id", Tag: "Perfect days", Summary: "Replace an activity of a perfect day", Auth: openapi.AuthRequired,
+		Description: "The activity keeps its id, created_at and position.",
+		Headers:     []openapi.Param{ifMatchHeader},
+		Request:     CreateActivityRequest{}, Response: models.Activity{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeActivityNotFound, apierror.CodeConflict,
+			apierror.CodePreconditionFailed, apierror.CodeStorage}},
+	{Method: "DELETE", Path: "/perfect-days/:id/activities/:activity_id", Tag: "Perfect days", Summary: "Remove an activity from a perfect day", Auth: openapi.AuthRequired,
+		Headers: []openapi.Param{ifMatchHeader},
+		Status:  http.StatusNoContent,
+		Errors: []apierror.Code{apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeActivityNotFound, apierror.CodeConflict,
+			apierror.CodePreconditionFailed, apierror.CodeStorage}},
+	{Method: "POST", Path: "/perfect-days/:id/activities:reorder", GinPath: "/perfect-days/:id/:action", Tag: "Perfect days", Summary: "Reorder the activities of a perfect day", Auth: openapi.AuthRequired,
+		Description: "activity_ids lists the id of every activity of the perfect day once, in the new order.",
+		Headers:     []openapi.Param{ifMatchHeader},
+		Request:     ReorderActivitiesRequest{}, Response: []models.Activity{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeConflict, apierror.CodePreconditionFailed, apierror.CodeStorage}},
+	{Method: "GET", Path: "/perfect-days/:id/revisions", Tag: "Perfect days", Summary: "List the revisions of a perfect day", Auth: openapi.AuthOptional,
+		Response: openapi.Fields{"revisions": []RevisionSummary{}},
+		Errors:   []apierror.Code{apierror.CodeNotFound, apierror.CodeInternal}},
+	{Method: "GET", Path: "/perfect-days/:id/revisions/:rev", Tag: "Perfect days", Summary: "Show a revision of a perfect day", Auth: openapi.AuthOptional,
+		Response: models.PerfectDayRevision{},
+		Errors:   []apierror.Code{apierror.CodeInvalidRevision, apierror.CodeNotFound, apierror.CodeRevisionNotFound}},
+	{Method: "POST", Path: "/perfect-days/:id/revisions/:rev/restore", Tag: "Perfect days", Summary: "Restore a perfect day as it was at a revision", Auth: openapi.AuthRequired,
+		Headers:  []openapi.Param{ifMatchHeader},
+		Response: models.PerfectDay{},
+		Errors:   []apierror.Code{apierror.CodeInvalidRevision, apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeRevisionNotFound, apierror.CodeConflict, apierror.CodePreconditionFailed}},
+
+	// Users
+	{Method: "POST", Path: "/users", Tag: "Users", Summary: "Create an account and log in",
+		Description: "The same as POST /auth/signup.",
+		Request:     SignupRequest{}, Status: http.StatusCreated, Response: LoginResponse{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeUserExists}},
+	{Method: "GET", Path: "/users/:username", Tag: "Users", Summary: "Show a user's profile", Auth: openapi.AuthOptional,
+		Response: UserProfile{}, Errors: []apierror.Code{apierror.CodeUserNotFound}},
+	{Method: "PATCH", Path: "/users/:username", Tag: "Users", Summary: "Change your profile", Auth: openapi.AuthSession,
+		Description: "Only the fields present in the request are changed.",
+		Request:     UpdateUserRequest{}, Response: UserProfile{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeForbidden, apierror.CodeUserNotFound, apierror.CodeStorage}},
+	{Method: "DELETE", Path: "/users/:username", Tag: "Users", Summary: "Delete your account", Auth: openapi.AuthSession,
+		Query:  []openapi.Param{{Name: "perfect_days", Default: "delete", Enum: []string{"delete", "anonymize"}, Description: "Delete your perfect days, or keep them without an owner"}},
+		Status: http.StatusNoContent,
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeForbidden, apierror.CodeUserNotFound, apierror.CodeStorage}},
+	{Method: "GET", Path: "/users/:username/perfect-days", Tag: "Users", Summary: "List a user's perfect days", Auth: openapi.AuthOptional,
+		Query:    append([]openapi.Param{{Name: "include_deleted", Type: "boolean", Default: false, Description: "Include perfect days in the trash, for their owner and admins"}}, pageParams...),
+		Response: search.SearchResult{},
+		Errors:   []apierror.Code{apierror.CodeValidation, apierror.CodeInvalidCursor, apierror.CodeUserNotFound, apierror.CodeInternal}},
+
+	// Places
+	{Method: "GET", Path: "/places/search", Tag: "Places", Summary: "Search places for activities", Auth: openapi.AuthOptional,
+		Description: "When the places service is unavailable the result is empty and meta.notice says so.",
+		Query: []openapi.Param{
+			{Name: "q", Required: true, Description: "Text to search for"},
+			{Name: "limit", Type: "integer", Default: 10, Description: "Results, at most 50"},
+		},
+		Response: openapi.Fields{"places": []places.PlaceResult{}, "query": "", "limit": 0},
+		Errors:   []apierror.Code{apierror.CodeMissingQuery}},
+	{Method: "GET", Path: "/areas", Tag: "Places", Summary: "List the areas of all perfect days", Auth: openapi.AuthOptional,
+		Response: openapi.Fields{"areas": []string{}}, Errors: []apierror.Code{apierror.CodeInternal}},
+
+	// Administration
+	{Method: "GET", Path: "/admin/backup", Tag: "Administration", Summary: "Download a backup of the data directory", Auth: openapi.AuthRequired,
+		ContentType: "application/gzip", Errors: []apierror.Code{apierror.CodeForbidden, apierror.CodeNotSupported, apierror.CodeBackupFailed}},
+	{Method: "GET", Path: "/admin/users", Tag: "Administration", Summary: "List all users with their roles", Auth: openapi.AuthRequired,
+		Response: []UserProfile{}, Errors: []apierror.Code{apierror.CodeForbidden, apierror.CodeStorage}},
+	{Method: "PUT", Path: "/admin/users/:username/role", Tag: "Administration", Summary: "Change a user's role", Auth: openapi.AuthSession,
+		Request: SetRoleRequest{}, Response: UserProfile{},
+		Errors: []apierror.Code{apierror.CodeValidation, apierror.CodeForbidden, apierror.CodeUserNotFound, apierror.CodeStorage}},
+	{Method: "GET", Path: "/admin/trash", Tag: "Administration", Summary: "List the perfect days in every user's trash", Auth: openapi.AuthRequired,
+		Response: openapi.Fields{"perfect_days": []*models.PerfectDay{}}, Errors: []apierror.Code{apierror.CodeForbidden, apierror.CodeStorage}},
+	{Method: "GET", Path: "/admin/audit", Tag: "Administration", Summary: "Read the audit log, newest first", Auth: openapi.AuthRequired,
+		Query: []openapi.Param{
+			{Name: "actor", Description: "Only events by this user"},
+			{Name: "action", Description: "Only events with this action, e.g. perfect_day.delete"},
+			{Name: "from", Description: "Only events at or after this date or RFC 3339 time"},
+			{Name: "to", Description: "Only events before this date or RFC 3339 time"},
+			{Name: "limit", Type: "integer", Default: 100, Description: "Events, at most 1000"},
+		},
+		Response: openapi.Fields{"events": []models.AuditEvent{}},
+		Errors:   []apierror.Code{apierror.CodeValidation, apierror.CodeForbidden, apierror.CodeStorage}},
+	{Method: "DELETE", Path: "/admin/perfect-days/:id", Tag: "Administration", Summary: "Delete any perfect day for good", Auth: openapi.AuthRequired,
+		Status: http.StatusNoContent, Errors: []apierror.Code{apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeStorage}},
+	{Method: "POST", Path: "/admin/perfect-days/:id/restore", Tag: "Administration", Summary: "Restore any perfect day from the trash", Auth: openapi.AuthRequired,
+		Response: models.PerfectDay{},
+		Errors:   []apierror.Code{apierror.CodeForbidden, apierror.CodeNotFound, apierror.CodeNotDeleted, apierror.CodeStorage}},
+}
+
+// APIDocument is the OpenAPI document of the API.
+func APIDocument() *openapi.Document {
+	return openapi.Build(openapi.Info{
+		Title:       "Perfect Day API",
+		Version:     response.Version,
+		Description: "Share and discover perfect days.",
+	}, "/api/v1", APIRoutes)
+}
+
+// OpenAPISpec serves the OpenAPI document.
+func (h *Handlers) OpenAPISpec(c *gin.Context) {
+	c.JSON(http.StatusOK, APIDocument())
+}
+
+// APIDocs serves a page for reading the OpenAPI document.
+func (h *Handlers) APIDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.DocsPage)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/search"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPageSize is the page size of listings without a limit.
+const defaultPageSize = 10
+
+// Pagination describes the page of a listing and links to its neighbours.
+type Pagination struct {
+	Total   int  `json:"total"`
+	Offset  int  `json:"offset"`
+	Limit   int  `json:"limit"`
+	HasMore bool `json:"has_more"`
+	// NextCursor and PrevCursor continue the listing after and before this
+	// page; Next and Prev link to those pages. They are empty at its ends.
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+}
+
+// searchPage pages perfectDays by the limit, offset and cursor query
+// parameters and links the result to its neighbours. It returns nil after
+// responding when a parameter is invalid.
+func (h *Handlers) searchPage(c *gin.Context, perfectDays []*models.PerfectDay, criteria search.SearchCriteria) *search.SearchResult {
+	criteria.Limit = defaultPageSize
+	if limit := c.Query("limit"); limit != "" {
+		var err error
+		criteria.Limit, err = strconv.Atoi(limit)
+		if err != nil || criteria.Limit < 1 || criteria.Limit > search.MaxLimit {
+			response.Error(c, apierror.Invalid("limit", "must be between 1 and "+strconv.Itoa(search.MaxLimit)))
+			return nil
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		var err error
+		criteria.Offset, err = strconv.Atoi(offset)
+		if err != nil || criteria.Offset < 0 {
+			response.Error(c, apierror.Invalid("offset", "must be 0 or more"))
+			return nil
+		}
+	}
+	criteria.Cursor = c.Query("cursor")
+
+	result, err := h.SearchService.Page(perfectDays, criteria)
+	if errors.Is(err, search.ErrInvalidCursor) {
+		response.Error(c, apierror.New(apierror.CodeInvalidCursor, "Invalid cursor, start again from the first page"))
+		return nil
+	}
+	if err != nil {
+		response.Error(c, apierror.New(apierror.CodeInternal, "Failed to page perfect days"))
+		return nil
+	}
+
+	result.Next = pageLink(c, result.NextCursor)
+	result.Prev = pageLink(c, result.PrevCursor)
+	return result
+}
+
+// pagination describes the page of result.
+func pagination(result *search.SearchResult) Pagination {
+	return Pagination{
+		Total:      result.Total,
+		Offset:     result.Offset,
+		Limit:      result.Limit,
+		HasMore:    result.NextCursor != "",
+		NextCursor: result.NextCursor,
+		PrevCursor: result.PrevCursor,
+		Next:       result.Next,
+		Prev:       result.Prev,
+	}
+}
+
+// pageLink returns the URL of the current request continued from cursor,
+// or "" without a cursor.
+func pageLink(c *gin.Context, cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	query := c.Request.URL.Query()
+	query.Del("offset")
+	query.Set("cursor", cursor)
+	return c.Request.URL.Path + "?" + query.Encode()
+}
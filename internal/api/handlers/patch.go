@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/jsonpatch"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"perfect-day/pkg/utils"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Media types of the patches PatchPerfectDay accepts. Plain JSON is taken
+// as a merge patch.
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// perfectDayReadOnly are the fields of a perfect day a patch cannot change.
+// Areas are derived from the activities.
+var perfectDayReadOnly = []string{
+	"id", "username", "areas", "is_deleted", "deleted_at", "created_at", "updated_at", "updated_by", "revision",
+}
+
+// perfectDayEdit holds the fields of a perfect day a patch can change.
+type perfectDayEdit struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Date        string            `json:"date"`
+	Activities  []models.Activity `json:"activities"`
+}
+
+// PatchPerfectDay changes part of a perfect day with an RFC 7396 merge patch
+// or an RFC 6902 JSON Patch applied to its JSON representation. Activities
+// keep their IDs and creation times as long as the patch keeps their id.
+func (h *Handlers) PatchPerfectDay(c *gin.Context) {
+	apply := jsonpatch.MergePatch
+	switch c.ContentType() {
+	case mergePatchType, "application/json":
+	case jsonPatchType:
+		apply = jsonpatch.Apply
+	default:
+		c.Header("Accept-Patch", mergePatchType+", "+jsonPatchType)
+		response.Error(c, apierror.New(apierror.CodeUnsupportedMedia, "Send a merge patch ("+mergePatchType+") or a JSON Patch ("+jsonPatchType+")"))
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		response.Error(c, apierror.Validation("Failed to read the patch"))
+		return
+	}
+
+	// Access was checked by the PerfectDayAccess middleware
+	existingPerfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+	if !checkIfMatch(c, existingPerfectDay) {
+		return
+	}
+
+	original, err := json.Marshal(existingPerfectDay)
+	if err != nil {
+		response.Error(c, apierror.New(apierror.CodeInternal, "Failed to encode perfect day"))
+		return
+	}
+	patched, err := apply(original, patch)
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		response.Error(c, apierror.New(apierror.CodePatchTestFailed, err.Error()))
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	edit, apiErr := decodePerfectDayEdit(original, patched)
+	if apiErr != nil {
+		response.Error(c, apiErr)
+		return
+	}
+
+	updatedPerfectDay, apiErr := applyPerfectDayEdit(existingPerfectDay, edit)
+	if apiErr != nil {
+		response.Error(c, apiErr)
+		return
+	}
+
+	updatedPerfectDay.UpdatedBy = c.GetString("username")
+	if err := h.Storage.PerfectDayStorage.Save(updatedPerfectDay); err != nil {
+		if errors.Is(err, storage.ErrRevisionConflict) {
+			respondRevisionConflict(c)
+			return
+		}
+		response.Error(c, apierror.Storage("Failed to save perfect day"))
+		return
+	}
+
+	h.audit(c, models.AuditPerfectDayUpdate, models.AuditTarget("perfect_day", updatedPerfectDay.ID))
+	c.Header("ETag", perfectDayETag(updatedPerfectDay))
+	response.JSON(c, http.StatusOK, updatedPerfectDay)
+}
+
+// decodePerfectDayEdit reads the editable fields of the patched document,
+// rejecting changes to read-only fields and fields a perfect day does not
+// have.
+func decodePerfectDayEdit(original, patched []byte) (*perfectDayEdit, *apierror.Error) {
+	var before, after map[string]interface{}
+	if err := json.Unmarshal(original, &before); err != nil {
+		return nil, apierror.New(apierror.CodeInternal, "Failed to decode perfect day")
+	}
+	if err := json.Unmarshal(patched, &after); err != nil || after == nil {
+		return nil, apierror.Validation("The patched perfect day must be an object")
+	}
+
+	var readOnly []apierror.FieldError
+	for _, field := range perfectDayReadOnly {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			readOnly = append(readOnly, apierror.FieldError{Field: field, Message: "is read-only"})
+		}
+		delete(after, field)
+	}
+	if len(readOnly) > 0 {
+		return nil, apierror.Validation("The patch changes read-only fields", readOnly...)
+	}
+
+	editable, err := json.Marshal(after)
+	if err != nil {
+		return nil, apierror.New(apierror.CodeInternal, "Failed to encode perfect day")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(editable))
+	decoder.DisallowUnknownFields()
+	var edit perfectDayEdit
+	if err := decoder.Decode(&edit); err != nil {
+		return nil, apierror.FromBinding(err)
+	}
+	return &edit, nil
+}
+
+// applyPerfectDayEdit validates edit and returns a copy of perfectDay with
+// it applied. Activities with the ID of an existing activity keep its
+// creation time, others are new and get an ID.
+func applyPerfectDayEdit(perfectDay *models.PerfectDay, edit *perfectDayEdit) (*models.PerfectDay, *apierror.Error) {
+	if _, err := models.NewPerfectDay(perfectDay.ID, edit.Title, edit.Description, perfectDay.Username, edit.Date); err != nil {
+		return nil, apierror.Validation(err.Error())
+	}
+
+	existing := make(map[string]models.Activity, len(perfectDay.Activities))
+	for _, activity := range perfectDay.Activities {
+		existing[activity.ID] = activity
+	}
+
+	seen := make(map[string]bool, len(edit.Activities))
+	activities := make([]models.Activity, 0, len(edit.Activities))
+	for i, edited := range edit.Activities {
+		field := fmt.Sprintf("activities[%d]", i)
+		if err := validateLocation(edited.Location); err != nil {
+			return nil, apierror.Invalid(field+".location", err.Error())
+		}
+
+		previous, known := existing[edited.ID]
+		id := edited.ID
+		if !known || seen[id] {
+			// New, or copied from another activity
+			id = utils.GenerateID()
+		}
+		seen[id] = true
+
+		activity, err := models.NewActivity(id, edited.Name, edited.Location, edited.StartTime, edited.Duration, edited.Description, edited.Commentary)
+		if err != nil {
+			return nil, apierror.Invalid(field, err.Error())
+		}
+		if known && id == edited.ID {
+			activity.CreatedAt = previous.CreatedAt
+		}
+		activities = append(activities, *activity)
+	}
+
+	updated := *perfectDay
+	updated.Title = edit.Title
+	updated.Description = edit.Description
+	updated.Date = edit.Date
+	updated.Activities = activities
+	updated.UpdateAreas()
+	return &updated, nil
+}
+
+// validateLocation checks what binding checks for the locations of created
+// perfect days.
+func validateLocation(location models.Location) error {
+	switch {
+	case location.Type != models.GooglePlaceLocation && location.Type != models.CustomTextLocation:
+		return fmt.Errorf("type must be %s or %s", models.GooglePlaceLocation, models.CustomTextLocation)
+	case location.Type == models.GooglePlaceLocation && location.PlaceID == "":
+		return fmt.Errorf("place_id is required for a %s location", models.GooglePlaceLocation)
+	case location.Name == "":
+		return fmt.Errorf("name is required")
+	case location.Area == "":
+		return fmt.Errorf("area is required")
+	}
+	return nil
+}
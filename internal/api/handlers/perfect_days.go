@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
 	"perfect-day/pkg/models"
 	"perfect-day/pkg/search"
+	"perfect-day/pkg/storage"
 	"perfect-day/pkg/utils"
-	"strconv"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -46,25 +50,11 @@ func (h *Handlers) ListPerfectDays(c *gin.Context) {
 	to := c.Query("to")
 	sortBy := c.DefaultQuery("sort", "created_at")
 	order := c.DefaultQuery("order", "desc")
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
 
 	// Load all perfect days
 	allPerfectDays, err := h.Storage.PerfectDayStorage.LoadAll(false) // exclude deleted
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Failed to load perfect days",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.New(apierror.CodeInternal, "Failed to load perfect days"))
 		return
 	}
 
@@ -76,63 +66,34 @@ func (h *Handlers) ListPerfectDays(c *gin.Context) {
 		DateTo:    to,
 		SortBy:    sortBy,
 		SortOrder: order,
-		Limit:     limit,
-		Offset:    offset,
 	}
 
 	if areas != "" {
 		searchCriteria.Areas = []string{areas}
 	}
 
-	searchResult := h.SearchService.Search(allPerfectDays, searchCriteria)
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"perfect_days": searchResult.PerfectDays,
-			"pagination": gin.H{
-				"total":    searchResult.Total,
-				"offset":   searchResult.Offset,
-				"limit":    searchResult.Limit,
-				"has_more": searchResult.Offset+searchResult.Limit < searchResult.Total,
-			},
-		},
-		"meta": gin.H{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "0.1.0",
-		},
+	searchResult := h.searchPage(c, allPerfectDays, searchCriteria)
+	if searchResult == nil {
+		return
+	}
+
+	response.JSON(c, http.StatusOK, gin.H{
+		"perfect_days": searchResult.PerfectDays,
+		"pagination":   pagination(searchResult),
 	})
 }
 
 func (h *Handlers) CreatePerfectDay(c *gin.Context) {
 	var req CreatePerfectDayRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid request data",
-				"details": err.Error(),
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
 	// Get authenticated username from middleware
 	username, exists := c.Get("username")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": gin.H{
-				"code":    "UNAUTHORIZED",
-				"message": "Not authenticated",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.Unauthorized())
 		return
 	}
 	usernameStr := username.(string)
@@ -140,16 +101,7 @@ func (h *Handlers) CreatePerfectDay(c *gin.Context) {
 	// Create perfect day
 	perfectDay, err := models.NewPerfectDay(utils.GenerateID(), req.Title, req.Description, usernameStr, req.Date)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": err.Error(),
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.Validation(err.Error()))
 		return
 	}
 
@@ -166,192 +118,63 @@ func (h *Handlers) CreatePerfectDay(c *gin.Context) {
 			actReq.Commentary,
 		)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": gin.H{
-					"code":    "VALIDATION_ERROR",
-					"message": "Invalid activity: " + err.Error(),
-				},
-				"meta": gin.H{
-					"timestamp": time.Now().UTC().Format(time.RFC3339),
-					"version":   "0.1.0",
-				},
-			})
+			response.Error(c, apierror.Validation("Invalid activity: "+err.Error()))
 			return
 		}
 		perfectDay.AddActivity(*activity)
 	}
 
 	// Save to storage
+	perfectDay.UpdatedBy = usernameStr
 	if err := h.Storage.PerfectDayStorage.Save(perfectDay); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "STORAGE_ERROR",
-				"message": "Failed to save perfect day",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.Storage("Failed to save perfect day"))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"data": perfectDay,
-		"meta": gin.H{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "0.1.0",
-		},
-	})
+	h.audit(c, models.AuditPerfectDayCreate, models.AuditTarget("perfect_day", perfectDay.ID))
+	c.Header("ETag", perfectDayETag(perfectDay))
+	response.JSON(c, http.StatusCreated, perfectDay)
 }
 
 func (h *Handlers) GetPerfectDay(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "MISSING_ID",
-				"message": "Perfect day ID is required",
-			},
-		})
-		return
-	}
-
-	// Try to find in all users' data
-	allPerfectDays, err := h.Storage.PerfectDayStorage.LoadAll(false)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Failed to load perfect days",
-			},
-		})
-		return
-	}
+	foundPerfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
 
-	var foundPerfectDay *models.PerfectDay
-	for _, pd := range allPerfectDays {
-		if pd.ID == id {
-			foundPerfectDay = pd
-			break
-		}
-	}
-
-	if foundPerfectDay == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": gin.H{
-				"code":    "NOT_FOUND",
-				"message": "Perfect day not found",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+	etag := perfectDayETag(foundPerfectDay)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag, true) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": foundPerfectDay,
-		"meta": gin.H{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "0.1.0",
-		},
-	})
+	response.JSON(c, http.StatusOK, foundPerfectDay)
 }
 
 func (h *Handlers) UpdatePerfectDay(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "MISSING_ID",
-				"message": "Perfect day ID is required",
-			},
-		})
-		return
-	}
-
 	var req CreatePerfectDayRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid request data",
-				"details": err.Error(),
-			},
-		})
+		response.Error(c, apierror.FromBinding(err))
 		return
 	}
 
-	// Find existing perfect day
-	allPerfectDays, err := h.Storage.PerfectDayStorage.LoadAll(false)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Failed to load perfect days",
-			},
-		})
-		return
-	}
-
-	var existingPerfectDay *models.PerfectDay
-	for _, pd := range allPerfectDays {
-		if pd.ID == id {
-			existingPerfectDay = pd
-			break
-		}
-	}
-
-	if existingPerfectDay == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": gin.H{
-				"code":    "NOT_FOUND",
-				"message": "Perfect day not found",
-			},
-		})
-		return
-	}
+	// Access was checked by the PerfectDayAccess middleware
+	existingPerfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+	id := existingPerfectDay.ID
+	usernameStr := c.GetString("username")
 
-	// Get authenticated username and verify ownership
-	username, exists := c.Get("username")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": gin.H{
-				"code":    "UNAUTHORIZED",
-				"message": "Not authenticated",
-			},
-		})
-		return
-	}
-	usernameStr := username.(string)
-
-	// Verify ownership
-	if existingPerfectDay.Username != usernameStr {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": gin.H{
-				"code":    "FORBIDDEN",
-				"message": "You can only update your own perfect days",
-			},
-		})
+	if !checkIfMatch(c, existingPerfectDay) {
 		return
 	}
 
 	// Update the perfect day
 	updatedPerfectDay, err := models.NewPerfectDay(id, req.Title, req.Description, existingPerfectDay.Username, req.Date)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": err.Error(),
-			},
-		})
+		response.Error(c, apierror.Validation(err.Error()))
 		return
 	}
 
-	// Copy creation time
+	// Copy creation time and the revision the update is based on
 	updatedPerfectDay.CreatedAt = existingPerfectDay.CreatedAt
+	updatedPerfectDay.Revision = existingPerfectDay.Revision
 
 	// Add activities
 	for _, actReq := range req.Activities {
@@ -366,118 +189,150 @@ func (h *Handlers) UpdatePerfectDay(c *gin.Context) {
 			actReq.Commentary,
 		)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": gin.H{
-					"code":    "VALIDATION_ERROR",
-					"message": "Invalid activity: " + err.Error(),
-				},
-			})
+			response.Error(c, apierror.Validation("Invalid activity: "+err.Error()))
 			return
 		}
 		updatedPerfectDay.AddActivity(*activity)
 	}
 
 	// Save to storage
+	updatedPerfectDay.UpdatedBy = usernameStr
 	if err := h.Storage.PerfectDayStorage.Save(updatedPerfectDay); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "STORAGE_ERROR",
-				"message": "Failed to save perfect day",
-			},
-		})
+		if errors.Is(err, storage.ErrRevisionConflict) {
+			respondRevisionConflict(c)
+			return
+		}
+		response.Error(c, apierror.Storage("Failed to save perfect day"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": updatedPerfectDay,
-		"meta": gin.H{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "0.1.0",
-		},
-	})
+	h.audit(c, models.AuditPerfectDayUpdate, models.AuditTarget("perfect_day", updatedPerfectDay.ID))
+	c.Header("ETag", perfectDayETag(updatedPerfectDay))
+	response.JSON(c, http.StatusOK, updatedPerfectDay)
 }
 
 func (h *Handlers) DeletePerfectDay(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "MISSING_ID",
-				"message": "Perfect day ID is required",
-			},
-		})
-		return
-	}
+	// A hard delete also reaches perfect days already in the trash; the
+	// PerfectDayAccess middleware checked it as a purge
+	hard := c.Query("hard") == "true"
+	existingPerfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+	usernameStr := c.GetString("username")
 
-	// Find existing perfect day
-	allPerfectDays, err := h.Storage.PerfectDayStorage.LoadAll(false)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Failed to load perfect days",
-			},
-		})
+	if !checkIfMatch(c, existingPerfectDay) {
 		return
 	}
 
-	var existingPerfectDay *models.PerfectDay
-	for _, pd := range allPerfectDays {
-		if pd.ID == id {
-			existingPerfectDay = pd
-			break
+	if hard {
+		// The stored copy must still be the one If-Match was checked against
+		if err := h.Storage.PerfectDayStorage.Delete(existingPerfectDay.Username, existingPerfectDay.ID, existingPerfectDay.Revision); err != nil {
+			if errors.Is(err, storage.ErrRevisionConflict) {
+				respondRevisionConflict(c)
+				return
+			}
+			response.Error(c, apierror.Storage("Failed to delete perfect day"))
+			return
 		}
+		h.audit(c, models.AuditPerfectDayPurge, models.AuditTarget("perfect_day", existingPerfectDay.ID))
+		c.Status(http.StatusNoContent)
+		return
 	}
 
-	if existingPerfectDay == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": gin.H{
-				"code":    "NOT_FOUND",
-				"message": "Perfect day not found",
-			},
-		})
+	// Soft delete
+	existingPerfectDay.SoftDelete()
+	existingPerfectDay.UpdatedBy = usernameStr
+
+	// Save to storage
+	if err := h.Storage.PerfectDayStorage.Save(existingPerfectDay); err != nil {
+		if errors.Is(err, storage.ErrRevisionConflict) {
+			respondRevisionConflict(c)
+			return
+		}
+		response.Error(c, apierror.Storage("Failed to delete perfect day"))
 		return
 	}
 
-	// Get authenticated username and verify ownership
-	username, exists := c.Get("username")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": gin.H{
-				"code":    "UNAUTHORIZED",
-				"message": "Not authenticated",
-			},
-		})
+	h.audit(c, models.AuditPerfectDayDelete, models.AuditTarget("perfect_day", existingPerfectDay.ID))
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) RestorePerfectDay(c *gin.Context) {
+	// Access was checked by the PerfectDayAccess middleware
+	existingPerfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+	usernameStr := c.GetString("username")
+
+	if !existingPerfectDay.IsDeleted {
+		response.Error(c, apierror.New(apierror.CodeNotDeleted, "Perfect day is not in the trash"))
 		return
 	}
-	usernameStr := username.(string)
 
-	// Verify ownership
-	if existingPerfectDay.Username != usernameStr {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": gin.H{
-				"code":    "FORBIDDEN",
-				"message": "You can only delete your own perfect days",
-			},
-		})
+	if !checkIfMatch(c, existingPerfectDay) {
 		return
 	}
 
-	// Soft delete
-	existingPerfectDay.SoftDelete()
+	existingPerfectDay.Restore()
+	existingPerfectDay.UpdatedBy = usernameStr
 
-	// Save to storage
 	if err := h.Storage.PerfectDayStorage.Save(existingPerfectDay); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "STORAGE_ERROR",
-				"message": "Failed to delete perfect day",
-			},
-		})
+		if errors.Is(err, storage.ErrRevisionConflict) {
+			respondRevisionConflict(c)
+			return
+		}
+		response.Error(c, apierror.Storage("Failed to restore perfect day"))
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	h.audit(c, models.AuditPerfectDayRestore, models.AuditTarget("perfect_day", existingPerfectDay.ID))
+	c.Header("ETag", perfectDayETag(existingPerfectDay))
+	response.JSON(c, http.StatusOK, existingPerfectDay)
+}
+
+// perfectDayETag returns the entity tag for the current revision of a
+// perfect day.
+func perfectDayETag(perfectDay *models.PerfectDay) string {
+	return fmt.Sprintf(`"%s-%d"`, perfectDay.ID, perfectDay.Revision)
+}
+
+// etagMatches reports whether the If-Match or If-None-Match header value
+// lists etag. If-None-Match uses weak comparison, so a W/ prefix is ignored.
+func etagMatches(header, etag string, weak bool) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces the request's If-Match precondition against the
+// stored perfect day. It writes a 412 response and returns false when the
+// client's copy is stale.
+func checkIfMatch(c *gin.Context, perfectDay *models.PerfectDay) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" || etagMatches(ifMatch, perfectDayETag(perfectDay), false) {
+		return true
+	}
+
+	c.Header("ETag", perfectDayETag(perfectDay))
+	respondRevisionConflict(c)
+	return false
+}
+
+// respondRevisionConflict reports that the perfect day changed since the
+// client loaded it: 412 when the client sent a precondition, 409 otherwise.
+func respondRevisionConflict(c *gin.Context) {
+	code := apierror.CodeConflict
+	if c.GetHeader("If-Match") != "" {
+		code = apierror.CodePreconditionFailed
+	}
+
+	response.Error(c, apierror.New(code, "Perfect day has been modified, reload it and try again"))
 }
 
 func createLocationFromRequest(req CreateLocationRequest) *models.Location {
@@ -3,8 +3,9 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,16 +13,7 @@ import (
 func (h *Handlers) SearchPlaces(c *gin.Context) {
 	query := c.Query("q")
 	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "MISSING_QUERY",
-				"message": "Search query is required",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.New(apierror.CodeMissingQuery, "Search query is required"))
 		return
 	}
 
@@ -37,31 +29,20 @@ func (h *Handlers) SearchPlaces(c *gin.Context) {
 	places, err := h.PlacesService.SearchPlaces(ctx, query)
 	if err != nil {
 		// If Places API fails, return empty results (graceful degradation)
-		c.JSON(http.StatusOK, gin.H{
-			"data": gin.H{
-				"places": []interface{}{},
-				"query":  query,
-				"limit":  limit,
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-				"notice":    "Places API unavailable, showing fallback results",
-			},
+		response.JSONWithMeta(c, http.StatusOK, gin.H{
+			"places": []interface{}{},
+			"query":  query,
+			"limit":  limit,
+		}, gin.H{
+			"notice": "Places API unavailable, showing fallback results",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"places": places,
-			"query":  query,
-			"limit":  limit,
-		},
-		"meta": gin.H{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "0.1.0",
-		},
+	response.JSON(c, http.StatusOK, gin.H{
+		"places": places,
+		"query":  query,
+		"limit":  limit,
 	})
 }
 
@@ -69,29 +50,14 @@ func (h *Handlers) GetAreas(c *gin.Context) {
 	// Load all perfect days to extract unique areas
 	allPerfectDays, err := h.Storage.PerfectDayStorage.LoadAll(false)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Failed to load areas",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.New(apierror.CodeInternal, "Failed to load areas"))
 		return
 	}
 
 	// Extract unique areas using search service
 	areas := h.SearchService.GetUniqueAreas(allPerfectDays)
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"areas": areas,
-		},
-		"meta": gin.H{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "0.1.0",
-		},
+	response.JSON(c, http.StatusOK, gin.H{
+		"areas": areas,
 	})
 }
\ No newline at end of file
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RevisionSummary describes one revision in a history listing. Changes are
+// relative to the previous revision and empty for the first one.
+type RevisionSummary struct {
+	Revision  int                  `json:"revision"`
+	Author    string               `json:"author"`
+	CreatedAt time.Time            `json:"created_at"`
+	Changes   []models.FieldChange `json:"changes"`
+}
+
+func (h *Handlers) ListPerfectDayRevisions(c *gin.Context) {
+	perfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+
+	revisions, err := h.Storage.PerfectDayStorage.ListRevisions(perfectDay.ID)
+	if err != nil {
+		response.Error(c, apierror.New(apierror.CodeInternal, "Failed to load revisions"))
+		return
+	}
+
+	summaries := make([]RevisionSummary, len(revisions))
+	for i, revision := range revisions {
+		summaries[i] = RevisionSummary{
+			Revision:  revision.Revision,
+			Author:    revision.Author,
+			CreatedAt: revision.CreatedAt,
+			Changes:   []models.FieldChange{},
+		}
+		if i > 0 {
+			summaries[i].Changes = models.DiffPerfectDays(&revisions[i-1].PerfectDay, &revision.PerfectDay)
+		}
+	}
+
+	response.JSON(c, http.StatusOK, gin.H{
+		"revisions": summaries,
+	})
+}
+
+func (h *Handlers) GetPerfectDayRevision(c *gin.Context) {
+	perfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+
+	revision, ok := h.loadRevision(c, perfectDay.ID)
+	if !ok {
+		return
+	}
+
+	response.JSON(c, http.StatusOK, revision)
+}
+
+func (h *Handlers) RestorePerfectDayRevision(c *gin.Context) {
+	// Access was checked by the PerfectDayAccess middleware
+	perfectDay := c.MustGet("perfect_day").(*models.PerfectDay)
+	usernameStr := c.GetString("username")
+
+	if !checkIfMatch(c, perfectDay) {
+		return
+	}
+
+	revision, ok := h.loadRevision(c, perfectDay.ID)
+	if !ok {
+		return
+	}
+
+	perfectDay.RestoreFrom(&revision.PerfectDay)
+	perfectDay.UpdatedBy = usernameStr
+
+	if err := h.Storage.PerfectDayStorage.Save(perfectDay); err != nil {
+		if errors.Is(err, storage.ErrRevisionConflict) {
+			respondRevisionConflict(c)
+			return
+		}
+		response.Error(c, apierror.Storage("Failed to restore perfect day"))
+		return
+	}
+
+	h.auditAs(c, usernameStr, models.AuditPerfectDayUpdate, models.AuditTarget("perfect_day", perfectDay.ID), "restored revision "+c.Param("rev"))
+	c.Header("ETag", perfectDayETag(perfectDay))
+	response.JSON(c, http.StatusOK, perfectDay)
+}
+
+// loadRevision loads the revision named by the :rev parameter.
+func (h *Handlers) loadRevision(c *gin.Context, id string) (*models.PerfectDayRevision, bool) {
+	number, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || number < 1 {
+		response.Error(c, apierror.New(apierror.CodeInvalidRevision, "Revision must be a positive number"))
+		return nil, false
+	}
+
+	revision, err := h.Storage.PerfectDayStorage.LoadRevision(id, number)
+	if errors.Is(err, storage.ErrRevisionNotFound) {
+		response.Error(c, apierror.New(apierror.CodeRevisionNotFound, "Revision not found"))
+		return nil, false
+	}
+	if err != nil {
+		response.Error(c, apierror.New(apierror.CodeInternal, "Failed to load revision"))
+		return nil, false
+	}
+
+	return revision, true
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/cookies"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logout ends the session the request was made with.
+func (h *Handlers) Logout(c *gin.Context) {
+	token, _ := c.Cookie(cookies.Session)
+	if err := h.AuthService.Logout(token); err != nil {
+		respondSessionStorageError(c)
+		return
+	}
+
+	h.audit(c, models.AuditLogout, models.AuditTarget("session", models.SessionID(token)))
+	h.clearSessionCookie(c)
+	c.Status(http.StatusNoContent)
+}
+
+// SessionResponse describes an active session. Current marks the session
+// of the request.
+type SessionResponse struct {
+	ID         string `json:"id"`
+	CreatedAt  string `json:"created_at" format:"date-time"`
+	LastSeenAt string `json:"last_seen_at" format:"date-time"`
+	ExpiresAt  string `json:"expires_at" format:"date-time"`
+	Current    bool   `json:"current"`
+}
+
+// ListSessions lists the active sessions of the authenticated user, marking
+// the one the request was made with.
+func (h *Handlers) ListSessions(c *gin.Context) {
+	sessions, err := h.AuthService.ListSessions(c.GetString("username"))
+	if err != nil {
+		respondSessionStorageError(c)
+		return
+	}
+
+	token, _ := c.Cookie(cookies.Session)
+	currentID := models.SessionID(token)
+
+	data := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		data = append(data, SessionResponse{
+			ID:         session.ID,
+			CreatedAt:  session.CreatedAt.UTC().Format(time.RFC3339),
+			LastSeenAt: session.LastSeenAt.UTC().Format(time.RFC3339),
+			ExpiresAt:  session.ExpiresAt.UTC().Format(time.RFC3339),
+			Current:    session.ID == currentID,
+		})
+	}
+
+	response.JSON(c, http.StatusOK, data)
+}
+
+// RevokeSession logs out one session of the authenticated user, e.g. a lost
+// device.
+func (h *Handlers) RevokeSession(c *gin.Context) {
+	id := c.Param("id")
+
+	err := h.AuthService.RevokeSession(c.GetString("username"), id)
+	if errors.Is(err, storage.ErrSessionNotFound) {
+		response.Error(c, apierror.NotFound("Session not found"))
+		return
+	}
+	if err != nil {
+		respondSessionStorageError(c)
+		return
+	}
+
+	h.audit(c, models.AuditSessionRevoke, models.AuditTarget("session", id))
+	token, _ := c.Cookie(cookies.Session)
+	if id == models.SessionID(token) {
+		h.clearSessionCookie(c)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeAllSessions logs the authenticated user out everywhere, including
+// the session the request was made with.
+func (h *Handlers) RevokeAllSessions(c *gin.Context) {
+	count, err := h.AuthService.LogoutEverywhere(c.GetString("username"))
+	if err != nil {
+		respondSessionStorageError(c)
+		return
+	}
+
+	h.auditAs(c, c.GetString("username"), models.AuditSessionRevoke, models.AuditTarget("user", c.GetString("username")), "all sessions")
+	h.clearSessionCookie(c)
+	response.JSON(c, http.StatusOK, gin.H{
+		"revoked": count,
+	})
+}
+
+// clearSessionCookie logs the browser out.
+func (h *Handlers) clearSessionCookie(c *gin.Context) {
+	h.Cookies.Clear(c, cookies.Session, "/")
+	h.Cookies.Clear(c, cookies.CSRF, "/")
+}
+
+func respondSessionStorageError(c *gin.Context) {
+	response.Error(c, apierror.Storage("Failed to update sessions"))
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateTokenRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+	// ExpiresInDays limits the token's lifetime; 0 means it never expires.
+	ExpiresInDays int `json:"expires_in_days" binding:"min=0"`
+}
+
+// CreateToken issues a personal API token. The secret is only part of this
+// response.
+func (h *Handlers) CreateToken(c *gin.Context) {
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	token, err := h.AuthService.CreateToken(c.GetString("username"), req.Name, req.Scopes, ttl)
+	if err != nil {
+		response.Error(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	h.audit(c, models.AuditTokenCreate, models.AuditTarget("token", token.ID))
+	data := tokenResponse(token)
+	data.Token = token.Token
+
+	response.JSON(c, http.StatusCreated, data)
+}
+
+func (h *Handlers) ListTokens(c *gin.Context) {
+	tokens, err := h.AuthService.ListTokens(c.GetString("username"))
+	if err != nil {
+		response.Error(c, apierror.Storage("Failed to list API tokens"))
+		return
+	}
+
+	data := make([]TokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		data = append(data, tokenResponse(token))
+	}
+
+	response.JSON(c, http.StatusOK, data)
+}
+
+func (h *Handlers) RevokeToken(c *gin.Context) {
+	err := h.AuthService.RevokeToken(c.GetString("username"), c.Param("id"))
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		response.Error(c, apierror.NotFound("API token not found"))
+		return
+	}
+	if err != nil {
+		response.Error(c, apierror.Storage("Failed to revoke API token"))
+		return
+	}
+
+	h.audit(c, models.AuditTokenRevoke, models.AuditTarget("token", c.Param("id")))
+	c.Status(http.StatusNoContent)
+}
+
+// TokenResponse describes an API token. The secret Token is only part of
+// the response creating it.
+type TokenResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at" format:"date-time"`
+	LastUsedAt *string  `json:"last_used_at" format:"date-time"`
+	ExpiresAt  *string  `json:"expires_at" format:"date-time"`
+	Token      string   `json:"token,omitempty"`
+}
+
+// tokenResponse describes a token without its secret.
+func tokenResponse(token *models.APIToken) TokenResponse {
+	data := TokenResponse{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if token.LastUsedAt != nil {
+		lastUsedAt := token.LastUsedAt.UTC().Format(time.RFC3339)
+		data.LastUsedAt = &lastUsedAt
+	}
+	if token.ExpiresAt != nil {
+		expiresAt := token.ExpiresAt.UTC().Format(time.RFC3339)
+		data.ExpiresAt = &expiresAt
+	}
+	return data
+}
@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
 	"perfect-day/pkg/models"
 	"perfect-day/pkg/search"
-	"strconv"
+	"perfect-day/pkg/storage"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,150 +15,175 @@ import (
 func (h *Handlers) GetUserProfile(c *gin.Context) {
 	username := c.Param("username")
 	if username == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "MISSING_USERNAME",
-				"message": "Username is required",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.New(apierror.CodeMissingUsername, "Username is required"))
 		return
 	}
 
 	// Check if user exists
 	if !h.Storage.UserStorage.Exists(username) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": gin.H{
-				"code":    "USER_NOT_FOUND",
-				"message": "User not found",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.New(apierror.CodeUserNotFound, "User not found"))
 		return
 	}
 
 	// Load user profile
 	user, err := h.Storage.UserStorage.Load(username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Failed to load user profile",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"username":   user.Username,
-			"timezone":   user.Timezone,
-			"created_at": user.CreatedAt.Format(time.RFC3339),
-		},
-		"meta": gin.H{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "0.1.0",
-		},
-	})
+		response.Error(c, apierror.New(apierror.CodeInternal, "Failed to load user profile"))
+		return
+	}
+
+	response.JSON(c, http.StatusOK, userResponse(user))
+}
+
+type UpdateUserRequest struct {
+	Timezone    *string `json:"timezone"`
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+}
+
+// UpdateUserProfile changes the fields present in the request body. Users
+// can only change their own profile.
+func (h *Handlers) UpdateUserProfile(c *gin.Context) {
+	username := c.Param("username")
+	if !requireSelf(c, username, "You can only change your own profile") {
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apierror.FromBinding(err))
+		return
+	}
+
+	user, err := h.Storage.UserStorage.Load(username)
+	if err != nil {
+		respondUserNotFound(c)
+		return
+	}
+
+	if err := user.UpdateProfile(req.Timezone, req.DisplayName, req.Bio); err != nil {
+		response.Error(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	if err := h.Storage.UserStorage.Save(user); err != nil {
+		response.Error(c, apierror.Storage("Failed to save user profile"))
+		return
+	}
+
+	h.audit(c, models.AuditUserUpdate, models.AuditTarget("user", username))
+	response.JSON(c, http.StatusOK, userResponse(user))
+}
+
+// DeleteUser deletes the authenticated user's account and logs them out
+// everywhere. Their perfect days are deleted, or kept without an owner with
+// ?perfect_days=anonymize.
+func (h *Handlers) DeleteUser(c *gin.Context) {
+	username := c.Param("username")
+	if !requireSelf(c, username, "You can only delete your own account") {
+		return
+	}
+
+	mode := c.DefaultQuery("perfect_days", "delete")
+	if mode != "delete" && mode != "anonymize" {
+		response.Error(c, apierror.Invalid("perfect_days", "must be delete or anonymize"))
+		return
+	}
+
+	if !h.Storage.UserStorage.Exists(username) {
+		respondUserNotFound(c)
+		return
+	}
+
+	if _, err := storage.DeleteUser(h.Storage, username, mode == "anonymize"); err != nil {
+		response.Error(c, apierror.Storage("Failed to delete user"))
+		return
+	}
+
+	h.auditAs(c, username, models.AuditUserDelete, models.AuditTarget("user", username), "perfect days: "+mode)
+	h.clearSessionCookie(c)
+	c.Status(http.StatusNoContent)
+}
+
+// requireSelf rejects the request unless it was made by username.
+func requireSelf(c *gin.Context, username, message string) bool {
+	if c.GetString("username") == username {
+		return true
+	}
+
+	response.Error(c, apierror.Forbidden(message))
+	return false
+}
+
+func respondUserNotFound(c *gin.Context) {
+	response.Error(c, apierror.New(apierror.CodeUserNotFound, "User not found"))
+}
+
+// UserProfile is the public profile of a user. Role is only part of the
+// admin's view of users.
+type UserProfile struct {
+	Username    string `json:"username"`
+	Timezone    string `json:"timezone"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	CreatedAt   string `json:"created_at" format:"date-time"`
+	Role        string `json:"role,omitempty"`
+}
+
+func userResponse(user *models.User) UserProfile {
+	return UserProfile{
+		Username:    user.Username,
+		Timezone:    user.Timezone,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		CreatedAt:   user.CreatedAt.Format(time.RFC3339),
+	}
 }
 
 func (h *Handlers) GetUserPerfectDays(c *gin.Context) {
 	username := c.Param("username")
 	if username == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "MISSING_USERNAME",
-				"message": "Username is required",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.New(apierror.CodeMissingUsername, "Username is required"))
 		return
 	}
 
 	// Check if user exists
 	if !h.Storage.UserStorage.Exists(username) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": gin.H{
-				"code":    "USER_NOT_FOUND",
-				"message": "User not found",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
-		return
-	}
-
-	// Get query parameters for pagination
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-	includeDeleted := c.DefaultQuery("include_deleted", "false") == "true"
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
+		response.Error(c, apierror.New(apierror.CodeUserNotFound, "User not found"))
+		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
-	}
+	includeDeleted := c.DefaultQuery("include_deleted", "false") == "true"
 
 	// Load user's perfect days
 	allUserPerfectDays, err := h.Storage.PerfectDayStorage.LoadAllByUser(username, includeDeleted)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Failed to load user's perfect days",
-			},
-			"meta": gin.H{
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"version":   "0.1.0",
-			},
-		})
+		response.Error(c, apierror.New(apierror.CodeInternal, "Failed to load user's perfect days"))
 		return
 	}
 
-	// Apply pagination
-	total := len(allUserPerfectDays)
-	end := offset + limit
-	if end > total {
-		end = total
-	}
-
-	var paginatedResults []*models.PerfectDay
-	if offset < total {
-		paginatedResults = allUserPerfectDays[offset:end]
+	// Only the owner and admins see the trash; for everyone else the flag
+	// changes nothing
+	if includeDeleted {
+		value, _ := c.Get("user")
+		user, _ := value.(*models.User)
+		visible := allUserPerfectDays[:0]
+		for _, perfectDay := range allUserPerfectDays {
+			if h.Policy.CanSee(user, perfectDay) {
+				visible = append(visible, perfectDay)
+			}
+		}
+		allUserPerfectDays = visible
 	}
 
-	// Use search service to format results consistently
-	searchResults := search.SearchResult{
-		PerfectDays: paginatedResults,
-		Total:       total,
-		Limit:       limit,
-		Offset:      offset,
+	// Page them like search results, newest first by default
+	searchResults := h.searchPage(c, allUserPerfectDays, search.SearchCriteria{
+		SortBy:    c.DefaultQuery("sort", "created_at"),
+		SortOrder: c.DefaultQuery("order", "desc"),
+	})
+	if searchResults == nil {
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": searchResults,
-		"meta": gin.H{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"version":   "0.1.0",
-		},
-	})
+	response.JSON(c, http.StatusOK, searchResults)
 }
\ No newline at end of file
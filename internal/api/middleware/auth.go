@@ -2,50 +2,136 @@ package middleware
 
 import (
 	"net/http"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/cookies"
+	"perfect-day/internal/api/response"
 	"perfect-day/pkg/auth"
-	"time"
+	"perfect-day/pkg/models"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthRequired is middleware that validates authentication for protected routes
-func AuthRequired(authService *auth.AuthService) gin.HandlerFunc {
+// AuthRequired is middleware that validates authentication for protected routes.
+// Requests authenticate with the session cookie or with a personal API token
+// sent as "Authorization: Bearer <token>". Cookie-authenticated requests
+// that change anything must also send the session's CSRF token in the
+// X-CSRF-Token header; bearer tokens are never sent by browsers on their
+// own, so token requests are exempt.
+func AuthRequired(authService *auth.AuthService, cookiePolicy cookies.Policy) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		sessionID, err := c.Cookie("session_id")
+		if bearerToken(c) != "" {
+			authenticateToken(c, authService)
+			return
+		}
+
+		sessionID, err := c.Cookie(cookies.Session)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "UNAUTHORIZED",
-					"message": "Not authenticated",
-				},
-				"meta": gin.H{
-					"timestamp": time.Now().UTC().Format(time.RFC3339),
-					"version":   "0.1.0",
-				},
-			})
-			c.Abort()
+			respondUnauthorized(c)
 			return
 		}
 
 		user, err := authService.ValidateSession(sessionID)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "UNAUTHORIZED",
-					"message": "Not authenticated",
-				},
-				"meta": gin.H{
-					"timestamp": time.Now().UTC().Format(time.RFC3339),
-					"version":   "0.1.0",
-				},
-			})
-			c.Abort()
+			respondUnauthorized(c)
+			return
+		}
+
+		if !safeMethod(c.Request.Method) && !auth.CheckCSRFToken(sessionID, c.GetHeader("X-CSRF-Token")) {
+			response.Error(c, apierror.New(apierror.CodeCSRFTokenInvalid, "Missing or invalid X-CSRF-Token header"))
 			return
 		}
 
+		// Sessions slide, so the cookies are renewed along with them
+		maxAge := int(auth.SessionTTL.Seconds())
+		cookiePolicy.Set(c, cookies.Session, sessionID, "/", maxAge, true)
+		cookiePolicy.Set(c, cookies.CSRF, auth.CSRFToken(sessionID), "/", maxAge, false)
+
 		// Store user information in context for use by handlers
 		c.Set("user", user)
 		c.Set("username", user.Username)
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// OptionalAuth is middleware for public routes that scripts may call with an
+// API token. Requests without one stay anonymous; the session cookie is not
+// consulted, so a stale cookie never breaks public pages.
+func OptionalAuth(authService *auth.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if bearerToken(c) == "" {
+			c.Next()
+			return
+		}
+		authenticateToken(c, authService)
+	}
+}
+
+// RequireScope is middleware that rejects requests made with an API token
+// lacking scope. Session and anonymous requests are not scoped. It must run
+// after AuthRequired or OptionalAuth.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("api_token")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		if token := value.(*models.APIToken); !token.HasScope(scope) {
+			response.Error(c, apierror.New(apierror.CodeInsufficientScope, "API token lacks the "+scope+" scope"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SessionRequired is middleware for account management routes, which API
+// tokens must not reach: a leaked token could otherwise mint more tokens or
+// lock the owner out. It must run after AuthRequired.
+func SessionRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("api_token"); exists {
+			response.Error(c, apierror.New(apierror.CodeSessionRequired, "This endpoint cannot be used with an API token, log in instead"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func authenticateToken(c *gin.Context, authService *auth.AuthService) {
+	user, token, err := authService.ValidateToken(bearerToken(c))
+	if err != nil {
+		respondUnauthorized(c)
+		return
+	}
+
+	c.Set("user", user)
+	c.Set("username", user.Username)
+	c.Set("api_token", token)
+	c.Next()
+}
+
+// safeMethod reports whether method is read-only, and so needs no CSRF token.
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > len("Bearer ") && strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(header[len("Bearer "):])
+	}
+	return ""
+}
+
+func respondUnauthorized(c *gin.Context) {
+	response.Error(c, apierror.Unauthorized())
+}
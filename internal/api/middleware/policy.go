@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"errors"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole is middleware that only lets users with role (or a more
+// privileged one) through. It must run after AuthRequired.
+func RequireRole(policy *auth.Policy, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !policy.HasRole(currentUser(c), role) {
+			response.Error(c, apierror.Forbidden("The "+role+" role is required"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// PerfectDayAccess is middleware that loads the perfect day named by the :id
+// parameter and lets the request through only if the policy allows action
+// on it. Handlers find the perfect day under "perfect_day". A delete with
+// ?hard=true counts as a purge. It must run after AuthRequired, or after
+// OptionalAuth for reads.
+func PerfectDayAccess(perfectDays storage.PerfectDayRepository, policy *auth.Policy, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		action := action
+		if action == auth.ActionDelete && c.Query("hard") == "true" {
+			action = auth.ActionPurge
+		}
+
+		perfectDay, err := perfectDays.LoadByID(c.Param("id"))
+		if err != nil && !errors.Is(err, storage.ErrPerfectDayNotFound) {
+			response.Error(c, apierror.New(apierror.CodeInternal, "Failed to load perfect day"))
+			return
+		}
+
+		user := currentUser(c)
+		inTrashOnly := action != auth.ActionPurge && action != auth.ActionRestore
+		if perfectDay == nil || !policy.CanSee(user, perfectDay) || (perfectDay.IsDeleted && inTrashOnly) {
+			response.Error(c, apierror.NotFound("Perfect day not found"))
+			return
+		}
+
+		if !policy.Can(user, action, perfectDay) {
+			response.Error(c, apierror.Forbidden("You are not allowed to "+action+" this perfect day"))
+			return
+		}
+
+		c.Set("perfect_day", perfectDay)
+		c.Next()
+	}
+}
+
+// currentUser returns the authenticated user, or nil for anonymous requests.
+func currentUser(c *gin.Context) *models.User {
+	user, _ := c.Get("user")
+	authenticated, _ := user.(*models.User)
+	return authenticated
+}
@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"perfect-day/internal/api/apierror"
+	"perfect-day/internal/api/response"
+	"perfect-day/pkg/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicy allows Requests per Period to each client. Tokens refill
+// continuously, so a client that has been idle may burst up to Requests.
+// The zero policy allows everything.
+type RateLimitPolicy struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Default rate limit policies.
+var (
+	// DefaultAPIRateLimit applies to every API route except the health
+	// checks.
+	DefaultAPIRateLimit = RateLimitPolicy{Requests: 300, Period: time.Minute}
+	// DefaultAuthRateLimit applies to the routes that log in or create
+	// accounts, on top of the lockout of accounts after failed logins.
+	DefaultAuthRateLimit = RateLimitPolicy{Requests: 20, Period: time.Minute}
+	// DefaultPlacesRateLimit applies to place searches, which spend the
+	// Google Places quota.
+	DefaultPlacesRateLimit = RateLimitPolicy{Requests: 30, Period: time.Minute}
+)
+
+// RateLimits are the policies of the routes that are rate limited.
+type RateLimits struct {
+	API    RateLimitPolicy
+	Auth   RateLimitPolicy
+	Places RateLimitPolicy
+}
+
+// Disabled reports whether the policy allows everything.
+func (p RateLimitPolicy) Disabled() bool {
+	return p.Requests <= 0 || p.Period <= 0
+}
+
+func (p RateLimitPolicy) String() string {
+	if p.Disabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", p.Requests, p.Period)
+}
+
+// ParseRateLimitPolicy parses a policy such as "300/m", "10/1m" or "1000/h".
+// "off" disables the limit and an empty value returns def.
+func ParseRateLimitPolicy(value string, def RateLimitPolicy) (RateLimitPolicy, error) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "":
+		return def, nil
+	case "off", "0":
+		return RateLimitPolicy{}, nil
+	}
+
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimitPolicy{}, fmt.Errorf("invalid rate limit %q (expected e.g. 300/m)", value)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return RateLimitPolicy{}, fmt.Errorf("invalid rate limit %q: bad request count", value)
+	}
+
+	switch period {
+	case "s":
+		period = "1s"
+	case "m":
+		period = "1m"
+	case "h":
+		period = "1h"
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateLimitPolicy{}, fmt.Errorf("invalid rate limit %q: bad period", value)
+	}
+
+	return RateLimitPolicy{Requests: n, Period: d}, nil
+}
+
+// RateLimiter keeps a token bucket per client for one policy.
+type RateLimiter struct {
+	policy RateLimitPolicy
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewRateLimiter(policy RateLimitPolicy) *RateLimiter {
+	return &RateLimiter{
+		policy:    policy,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// take spends a token of key's bucket if one is left. It returns the whole
+// tokens left, how long until the bucket is full again and, when the
+// request is refused, how long until a token is available.
+func (rl *RateLimiter) take(key string, now time.Time) (bool, int, time.Duration, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	limit := float64(rl.policy.Requests)
+	perToken := rl.policy.Period / time.Duration(rl.policy.Requests)
+
+	// Full buckets are the same as missing ones, so they are dropped once
+	// per period to keep memory bounded by the number of active clients
+	if now.Sub(rl.lastSweep) >= rl.policy.Period {
+		for k, b := range rl.buckets {
+			if rl.refill(b, now) >= limit {
+				delete(rl.buckets, k)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	bucket, ok := rl.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: limit, updated: now}
+		rl.buckets[key] = bucket
+	}
+	bucket.tokens = rl.refill(bucket, now)
+	bucket.updated = now
+
+	allowed := bucket.tokens >= 1
+	var retryAfter time.Duration
+	if allowed {
+		bucket.tokens--
+	} else {
+		retryAfter = time.Duration((1 - bucket.tokens) * float64(perToken))
+	}
+
+	reset := time.Duration((limit - bucket.tokens) * float64(perToken))
+	return allowed, int(bucket.tokens), reset, retryAfter
+}
+
+func (rl *RateLimiter) refill(bucket *tokenBucket, now time.Time) float64 {
+	elapsed := now.Sub(bucket.updated)
+	tokens := bucket.tokens + float64(elapsed)/float64(rl.policy.Period)*float64(rl.policy.Requests)
+	return math.Min(tokens, float64(rl.policy.Requests))
+}
+
+// RateLimit is middleware that limits requests with limiter. Requests are
+// counted per API token, user or client IP, whichever is known when the
+// limit is checked, so it should run after AuthRequired or OptionalAuth on
+// routes that use them. Responses carry RateLimit-* headers, and refused
+// requests get 429 with Retry-After.
+func RateLimit(limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter.policy.Disabled() {
+			c.Next()
+			return
+		}
+
+		allowed, remaining, reset, retryAfter := limiter.take(rateLimitKey(c), time.Now())
+
+		c.Header("RateLimit-Limit", strconv.Itoa(limiter.policy.Requests))
+		c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limiter.policy.Requests, ceilSeconds(limiter.policy.Period)))
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+			response.Error(c, apierror.New(apierror.CodeRateLimited, "Too many requests, try again later"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey identifies the client a request is counted against.
+func rateLimitKey(c *gin.Context) string {
+	if value, exists := c.Get("api_token"); exists {
+		return "token:" + value.(*models.APIToken).ID
+	}
+	if username := c.GetString("username"); username != "" {
+		return "user:" + username
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,9 @@
+package openapi
+
+import _ "embed"
+
+// DocsPage is a page that renders the document served next to it as
+// openapi.json, for reading the API description in a browser.
+//
+//go:embed docs.html
+var DocsPage []byte
@@ -0,0 +1,338 @@
+// Package openapi generates the OpenAPI 3.1 description of the API from the
+// Go types its handlers read and write, so the description follows the code.
+package openapi
+
+import (
+	"net/http"
+	"perfect-day/internal/api/apierror"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Auth says how a route authenticates its caller.
+type Auth int
+
+const (
+	// AuthNone routes are public.
+	AuthNone Auth = iota
+	// AuthOptional routes are public but show more to authenticated users.
+	AuthOptional
+	// AuthRequired routes take a session cookie or an API token.
+	AuthRequired
+	// AuthSession routes take a session cookie only.
+	AuthSession
+)
+
+// Param is a query parameter or header of a route.
+type Param struct {
+	Name        string
+	Description string
+	// Type is the parameter's JSON type; empty means string.
+	Type     string
+	Required bool
+	Default  interface{}
+	Enum     []string
+}
+
+// Route describes one operation of the API.
+type Route struct {
+	Method string
+	// Path is the gin path relative to the API base, e.g.
+	// "/perfect-days/:id"; its parameters become path parameters. A colon
+	// inside a segment is literal, as in "/perfect-days/:id/activities:reorder".
+	Path string
+	// GinPath is the path gin routes the operation by, if it cannot route
+	// Path itself.
+	GinPath     string
+	Tag         string
+	Summary     string
+	Description string
+	Auth        Auth
+	Query       []Param
+	Headers     []Param
+	// Request is a value of the type of the JSON request body, if any.
+	Request interface{}
+	// RequestTypes map media types of the request body to a value of its
+	// type, for bodies that are not plain JSON.
+	RequestTypes map[string]interface{}
+	// Status is the status of a successful response, 200 by default.
+	Status int
+	// Response is a value of the type of the data of a successful
+	// response, nil for responses without a body.
+	Response interface{}
+	// ContentType replaces the JSON envelope of a successful response,
+	// e.g. for downloads.
+	ContentType string
+	// Errors are the error codes the route responds with, besides those
+	// of its authentication and rate limit.
+	Errors []apierror.Code
+	// Unlimited routes are not rate limited.
+	Unlimited bool
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Servers    []Server                        `json:"servers"`
+	Tags       []Tag                           `json:"tags,omitempty"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	Responses       map[string]Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response or, with Ref set, a reference to a shared one.
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// The security schemes of authenticated routes.
+const (
+	sessionScheme = "sessionCookie"
+	tokenScheme   = "apiToken"
+)
+
+var pathParam = regexp.MustCompile(`/:([A-Za-z_]+)`)
+
+// Path turns a gin path into an OpenAPI one, e.g. "/perfect-days/:id" into
+// "/perfect-days/{id}".
+func Path(ginPath string) string {
+	return pathParam.ReplaceAllString(ginPath, "/{$1}")
+}
+
+// Build describes routes in a document for the API at baseURL.
+func Build(info Info, baseURL string, routes []Route) *Document {
+	s := newSchemas()
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Servers: []Server{{URL: baseURL}},
+		Paths:   map[string]map[string]Operation{},
+	}
+
+	tags := map[string]bool{}
+	for _, route := range routes {
+		path := Path(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]Operation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = operation(s, route)
+		if route.Tag != "" && !tags[route.Tag] {
+			tags[route.Tag] = true
+			doc.Tags = append(doc.Tags, Tag{Name: route.Tag})
+		}
+	}
+
+	s.components["Meta"] = s.of(Fields{"timestamp": "", "version": ""})
+	s.components["Meta"].Properties["timestamp"].Format = "date-time"
+	s.components["ErrorResponse"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"error": s.of(apierror.Error{}),
+			"meta":  {Ref: "#/components/schemas/Meta"},
+		},
+		Required: []string{"error", "meta"},
+	}
+
+	doc.Components = Components{
+		Schemas: s.components,
+		Responses: map[string]Response{
+			"Error": {
+				Description: "The error; its code says what went wrong",
+				Content: map[string]MediaType{
+					"application/json": {Schema: &Schema{Ref: "#/components/schemas/ErrorResponse"}},
+				},
+			},
+		},
+		SecuritySchemes: map[string]SecurityScheme{
+			sessionScheme: {Type: "apiKey", In: "cookie", Name: "session_id"},
+			tokenScheme:   {Type: "http", Scheme: "bearer"},
+		},
+	}
+	return doc
+}
+
+func operation(s *schemas, route Route) Operation {
+	op := Operation{
+		OperationID: operationID(route),
+		Summary:     route.Summary,
+		Description: route.Description,
+		Responses:   map[string]Response{},
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, param := range route.Query {
+		op.Parameters = append(op.Parameters, parameter(param, "query"))
+	}
+	for _, param := range route.Headers {
+		op.Parameters = append(op.Parameters, parameter(param, "header"))
+	}
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: s.of(route.Request)}},
+		}
+	}
+	if route.RequestTypes != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{}}
+		for mediaType, value := range route.RequestTypes {
+			op.RequestBody.Content[mediaType] = MediaType{Schema: s.of(value)}
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	switch {
+	case route.ContentType != "":
+		success.Content = map[string]MediaType{
+			route.ContentType: {Schema: &Schema{Type: "string", Format: "binary"}},
+		}
+	case route.Response != nil:
+		success.Content = map[string]MediaType{
+			"application/json": {Schema: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"data": s.of(route.Response),
+					"meta": {Ref: "#/components/schemas/Meta"},
+				},
+				Required: []string{"data", "meta"},
+			}},
+		}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	codes := route.Errors
+	if !route.Unlimited {
+		codes = append(codes, apierror.CodeRateLimited)
+	}
+	switch route.Auth {
+	case AuthOptional:
+		op.Security = []map[string][]string{{}, {sessionScheme: {}}, {tokenScheme: {}}}
+		codes = append(codes, apierror.CodeUnauthorized)
+	case AuthRequired:
+		op.Security = []map[string][]string{{sessionScheme: {}}, {tokenScheme: {}}}
+		codes = append(codes, apierror.CodeUnauthorized, apierror.CodeInsufficientScope)
+	case AuthSession:
+		op.Security = []map[string][]string{{sessionScheme: {}}}
+		codes = append(codes, apierror.CodeUnauthorized, apierror.CodeSessionRequired)
+	}
+	if route.Auth >= AuthRequired && route.Method != http.MethodGet {
+		codes = append(codes, apierror.CodeCSRFTokenInvalid)
+	}
+	for _, code := range codes {
+		op.Responses[strconv.Itoa(code.Status())] = Response{Ref: "#/components/responses/Error"}
+	}
+	return op
+}
+
+func parameter(param Param, in string) Parameter {
+	schema := &Schema{Type: param.Type, Enum: param.Enum, Default: param.Default}
+	if param.Type == "" {
+		schema.Type = "string"
+	}
+	return Parameter{
+		Name:        param.Name,
+		In:          in,
+		Description: param.Description,
+		Required:    param.Required,
+		Schema:      schema,
+	}
+}
+
+// operationID names a route after its method and path, e.g.
+// "getPerfectDaysIdRevisions".
+func operationID(route Route) string {
+	id := strings.ToLower(route.Method)
+	words := strings.FieldsFunc(route.Path, func(r rune) bool {
+		return r == '/' || r == '-' || r == '_' || r == ':'
+	})
+	for _, word := range words {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+	return id
+}
+
+// Operations lists the method and OpenAPI path of every operation of doc,
+// sorted.
+func (doc *Document) Operations() []string {
+	var operations []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			operations = append(operations, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
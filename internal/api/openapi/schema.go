@@ -0,0 +1,234 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Schema is a JSON Schema as used by OpenAPI 3.1.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Fields describes an object built from a gin.H in a handler: the schema of
+// each property is that of the type of its value, e.g.
+//
+//	Fields{"areas": []string{}}
+type Fields map[string]interface{}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	fieldsType = reflect.TypeOf(Fields{})
+)
+
+// schemas generates schemas from Go types. Named structs become components
+// referenced by name, so each is described once however often it is used.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: map[string]*Schema{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+// of returns the schema of the type of value, which may be a Fields.
+func (s *schemas) of(value interface{}) *Schema {
+	if fields, ok := value.(Fields); ok {
+		return s.fields(fields)
+	}
+	return s.schema(reflect.TypeOf(value))
+}
+
+func (s *schemas) fields(fields Fields) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for name, value := range fields {
+		schema.Properties[name] = s.of(value)
+		schema.Required = append(schema.Required, name)
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (s *schemas) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case fieldsType:
+		return &Schema{Type: "object"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := s.schema(t.Elem())
+		if primitive, ok := schema.Type.(string); ok {
+			schema.Type = []string{primitive, "null"}
+		}
+		return schema
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	default:
+		// interface{} and anything JSON cannot describe better
+		return &Schema{}
+	}
+}
+
+// component registers the named struct t and returns its component name.
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := s.components[name]; taken {
+		// Same name in another package, e.g. two Request types
+		pkg := []rune(path.Base(t.PkgPath()))
+		pkg[0] = unicode.ToUpper(pkg[0])
+		name = string(pkg) + name
+	}
+
+	// Register the name before describing the fields, so types referring
+	// to themselves end in a reference
+	s.names[t] = name
+	s.components[name] = nil
+	s.components[name] = s.object(t)
+	return name
+}
+
+// object describes the JSON encoding of a struct: its exported fields under
+// their JSON names, refined by the binding and format tags. A struct with
+// binding tags is a request body, where only fields with binding:"required"
+// are required; in other structs every field encoding/json always writes is.
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.addFields(schema, t, hasBindings(t))
+	return schema
+}
+
+func hasBindings(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("binding"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *schemas) addFields(schema *Schema, t reflect.Type, request bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(schema, embedded, request)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.schema(field.Type)
+		if format := field.Tag.Get("format"); format != "" {
+			property.Format = format
+		}
+		required := !request && !strings.Contains(options, "omitempty")
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			applyBinding(property, rule)
+			if rule == "required" {
+				required = true
+			}
+		}
+
+		schema.Properties[name] = property
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// applyBinding adds the constraint of a validator rule such as "min=0" or
+// "oneof=a b" to property. Rules without an equivalent are left out.
+func applyBinding(property *Schema, rule string) {
+	key, param, _ := strings.Cut(rule, "=")
+	switch key {
+	case "oneof":
+		property.Enum = strings.Fields(param)
+	case "min", "max":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		switch property.Type {
+		case "integer", "number":
+			if key == "min" {
+				property.Minimum = &n
+			} else {
+				property.Maximum = &n
+			}
+		case "string":
+			length := int(n)
+			if key == "min" {
+				property.MinLength = &length
+			} else {
+				property.MaxLength = &length
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package response writes the API's JSON envelopes. Successful responses
+// are {"data": ..., "meta": ...} and failed ones {"error": ..., "meta": ...},
+// so every route has the same shape.
+package response
+
+import (
+	"perfect-day/internal/api/apierror"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the API version reported in every envelope.
+const Version = "0.1.0"
+
+// Meta returns the meta block of a response.
+func Meta() gin.H {
+	return gin.H{
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"version":   Version,
+	}
+}
+
+// JSON writes data with the given status.
+func JSON(c *gin.Context, status int, data interface{}) {
+	c.JSON(status, gin.H{
+		"data": data,
+		"meta": Meta(),
+	})
+}
+
+// JSONWithMeta writes data like JSON, adding the entries of meta to the
+// meta block, e.g. a notice about degraded results.
+func JSONWithMeta(c *gin.Context, status int, data interface{}, meta gin.H) {
+	merged := Meta()
+	for key, value := range meta {
+		merged[key] = value
+	}
+	c.JSON(status, gin.H{
+		"data": data,
+		"meta": merged,
+	})
+}
+
+// Error writes err with its status and stops the handler chain.
+func Error(c *gin.Context, err *apierror.Error) {
+	c.AbortWithStatusJSON(err.Status(), gin.H{
+		"error": err,
+		"meta":  Meta(),
+	})
+}
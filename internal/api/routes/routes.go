@@ -4,11 +4,12 @@ import (
 	"perfect-day/internal/api/handlers"
 	"perfect-day/internal/api/middleware"
 	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, h *handlers.Handlers, authService *auth.AuthService) {
+func SetupRoutes(router *gin.Engine, h *handlers.Handlers, authService *auth.AuthService, policy *auth.Policy, rateLimits middleware.RateLimits) {
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 
@@ -16,36 +17,95 @@ func SetupRoutes(router *gin.Engine, h *handlers.Handlers, authService *auth.Aut
 	v1.GET("/health", h.HealthCheck)
 	v1.GET("/version", h.Version)
 
+	// API description, see handlers.APIRoutes
+	v1.GET("/openapi.json", h.OpenAPISpec)
+	v1.GET("/docs", h.APIDocs)
+
 	// Authentication
+	authRequired := middleware.AuthRequired(authService, h.Cookies)
+	sessionRequired := middleware.SessionRequired()
+	optionalAuth := middleware.OptionalAuth(authService)
+	canRead := middleware.RequireScope(models.ScopePerfectDaysRead)
+	canWrite := middleware.RequireScope(models.ScopePerfectDaysWrite)
+
+	// Rate limits count requests per API token, user or client IP, so they
+	// follow the authentication middleware
+	apiLimit := middleware.RateLimit(middleware.NewRateLimiter(rateLimits.API))
+	authLimit := middleware.RateLimit(middleware.NewRateLimiter(rateLimits.Auth))
+	placesLimit := middleware.RateLimit(middleware.NewRateLimiter(rateLimits.Places))
+
 	authGroup := v1.Group("/auth")
 	{
-		authGroup.POST("/login", h.Login)
-		authGroup.GET("/me", middleware.AuthRequired(authService), h.GetCurrentUser)
+		authGroup.POST("/signup", authLimit, h.Signup)
+		authGroup.POST("/login", authLimit, h.Login)
+		authGroup.PUT("/password", authRequired, apiLimit, sessionRequired, h.ChangePassword)
+		authGroup.GET("/me", authRequired, apiLimit, h.GetCurrentUser)
+		authGroup.POST("/logout", authRequired, apiLimit, sessionRequired, h.Logout)
+		authGroup.GET("/sessions", authRequired, apiLimit, sessionRequired, h.ListSessions)
+		authGroup.DELETE("/sessions", authRequired, apiLimit, sessionRequired, h.RevokeAllSessions)
+		authGroup.DELETE("/sessions/:id", authRequired, apiLimit, sessionRequired, h.RevokeSession)
+		authGroup.GET("/tokens", authRequired, apiLimit, sessionRequired, h.ListTokens)
+		authGroup.POST("/tokens", authRequired, apiLimit, sessionRequired, h.CreateToken)
+		authGroup.DELETE("/tokens/:id", authRequired, apiLimit, sessionRequired, h.RevokeToken)
+		if h.OIDC != nil {
+			authGroup.GET("/oidc/login", authLimit, h.OIDCLogin)
+			authGroup.GET("/oidc/callback", authLimit, h.OIDCCallback)
+		}
 	}
 
-	// Perfect days
+	// Perfect days; the policy decides who may do what with each one
+	perfectDayAccess := func(action string) gin.HandlerFunc {
+		return middleware.PerfectDayAccess(h.Storage.PerfectDayStorage, policy, action)
+	}
 	perfectDays := v1.Group("/perfect-days")
 	{
-		perfectDays.GET("", h.ListPerfectDays) // Public read access
-		perfectDays.POST("", middleware.AuthRequired(authService), h.CreatePerfectDay)
-		perfectDays.GET("/:id", h.GetPerfectDay) // Public read access
-		perfectDays.PUT("/:id", middleware.AuthRequired(authService), h.UpdatePerfectDay)
-		perfectDays.DELETE("/:id", middleware.AuthRequired(authService), h.DeletePerfectDay)
+		perfectDays.GET("", optionalAuth, apiLimit, canRead, h.ListPerfectDays) // Public read access
+		perfectDays.POST("", authRequired, apiLimit, canWrite, h.CreatePerfectDay)
+		perfectDays.GET("/:id", optionalAuth, apiLimit, canRead, perfectDayAccess(auth.ActionRead), h.GetPerfectDay) // Public read access
+		perfectDays.PUT("/:id", authRequired, apiLimit, canWrite, perfectDayAccess(auth.ActionEdit), h.UpdatePerfectDay)
+		perfectDays.PATCH("/:id", authRequired, apiLimit, canWrite, perfectDayAccess(auth.ActionEdit), h.PatchPerfectDay)
+		perfectDays.DELETE("/:id", authRequired, apiLimit, canWrite, perfectDayAccess(auth.ActionDelete), h.DeletePerfectDay)
+		perfectDays.POST("/:id/restore", authRequired, apiLimit, canWrite, perfectDayAccess(auth.ActionRestore), h.RestorePerfectDay)
+		perfectDays.GET("/:id/activities", optionalAuth, apiLimit, canRead, perfectDayAccess(auth.ActionRead), h.ListActivities)
+		perfectDays.POST("/:id/activities", authRequired, apiLimit, canWrite, perfectDayAccess(auth.ActionEdit), h.CreateActivity)
+		perfectDays.GET("/:id/activities/:activity_id", optionalAuth, apiLimit, canRead, perfectDayAccess(auth.ActionRead), h.GetActivity)
+		perfectDays.PUT("/:id/activities/:activity_id", authRequired, apiLimit, canWrite, perfectDayAccess(auth.ActionEdit), h.UpdateActivity)
+		perfectDays.DELETE("/:id/activities/:activity_id", authRequired, apiLimit, canWrite, perfectDayAccess(auth.ActionEdit), h.DeleteActivity)
+		// Custom methods such as activities:reorder
+		perfectDays.POST("/:id/:action", handlers.KnownPerfectDayAction, authRequired, apiLimit, canWrite, perfectDayAccess(auth.ActionEdit), h.PerfectDayAction)
+		perfectDays.GET("/:id/revisions", optionalAuth, apiLimit, canRead, perfectDayAccess(auth.ActionRead), h.ListPerfectDayRevisions)
+		perfectDays.GET("/:id/revisions/:rev", optionalAuth, apiLimit, canRead, perfectDayAccess(auth.ActionRead), h.GetPerfectDayRevision)
+		perfectDays.POST("/:id/revisions/:rev/restore", authRequired, apiLimit, canWrite, perfectDayAccess(auth.ActionEdit), h.RestorePerfectDayRevision)
 	}
 
 	// Users
 	users := v1.Group("/users")
 	{
-		users.GET("/:username", h.GetUserProfile)
-		users.GET("/:username/perfect-days", h.GetUserPerfectDays)
+		users.POST("", authLimit, h.Signup)
+		users.PATCH("/:username", authRequired, apiLimit, sessionRequired, h.UpdateUserProfile)
+		users.DELETE("/:username", authRequired, apiLimit, sessionRequired, h.DeleteUser)
+		users.GET("/:username", optionalAuth, apiLimit, canRead, h.GetUserProfile)
+		users.GET("/:username/perfect-days", optionalAuth, apiLimit, canRead, h.GetUserPerfectDays)
 	}
 
 	// Places
 	places := v1.Group("/places")
 	{
-		places.GET("/search", h.SearchPlaces)
+		places.GET("/search", optionalAuth, placesLimit, h.SearchPlaces)
 	}
 
 	// Areas
-	v1.GET("/areas", h.GetAreas)
+	v1.GET("/areas", optionalAuth, apiLimit, h.GetAreas)
+
+	// Administration
+	admin := v1.Group("/admin", authRequired, apiLimit, middleware.RequireScope(models.ScopeAdmin), middleware.RequireRole(policy, models.RoleAdmin))
+	{
+		admin.GET("/backup", h.BackupDataDir)
+		admin.GET("/users", h.ListUsers)
+		admin.PUT("/users/:username/role", sessionRequired, h.SetUserRole)
+		admin.GET("/trash", h.ListTrash)
+		admin.GET("/audit", h.ListAuditEvents)
+		admin.DELETE("/perfect-days/:id", perfectDayAccess(auth.ActionPurge), h.PurgePerfectDay)
+		admin.POST("/perfect-days/:id/restore", perfectDayAccess(auth.ActionRestore), h.RestorePerfectDay)
+	}
 }
\ No newline at end of file
@@ -2,14 +2,19 @@ package server
 
 import (
 	"net/http"
+	"perfect-day/internal/api/cookies"
 	"perfect-day/internal/api/handlers"
 	"perfect-day/internal/api/middleware"
 	"perfect-day/internal/api/routes"
 	"perfect-day/pkg/auth"
 	"perfect-day/pkg/config"
+	"perfect-day/pkg/oidc"
 	"perfect-day/pkg/places"
 	"perfect-day/pkg/search"
 	"perfect-day/pkg/storage"
+	"perfect-day/pkg/utils"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,36 +22,110 @@ import (
 type Server struct {
 	router        *gin.Engine
 	config        *config.Config
+	rateLimits    middleware.RateLimits
+	cookies       cookies.Policy
 	Storage       *storage.Storage
 	AuthService   *auth.AuthService
+	Policy        *auth.Policy
+	OIDC          *oidc.Provider
 	PlacesService *places.PlacesService
 	SearchService *search.SearchService
+
+	// stop ends the background sweepers, see Close
+	stop        chan struct{}
+	sweepers    sync.WaitGroup
+	closeOnce   sync.Once
+	ownsStorage bool
 }
 
 func NewServer(cfg *config.Config) *Server {
+	storage, err := storage.Open(cfg.StorageBackend, cfg.DataDir)
+	if err != nil {
+		panic("Failed to open storage: " + err.Error())
+	}
+	server := NewServerWithStorage(cfg, storage)
+	server.ownsStorage = true
+	return server
+}
+
+// NewServerWithStorage creates a server on top of an already constructed
+// storage backend, e.g. storage.NewMemoryStorage() in tests. Closing the
+// server leaves the storage open.
+func NewServerWithStorage(cfg *config.Config, storage *storage.Storage) *Server {
 	// Initialize storage
-	storage := storage.NewStorage(cfg.DataDir)
 	if err := storage.Initialize(); err != nil {
 		panic("Failed to initialize storage: " + err.Error())
 	}
 
 	// Initialize services
-	authService := auth.NewAuthService(storage.UserStorage)
+	authService := auth.NewAuthService(storage.UserStorage, storage.SessionStorage, storage.TokenStorage)
+	authService.ReserveUsernames(cfg.AdminUsers)
 	placesService, _ := places.NewPlacesService(cfg.GooglePlacesAPIKey)
 	searchService := search.NewSearchService()
+	if cfg.DataDir != "" {
+		// Cursors stay valid across restarts and for every server sharing
+		// the data directory
+		cursorKey, err := search.LoadCursorKey(cfg.DataDir)
+		if err != nil {
+			panic("Failed to load cursor key: " + err.Error())
+		}
+		searchService = search.NewSearchServiceWithCursorKey(cursorKey)
+	}
+
+	// Single sign-on is optional
+	var oidcProvider *oidc.Provider
+	if cfg.OIDCIssuer != "" {
+		var err error
+		oidcProvider, err = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+		})
+		if err != nil {
+			panic("Failed to configure OIDC: " + err.Error())
+		}
+	}
+
+	rateLimits, err := parseRateLimits(cfg)
+	if err != nil {
+		panic("Failed to configure rate limits: " + err.Error())
+	}
+
+	cookiePolicy, err := cookies.NewPolicy(cfg.CookieSecure, cfg.CookieSameSite)
+	if err != nil {
+		panic("Failed to configure cookies: " + err.Error())
+	}
 
 	// Create server
 	server := &Server{
 		config:        cfg,
+		rateLimits:    rateLimits,
+		cookies:       cookiePolicy,
 		Storage:       storage,
 		AuthService:   authService,
+		Policy:        auth.NewPolicy(cfg.AdminUsers),
+		OIDC:          oidcProvider,
 		PlacesService: placesService,
 		SearchService: searchService,
+		stop:          make(chan struct{}),
 	}
 
 	// Setup router
 	server.setupRouter()
 
+	// Purge expired trash in the background
+	if cfg.TrashRetention != "" {
+		retention, err := utils.ParseAge(cfg.TrashRetention)
+		if err != nil {
+			panic("Invalid trash retention: " + err.Error())
+		}
+		server.startTrashSweeper(retention)
+	}
+
+	// Evict expired sessions in the background
+	server.startSessionSweeper()
+
 	return server
 }
 
@@ -55,28 +134,90 @@ func (s *Server) setupRouter() {
 	gin.SetMode(gin.ReleaseMode)
 
 	s.router = gin.New()
+	if err := s.router.SetTrustedProxies(s.config.TrustedProxies); err != nil {
+		panic("Failed to configure trusted proxies: " + err.Error())
+	}
 
 	// Add middleware
 	s.router.Use(gin.Logger())
 	s.router.Use(gin.Recovery())
-	s.router.Use(middleware.CORS())
+	s.router.Use(middleware.CORS(s.config.AllowedOrigins))
 
 	// Create handlers
 	handlers := &handlers.Handlers{
 		AuthService:   s.AuthService,
+		Policy:        s.Policy,
+		OIDC:          s.OIDC,
 		Storage:       s.Storage,
 		PlacesService: s.PlacesService,
 		SearchService: s.SearchService,
+		Cookies:       s.cookies,
 	}
 
 	// Setup routes
-	routes.SetupRoutes(s.router, handlers, s.AuthService)
+	routes.SetupRoutes(s.router, handlers, s.AuthService, s.Policy, s.rateLimits)
+}
+
+// parseRateLimits reads the rate limit policies of cfg, using the defaults
+// for those that are not set.
+func parseRateLimits(cfg *config.Config) (middleware.RateLimits, error) {
+	var limits middleware.RateLimits
+	var err error
+	if limits.API, err = middleware.ParseRateLimitPolicy(cfg.RateLimit, middleware.DefaultAPIRateLimit); err != nil {
+		return limits, err
+	}
+	if limits.Auth, err = middleware.ParseRateLimitPolicy(cfg.AuthRateLimit, middleware.DefaultAuthRateLimit); err != nil {
+		return limits, err
+	}
+	if limits.Places, err = middleware.ParseRateLimitPolicy(cfg.PlacesRateLimit, middleware.DefaultPlacesRateLimit); err != nil {
+		return limits, err
+	}
+	return limits, nil
 }
 
 func (s *Server) Start(addr string) error {
 	return s.router.Run(addr)
 }
 
+// Close stops the background sweepers, waiting for a running sweep to
+// finish. A server made by NewServer closes its storage too. Closing twice
+// does nothing.
+func (s *Server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		s.sweepers.Wait()
+		if s.ownsStorage {
+			err = s.Storage.Close()
+		}
+	})
+	return err
+}
+
+// every runs task every interval in the background until the server is
+// closed.
+func (s *Server) every(interval time.Duration, task func()) {
+	s.sweepers.Add(1)
+	go func() {
+		defer s.sweepers.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				task()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	s.router.ServeHTTP(w, req)
-}
\ No newline at end of file
+}
+
+// Routes lists the method and path of every registered route.
+func (s *Server) Routes() gin.RoutesInfo {
+	return s.router.Routes()
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"log"
+	"time"
+)
+
+// sessionSweepInterval is how often expired sessions are evicted while the
+// server is running.
+const sessionSweepInterval = 10 * time.Minute
+
+// startSessionSweeper evicts expired sessions: once right away, then every
+// sessionSweepInterval until the server is closed. Expired sessions are
+// rejected regardless; sweeping keeps them from piling up in storage.
+func (s *Server) startSessionSweeper() {
+	s.sweepSessions()
+	s.every(sessionSweepInterval, s.sweepSessions)
+}
+
+func (s *Server) sweepSessions() {
+	evicted, err := s.AuthService.DeleteExpiredSessions()
+	if err != nil {
+		log.Printf("Session sweep failed: %v", err)
+	}
+	if evicted > 0 {
+		log.Printf("Session sweep evicted %d expired sessions", evicted)
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"log"
+	"perfect-day/pkg/storage"
+	"time"
+)
+
+// trashSweepInterval is how often expired trash is purged while the server
+// is running.
+const trashSweepInterval = time.Hour
+
+// startTrashSweeper purges perfect days that have been in the trash for
+// longer than retention: once right away, then every trashSweepInterval
+// until the server is closed.
+func (s *Server) startTrashSweeper(retention time.Duration) {
+	s.sweepTrash(retention)
+	s.every(trashSweepInterval, func() { s.sweepTrash(retention) })
+}
+
+func (s *Server) sweepTrash(retention time.Duration) {
+	purged, err := storage.PurgeDeleted(s.Storage.PerfectDayStorage, "", time.Now().Add(-retention))
+	if err != nil {
+		log.Printf("Trash sweep failed: %v", err)
+	}
+	if len(purged) > 0 {
+		log.Printf("Trash sweep purged %d perfect days deleted more than %s ago", len(purged), retention)
+	}
+}
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"perfect-day/pkg/utils"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Administer users and everyone's perfect days",
+	Long: `Administer the users and perfect days of the data directory. Only admins may
+use these commands: users with the admin role, or listed in ADMIN_USERS
+(comma-separated), which is how the first admin is appointed.`,
+}
+
+var adminUsersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "List all users and their roles",
+	Run:   runAdminUsers,
+}
+
+var adminSetRoleCmd = &cobra.Command{
+	Use:   "set-role <username> <role>",
+	Short: "Change the role of a user",
+	Long:  "Change the role of a user to one of: " + strings.Join(models.Roles, ", ") + ".",
+	Args:  cobra.ExactArgs(2),
+	Run:   runAdminSetRole,
+}
+
+var adminTrashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List the deleted perfect days of all users",
+	Run:   runAdminTrash,
+}
+
+var adminRestoreCmd = &cobra.Command{
+	Use:   "restore <ID>",
+	Short: "Restore any user's deleted perfect day",
+	Args:  cobra.ExactArgs(1),
+	Run:   runAdminRestore,
+}
+
+var adminPurgeCmd = &cobra.Command{
+	Use:   "purge <ID>",
+	Short: "Permanently delete any user's perfect day",
+	Long:  "Permanently delete a perfect day and its revision history, whether it is in the trash or not.",
+	Args:  cobra.ExactArgs(1),
+	Run:   runAdminPurge,
+}
+
+func init() {
+	adminCmd.AddCommand(adminUsersCmd)
+	adminCmd.AddCommand(adminSetRoleCmd)
+	adminCmd.AddCommand(adminTrashCmd)
+	adminCmd.AddCommand(adminRestoreCmd)
+	adminCmd.AddCommand(adminPurgeCmd)
+}
+
+// openAdminStorage opens the storage for an admin command, exiting unless
+// the logged in user is an admin.
+func openAdminStorage() (*storage.Storage, *auth.Policy, string) {
+	currentUser := getCurrentUser()
+	if currentUser == "" {
+		fmt.Println("Please login first using 'perfect-day login'")
+		os.Exit(1)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	store := openStorage(config)
+
+	user, err := store.UserStorage.Load(currentUser)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading user: %v\n", err)
+		os.Exit(1)
+	}
+
+	var adminUsers []string
+	for _, username := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
+		if username = strings.TrimSpace(username); username != "" {
+			adminUsers = append(adminUsers, username)
+		}
+	}
+	policy := auth.NewPolicy(adminUsers)
+
+	if !policy.HasRole(user, models.RoleAdmin) {
+		fmt.Println("Admin commands require the admin role")
+		os.Exit(1)
+	}
+
+	return store, policy, currentUser
+}
+
+func runAdminUsers(cmd *cobra.Command, args []string) {
+	store, policy, _ := openAdminStorage()
+
+	users, err := store.UserStorage.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading users: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Found %d users:\n\n", len(users))
+	fmt.Printf("%-20s %-10s %-20s %s\n", "Username", "Role", "Timezone", "Created")
+	fmt.Println(strings.Repeat("-", 70))
+
+	for _, user := range users {
+		fmt.Printf("%-20s %-10s %-20s %s\n",
+			user.Username, policy.Role(user), user.Timezone, user.CreatedAt.Format("2006-01-02"))
+	}
+}
+
+func runAdminSetRole(cmd *cobra.Command, args []string) {
+	username, role := args[0], args[1]
+
+	if err := models.ValidateRole(role); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, _, _ := openAdminStorage()
+
+	user, err := store.UserStorage.Load(username)
+	if err != nil {
+		fmt.Printf("User '%s' not found\n", username)
+		os.Exit(1)
+	}
+
+	user.Role = role
+	if err := store.UserStorage.Save(user); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving user: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("User '%s' is now a %s\n", username, role)
+}
+
+func runAdminTrash(cmd *cobra.Command, args []string) {
+	store, _, _ := openAdminStorage()
+
+	deleted, err := storage.ListDeleted(store.PerfectDayStorage, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading perfect days: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(deleted) == 0 {
+		fmt.Println("Trash is empty")
+		return
+	}
+
+	fmt.Printf("Found %d deleted perfect days:\n\n", len(deleted))
+	fmt.Printf("%-8s %-15s %-20s %-12s %s\n", "ID", "Username", "Title", "Date", "Deleted")
+	fmt.Println(strings.Repeat("-", 80))
+
+	for _, pd := range deleted {
+		fmt.Printf("%-8s %-15s %-20s %-12s %s\n",
+			pd.ID[:8], utils.TruncateString(pd.Username, 15), utils.TruncateString(pd.Title, 20), pd.Date,
+			pd.DeletionTime().Format("2006-01-02 15:04"))
+	}
+
+	fmt.Println("\nUse 'perfect-day admin restore <ID>' to restore a perfect day")
+}
+
+func runAdminRestore(cmd *cobra.Command, args []string) {
+	perfectDayID := args[0]
+	store, policy, currentUser := openAdminStorage()
+
+	perfectDay, err := lookupPerfectDay(store.PerfectDayStorage, perfectDayID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading perfect day: %v\n", err)
+		os.Exit(1)
+	}
+
+	if perfectDay == nil {
+		fmt.Printf("Perfect day with ID '%s' not found\n", perfectDayID)
+		os.Exit(1)
+	}
+
+	if !perfectDay.IsDeleted {
+		fmt.Println("Perfect day is not deleted")
+		return
+	}
+
+	user, _ := store.UserStorage.Load(currentUser)
+	if !policy.Can(user, auth.ActionRestore, perfectDay) {
+		fmt.Println("You don't have permission to restore this perfect day")
+		os.Exit(1)
+	}
+
+	perfectDay.Restore()
+	perfectDay.UpdatedBy = currentUser
+
+	if err := store.PerfectDayStorage.Save(perfectDay); err != nil {
+		fmt.Fprintf(os.Stderr, "Error restoring perfect day: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Perfect day '%s' of %s has been restored\n", perfectDay.Title, perfectDay.Username)
+}
+
+func runAdminPurge(cmd *cobra.Command, args []string) {
+	perfectDayID := args[0]
+	store, policy, currentUser := openAdminStorage()
+
+	perfectDay, err := lookupPerfectDay(store.PerfectDayStorage, perfectDayID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading perfect day: %v\n", err)
+		os.Exit(1)
+	}
+
+	if perfectDay == nil {
+		fmt.Printf("Perfect day with ID '%s' not found\n", perfectDayID)
+		os.Exit(1)
+	}
+
+	user, _ := store.UserStorage.Load(currentUser)
+	if !policy.Can(user, auth.ActionPurge, perfectDay) {
+		fmt.Println("You don't have permission to delete this perfect day")
+		os.Exit(1)
+	}
+
+	if !utils.PromptConfirm(fmt.Sprintf("Permanently delete '%s' of %s? This cannot be undone.", perfectDay.Title, perfectDay.Username)) {
+		fmt.Println("Purge cancelled")
+		return
+	}
+
+	if err := store.PerfectDayStorage.Delete(perfectDay.Username, perfectDay.ID, perfectDay.Revision); err != nil {
+		fmt.Fprintf(os.Stderr, "Error deleting perfect day: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Perfect day '%s' of %s has been permanently deleted\n", perfectDay.Title, perfectDay.Username)
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditActor  string
+	auditAction string
+	auditFrom   string
+	auditTo     string
+	auditLimit  int
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log",
+	Long: `Show the audit log recorded by the API, newest first: logins, session
+revocations, profile changes and changes to perfect days, with who made them
+and from where. Only admins may read it.
+
+--from and --to take a date (2025-01-15), an RFC 3339 time or an age such
+as 7d or 12h, e.g.
+
+  perfect-day audit --action login.failure --from 7d`,
+	Run: runAudit,
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditActor, "actor", "", "Only events by this user")
+	auditCmd.Flags().StringVar(&auditAction, "action", "", "Only events with this action, e.g. perfect_day.delete")
+	auditCmd.Flags().StringVar(&auditFrom, "from", "", "Only events at or after this time")
+	auditCmd.Flags().StringVar(&auditTo, "to", "", "Only events before this time")
+	auditCmd.Flags().IntVarP(&auditLimit, "limit", "n", 50, "Show at most this many events (0 for all)")
+}
+
+func runAudit(cmd *cobra.Command, args []string) {
+	filter := models.AuditFilter{Actor: auditActor, Action: auditAction, Limit: auditLimit}
+
+	var err error
+	if filter.From, err = parseAuditBound(auditFrom); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --from: %v\n", err)
+		os.Exit(1)
+	}
+	if filter.To, err = parseAuditBound(auditTo); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --to: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, _, _ := openAdminStorage()
+	defer store.Close()
+
+	events, err := store.AuditStorage.Query(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading audit log: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(events) == 0 {
+		fmt.Println("No audit events found")
+		return
+	}
+
+	fmt.Printf("%-20s %-15s %-20s %-25s %-15s %s\n", "Time", "Actor", "Action", "Target", "IP", "Detail")
+	fmt.Println(strings.Repeat("-", 110))
+
+	for _, event := range events {
+		fmt.Printf("%-20s %-15s %-20s %-25s %-15s %s\n",
+			event.Time.Local().Format("2006-01-02 15:04:05"), utils.TruncateString(event.Actor, 15), event.Action,
+			utils.TruncateString(event.Target, 25), event.IP, event.Detail)
+	}
+}
+
+// parseAuditBound parses a time, a date or an age counted back from now.
+func parseAuditBound(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	age, err := utils.ParseAge(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a date, an RFC 3339 time or an age such as 7d")
+	}
+	return time.Now().Add(-age), nil
+}
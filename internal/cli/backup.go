@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/backup"
+	"perfect-day/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up or restore the data directory",
+	Long: `Save the whole data directory (users, perfect days, revisions and the current
+login) to a single archive, or restore it from one. Archives carry a manifest
+with checksums that is verified before anything is restored.`,
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create <file.tar.gz>",
+	Short: "Write a backup archive of the data directory",
+	Args:  cobra.ExactArgs(1),
+	Run:   runBackupCreate,
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <file.tar.gz>",
+	Short: "Replace the data directory with a backup archive",
+	Long: `Verify a backup archive and replace the content of the data directory with it.
+The current content is kept in a sibling directory so the restore can be undone.
+Stop the API server before restoring a data directory it uses.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runBackupRestore,
+}
+
+func init() {
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+}
+
+func runBackupCreate(cmd *cobra.Command, args []string) {
+	archivePath := args[0]
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Write next to the destination first so a failed backup never leaves
+	// a truncated archive behind under the requested name.
+	tmp, err := os.CreateTemp(filepath.Dir(archivePath), ".backup-*.tar.gz")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating backup file: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.Remove(tmp.Name())
+
+	manifest, err := backup.Create(tmp, config.DataDirectory)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating backup: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.Rename(tmp.Name(), archivePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing backup file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Backed up %d files from %s to %s\n", len(manifest.Files), config.DataDirectory, archivePath)
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) {
+	archivePath := args[0]
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening backup: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	manifest, err := backup.Verify(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error verifying backup: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Backup from %s with %d files\n", manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"), len(manifest.Files))
+	if !utils.PromptConfirm(fmt.Sprintf("Replace the data in %s with this backup?", config.DataDirectory)) {
+		fmt.Println("Restore cancelled")
+		return
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading backup: %v\n", err)
+		os.Exit(1)
+	}
+
+	_, previousDir, err := backup.Restore(file, config.DataDirectory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error restoring backup: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Restored %d files into %s\n", len(manifest.Files), config.DataDirectory)
+	if previousDir != "" {
+		fmt.Printf("The previous data was moved to %s\n", previousDir)
+	}
+}
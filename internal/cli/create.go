@@ -7,7 +7,6 @@ import (
 	"perfect-day/pkg/utils"
 	"perfect-day/pkg/models"
 	"perfect-day/pkg/places"
-	"perfect-day/pkg/storage"
 	"strconv"
 	"time"
 
@@ -34,7 +33,7 @@ func runCreate(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	storage := storage.NewStorage(config.DataDirectory)
+	storage := openStorage(config)
 	placesService, _ := places.NewPlacesService(config.GooglePlacesAPIKey)
 
 	fmt.Println("Creating a new Perfect Day...")
@@ -107,6 +106,7 @@ func runCreate(cmd *cobra.Command, args []string) {
 	}
 
 	perfectDay.SortActivitiesByTime()
+	perfectDay.UpdatedBy = username
 
 	if err := storage.PerfectDayStorage.Save(perfectDay); err != nil {
 		fmt.Fprintf(os.Stderr, "Error saving perfect day: %v\n", err)
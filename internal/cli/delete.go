@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"perfect-day/pkg/utils"
-	"perfect-day/pkg/storage"
 
 	"github.com/spf13/cobra"
 )
@@ -32,27 +31,17 @@ func runDelete(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	storage := storage.NewStorage(config.DataDirectory)
+	storage := openStorage(config)
 
-	perfectDay, err := storage.PerfectDayStorage.Load(currentUser, perfectDayID)
+	perfectDay, err := lookupPerfectDay(storage.PerfectDayStorage, perfectDayID)
 	if err != nil {
-		allPerfectDays, err := storage.PerfectDayStorage.LoadAllByUser(currentUser, false)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading perfect days: %v\n", err)
-			os.Exit(1)
-		}
-
-		for _, pd := range allPerfectDays {
-			if pd.ID[:8] == perfectDayID {
-				perfectDay = pd
-				break
-			}
-		}
-
-		if perfectDay == nil {
-			fmt.Printf("Perfect day with ID '%s' not found or you don't have permission to delete it\n", perfectDayID)
-			os.Exit(1)
-		}
+		fmt.Fprintf(os.Stderr, "Error loading perfect day: %v\n", err)
+		os.Exit(1)
+	}
+
+	if perfectDay == nil || perfectDay.Username != currentUser {
+		fmt.Printf("Perfect day with ID '%s' not found or you don't have permission to delete it\n", perfectDayID)
+		os.Exit(1)
 	}
 
 	if perfectDay.IsDeleted {
@@ -70,6 +59,7 @@ func runDelete(cmd *cobra.Command, args []string) {
 	}
 
 	perfectDay.SoftDelete()
+	perfectDay.UpdatedBy = currentUser
 
 	if err := storage.PerfectDayStorage.Save(perfectDay); err != nil {
 		fmt.Fprintf(os.Stderr, "Error deleting perfect day: %v\n", err)
@@ -77,4 +67,7 @@ func runDelete(cmd *cobra.Command, args []string) {
 	}
 
 	fmt.Printf("Perfect day '%s' has been deleted\n", perfectDay.Title)
+	fmt.Println("Use 'perfect-day restore <ID>' to undo")
+
+	sweepTrash(config, storage, currentUser)
 }
\ No newline at end of file
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"perfect-day/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorFix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the data directory for damaged records",
+	Long: `Check every user and perfect day file of the files backend for problems that
+listings silently skip over: unparseable files, perfect days whose ID or
+username does not match where they are stored or whose user is missing,
+IDs used more than once, stale areas, and invalid dates and times.
+
+With --fix, unparseable files and older duplicates are moved to the
+quarantine directory inside the data directory, and fields that can be
+derived from the rest of the record are repaired. Everything else is only
+reported. The command exits with status 1 while problems remain.`,
+	Run: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Quarantine bad files and repair derivable fields")
+}
+
+func runDoctor(cmd *cobra.Command, args []string) {
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	store := openStorage(config)
+	defer store.Close()
+
+	fileStorage, ok := store.PerfectDayStorage.(*storage.PerfectDayStorage)
+	if !ok {
+		fmt.Println("The configured storage backend is not the files backend; nothing to check")
+		return
+	}
+
+	report, err := fileStorage.Doctor(doctorFix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking data directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Checked %d users and %d perfect days in %s\n", report.Users, report.PerfectDays, config.DataDirectory)
+
+	if len(report.Issues) == 0 {
+		fmt.Println("No problems found")
+		return
+	}
+
+	fmt.Println()
+	for _, issue := range report.Issues {
+		fmt.Printf("[%s] %s: %s\n", issue.Kind, issue.Path, issue.Message)
+		if issue.Fix != "" {
+			fmt.Printf("  fixed: %s\n", issue.Fix)
+		}
+	}
+	fmt.Println()
+
+	unfixed := report.Unfixed()
+	fmt.Printf("Found %d problems, %d fixed\n", len(report.Issues), len(report.Issues)-unfixed)
+	if report.QuarantineDir != "" {
+		fmt.Printf("Quarantined files are in %s\n", report.QuarantineDir)
+	}
+
+	if unfixed > 0 {
+		if !doctorFix {
+			fmt.Println("Run 'perfect-day doctor --fix' to repair what can be repaired")
+		}
+		os.Exit(1)
+	}
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"perfect-day/pkg/utils"
@@ -8,6 +9,7 @@ import (
 	"perfect-day/pkg/places"
 	"perfect-day/pkg/storage"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -36,7 +38,7 @@ func runEdit(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	storage := storage.NewStorage(config.DataDirectory)
+	storage := openStorage(config)
 	placesService, _ := places.NewPlacesService(config.GooglePlacesAPIKey)
 
 	perfectDay, err := loadPerfectDayForEdit(storage, currentUser, perfectDayID)
@@ -50,6 +52,8 @@ func runEdit(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	perfectDay.UpdatedBy = currentUser
+
 	fmt.Printf("Editing Perfect Day: %s\n", perfectDay.Title)
 	fmt.Printf("Current date: %s\n", perfectDay.Date)
 	fmt.Printf("Current activities: %d\n", len(perfectDay.Activities))
@@ -64,18 +68,12 @@ func runEdit(cmd *cobra.Command, args []string) {
 }
 
 func loadPerfectDayForEdit(storage *storage.Storage, username, perfectDayID string) (*models.PerfectDay, error) {
-	perfectDay, err := storage.PerfectDayStorage.Load(username, perfectDayID)
+	perfectDay, err := lookupPerfectDay(storage.PerfectDayStorage, perfectDayID)
 	if err != nil {
-		userPerfectDays, err := storage.PerfectDayStorage.LoadAllByUser(username, true)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load perfect days: %v", err)
-		}
+		return nil, err
+	}
 
-		for _, pd := range userPerfectDays {
-			if pd.ID == perfectDayID || pd.ID[:8] == perfectDayID {
-				return pd, nil
-			}
-		}
+	if perfectDay == nil || perfectDay.Username != username {
 		return nil, fmt.Errorf("perfect day with ID '%s' not found", perfectDayID)
 	}
 	return perfectDay, nil
@@ -249,7 +247,6 @@ func addNewActivity(perfectDay *models.PerfectDay, placesService *places.PlacesS
 	}
 
 	perfectDay.AddActivity(*activity)
-	perfectDay.SortActivitiesByTime()
 	fmt.Printf("Activity '%s' added successfully!\n", activityName)
 }
 
@@ -263,7 +260,8 @@ func editActivity(perfectDay *models.PerfectDay, placesService *places.PlacesSer
 		return
 	}
 
-	activity := &perfectDay.Activities[index-1]
+	// Edit a copy, UpdateActivity validates it and keeps its ID
+	activity := perfectDay.Activities[index-1]
 	fmt.Printf("Editing: %s\n", activity.Name)
 
 	fmt.Printf("Current name: %s\n", activity.Name)
@@ -312,8 +310,10 @@ func editActivity(perfectDay *models.PerfectDay, placesService *places.PlacesSer
 		activity.Commentary = newCommentary
 	}
 
-	perfectDay.SortActivitiesByTime()
-	perfectDay.UpdatedAt = time.Now()
+	if _, err := perfectDay.UpdateActivity(activity.ID, activity); err != nil {
+		fmt.Printf("Error updating activity: %v\n", err)
+		return
+	}
 	fmt.Println("Activity updated successfully!")
 }
 
@@ -331,9 +331,10 @@ func removeActivity(perfectDay *models.PerfectDay) {
 	fmt.Printf("Remove: %s at %s?\n", activity.Name, activity.Location.Name)
 
 	if utils.PromptConfirm("Are you sure?") {
-		perfectDay.Activities = append(perfectDay.Activities[:index-1], perfectDay.Activities[index:]...)
-		perfectDay.UpdateAreas()
-		perfectDay.UpdatedAt = time.Now()
+		if err := perfectDay.RemoveActivity(activity.ID); err != nil {
+			fmt.Printf("Error removing activity: %v\n", err)
+			return
+		}
 		fmt.Println("Activity removed successfully!")
 	}
 }
@@ -351,9 +352,22 @@ func reorderActivities(perfectDay *models.PerfectDay) {
 		return
 	}
 
-	// Manual reordering could be implemented here if needed
-	fmt.Println("Manual reordering not implemented yet. Activities sorted by time.")
-	perfectDay.SortActivitiesByTime()
+	orderStr := utils.PromptInput("New order (activity numbers separated by spaces, e.g. 2 1 3): ")
+	ids := make([]string, 0, len(perfectDay.Activities))
+	for _, field := range strings.Fields(orderStr) {
+		index, err := strconv.Atoi(field)
+		if err != nil || index < 1 || index > len(perfectDay.Activities) {
+			fmt.Printf("Invalid activity number: %s\n", field)
+			return
+		}
+		ids = append(ids, perfectDay.Activities[index-1].ID)
+	}
+
+	if err := perfectDay.ReorderActivities(ids); err != nil {
+		fmt.Printf("Error reordering activities: %v\n", err)
+		return
+	}
+	fmt.Println("Activities reordered!")
 }
 
 func previewPerfectDay(perfectDay *models.PerfectDay) {
@@ -363,19 +377,39 @@ func previewPerfectDay(perfectDay *models.PerfectDay) {
 }
 
 func saveAndExit(perfectDay *models.PerfectDay, storage *storage.Storage) bool {
-	if utils.PromptConfirm("Save changes?") {
-		perfectDay.UpdatedAt = time.Now()
-		if err := storage.PerfectDayStorage.Save(perfectDay); err != nil {
-			fmt.Fprintf(os.Stderr, "Error saving perfect day: %v\n", err)
-			return true // Stay in edit mode
-		}
-		fmt.Println("Perfect day saved successfully!")
-	} else {
+	if !utils.PromptConfirm("Save changes?") {
 		fmt.Println("Changes discarded.")
+		return false
+	}
+
+	// Someone else (another CLI session or the API) may have saved this
+	// perfect day while it was being edited here.
+	if current, err := storage.PerfectDayStorage.LoadByID(perfectDay.ID); err == nil && current.Revision != perfectDay.Revision {
+		fmt.Printf("This perfect day was changed elsewhere while you were editing it (revision %d, now %d).\n", perfectDay.Revision, current.Revision)
+		if !utils.PromptConfirm("Overwrite those changes with yours?") {
+			fmt.Println("Changes discarded.")
+			return false
+		}
+		perfectDay.Revision = current.Revision
 	}
+
+	perfectDay.UpdatedAt = time.Now()
+	if err := storage.PerfectDayStorage.Save(perfectDay); err != nil {
+		if isRevisionConflict(err) {
+			fmt.Println("This perfect day was changed again while saving. Choose 'Save and exit' to try again.")
+			return true
+		}
+		fmt.Fprintf(os.Stderr, "Error saving perfect day: %v\n", err)
+		return true // Stay in edit mode
+	}
+	fmt.Println("Perfect day saved successfully!")
 	return false
 }
 
+func isRevisionConflict(err error) bool {
+	return errors.Is(err, storage.ErrRevisionConflict)
+}
+
 func validateDate(dateStr string) error {
 	_, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"perfect-day/pkg/models"
+
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <ID>",
+	Short: "Show the revision history of a perfect day",
+	Long: `List every saved revision of a perfect day with its author and time, and the
+fields that changed compared to the previous revision.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runHistory,
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	perfectDayID := args[0]
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage := openStorage(config)
+
+	perfectDay, err := lookupPerfectDay(storage.PerfectDayStorage, perfectDayID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading perfect day: %v\n", err)
+		os.Exit(1)
+	}
+
+	if perfectDay == nil {
+		fmt.Printf("Perfect day with ID '%s' not found\n", perfectDayID)
+		os.Exit(1)
+	}
+
+	revisions, err := storage.PerfectDayStorage.ListRevisions(perfectDay.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading revisions: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("History of '%s' (%s)\n", perfectDay.Title, perfectDay.ID)
+	if len(revisions) == 0 {
+		fmt.Println("\nNo revisions recorded yet")
+		return
+	}
+
+	for i, revision := range revisions {
+		current := ""
+		if revision.Revision == perfectDay.Revision {
+			current = " (current)"
+		}
+		fmt.Printf("\nRevision %d%s by %s at %s\n", revision.Revision, current, revision.Author,
+			revision.CreatedAt.Format("2006-01-02 15:04:05"))
+
+		if i == 0 {
+			fmt.Println("  Created")
+			continue
+		}
+		printFieldChanges(models.DiffPerfectDays(&revisions[i-1].PerfectDay, &revision.PerfectDay))
+	}
+}
+
+func printFieldChanges(changes []models.FieldChange) {
+	if len(changes) == 0 {
+		fmt.Println("  No field changes")
+		return
+	}
+
+	for _, change := range changes {
+		switch {
+		case change.Old == "":
+			fmt.Printf("  + %s: %s\n", change.Field, change.New)
+		case change.New == "":
+			fmt.Printf("  - %s: %s\n", change.Field, change.Old)
+		default:
+			fmt.Printf("  ~ %s: %s -> %s\n", change.Field, change.Old, change.New)
+		}
+	}
+}
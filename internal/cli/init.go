@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"perfect-day/pkg/storage"
+	"perfect-day/pkg/utils"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -14,11 +16,15 @@ import (
 type Config struct {
 	GooglePlacesAPIKey string `json:"google_places_api_key,omitempty"`
 	DataDirectory      string `json:"data_directory,omitempty"`
+	StorageBackend     string `json:"storage_backend,omitempty"`
+	TrashRetention     string `json:"trash_retention,omitempty"`
 }
 
 var (
 	initAPIKey    string
 	initDataDir   string
+	initStorage   string
+	initTrashRetention string
 	initInteractive bool
 )
 
@@ -32,6 +38,8 @@ var initCmd = &cobra.Command{
 func init() {
 	initCmd.Flags().StringVar(&initAPIKey, "api-key", "", "Google Places API key")
 	initCmd.Flags().StringVar(&initDataDir, "data-dir", "", "Data directory path")
+	initCmd.Flags().StringVar(&initStorage, "storage", "", "Storage backend: files, sqlite")
+	initCmd.Flags().StringVar(&initTrashRetention, "trash-retention", "", "Purge deleted perfect days after this age, e.g. 30d")
 	initCmd.Flags().BoolVarP(&initInteractive, "interactive", "i", false, "Interactive setup")
 }
 
@@ -61,7 +69,7 @@ func runInit(cmd *cobra.Command, args []string) {
 	}
 
 	// Interactive mode or flag-based setup
-	if initInteractive || (initAPIKey == "" && initDataDir == "") {
+	if initInteractive || (initAPIKey == "" && initDataDir == "" && initStorage == "" && initTrashRetention == "") {
 		runInteractiveSetup(config)
 	} else {
 		if initAPIKey != "" {
@@ -70,6 +78,16 @@ func runInit(cmd *cobra.Command, args []string) {
 		if initDataDir != "" {
 			config.DataDirectory = initDataDir
 		}
+		if initStorage != "" {
+			config.StorageBackend = initStorage
+		}
+		if initTrashRetention != "" {
+			if _, err := utils.ParseAge(initTrashRetention); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			config.TrashRetention = initTrashRetention
+		}
 	}
 
 	// Set default data directory if not specified
@@ -92,6 +110,12 @@ func runInit(cmd *cobra.Command, args []string) {
 	fmt.Printf("Configuration saved to: %s\n", configFile)
 	fmt.Println("\nConfiguration:")
 	fmt.Printf("  Data Directory: %s\n", config.DataDirectory)
+	if config.StorageBackend != "" {
+		fmt.Printf("  Storage Backend: %s\n", config.StorageBackend)
+	}
+	if config.TrashRetention != "" {
+		fmt.Printf("  Trash Retention: %s\n", config.TrashRetention)
+	}
 	if config.GooglePlacesAPIKey != "" {
 		fmt.Printf("  Google Places API: Configured\n")
 	} else {
@@ -111,7 +135,8 @@ func runInteractiveSetup(config *Config) {
 	reader := bufio.NewReader(os.Stdin)
 
 	fmt.Println("🌟 Perfect Day Configuration Setup")
-	fmt.Println("Press Enter to keep existing values or leave blank for defaults.\n")
+	fmt.Println("Press Enter to keep existing values or leave blank for defaults.")
+	fmt.Println()
 
 	// Google Places API Key
 	currentAPI := config.GooglePlacesAPIKey
@@ -159,6 +184,17 @@ func GetConfigPath() string {
 	return filepath.Join(homeDir, ".perfect-day", "config.json")
 }
 
+// openStorage returns the storage backend configured for the CLI. Commands
+// should go through it rather than constructing a backend themselves.
+func openStorage(config *Config) *storage.Storage {
+	storage, err := storage.Open(config.StorageBackend, config.DataDirectory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening storage: %v\n", err)
+		os.Exit(1)
+	}
+	return storage
+}
+
 func LoadConfig() (*Config, error) {
 	configFile := GetConfigPath()
 
@@ -176,6 +212,12 @@ func LoadConfig() (*Config, error) {
 	if envDataDir := os.Getenv("PERFECT_DAY_DATA_DIR"); envDataDir != "" {
 		config.DataDirectory = envDataDir
 	}
+	if envStorage := os.Getenv("PERFECT_DAY_STORAGE"); envStorage != "" {
+		config.StorageBackend = envStorage
+	}
+	if envRetention := os.Getenv("PERFECT_DAY_TRASH_RETENTION"); envRetention != "" {
+		config.TrashRetention = envRetention
+	}
 
 	// Set default data directory if still empty
 	if config.DataDirectory == "" {
@@ -5,7 +5,7 @@ import (
 	"os"
 	"perfect-day/pkg/utils"
 	"perfect-day/pkg/models"
-	"perfect-day/pkg/storage"
+	"perfect-day/pkg/search"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -15,6 +15,8 @@ var (
 	listUser    string
 	listAll     bool
 	listDeleted bool
+	listLimit   int
+	listCursor  string
 )
 
 var listCmd = &cobra.Command{
@@ -28,6 +30,8 @@ func init() {
 	listCmd.Flags().StringVarP(&listUser, "user", "u", "", "List perfect days for specific user")
 	listCmd.Flags().BoolVarP(&listAll, "all", "a", false, "List perfect days from all users")
 	listCmd.Flags().BoolVar(&listDeleted, "deleted", false, "Include deleted perfect days")
+	listCmd.Flags().IntVarP(&listLimit, "limit", "l", 0, "Number of perfect days to show (0 for all)")
+	listCmd.Flags().StringVar(&listCursor, "cursor", "", "Continue from the cursor printed with a previous page")
 }
 
 func runList(cmd *cobra.Command, args []string) {
@@ -37,7 +41,7 @@ func runList(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	storage := storage.NewStorage(config.DataDirectory)
+	storage := openStorage(config)
 
 	var perfectDays []*models.PerfectDay
 
@@ -64,11 +68,28 @@ func runList(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	printPerfectDaysList(perfectDays)
+	// Newest first, paged like search results
+	results, err := openSearchService(config).Page(perfectDays, search.SearchCriteria{
+		SortBy:    "created_at",
+		SortOrder: "desc",
+		Limit:     listLimit,
+		Cursor:    listCursor,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printPerfectDaysList(results.PerfectDays, results.Total)
+	printPageCursors(results)
 }
 
-func printPerfectDaysList(perfectDays []*models.PerfectDay) {
-	fmt.Printf("Found %d perfect days:\n\n", len(perfectDays))
+func printPerfectDaysList(perfectDays []*models.PerfectDay, total int) {
+	fmt.Printf("Found %d perfect days", total)
+	if len(perfectDays) < total {
+		fmt.Printf(" (showing %d)", len(perfectDays))
+	}
+	fmt.Print(":\n\n")
 
 	fmt.Printf("%-8s %-20s %-12s %-15s %-20s %s\n",
 		"ID", "Title", "Username", "Date", "Areas", "Activities")
@@ -3,9 +3,9 @@ package cli
 import (
 	"fmt"
 	"os"
+	"perfect-day/pkg/auth"
 	"perfect-day/pkg/utils"
 	"perfect-day/pkg/models"
-	"perfect-day/pkg/storage"
 
 	"github.com/spf13/cobra"
 )
@@ -13,7 +13,7 @@ import (
 var loginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Login or create a new user",
-	Long:  "Login with an existing username or create a new user with timezone information.",
+	Long:  "Login with an existing username and password, or create a new user with a timezone and password.",
 	Run:   runLogin,
 }
 
@@ -24,7 +24,7 @@ func runLogin(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	storage := storage.NewStorage(config.DataDirectory)
+	storage := openStorage(config)
 	if err := storage.Initialize(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing storage: %v\n", err)
 		os.Exit(1)
@@ -42,6 +42,22 @@ func runLogin(cmd *cobra.Command, args []string) {
 			fmt.Fprintf(os.Stderr, "Error loading user: %v\n", err)
 			os.Exit(1)
 		}
+
+		if user.HasPassword() {
+			password := utils.PromptPassword("Password: ")
+			if !auth.CheckPassword(user.PasswordHash, password) {
+				fmt.Println("Invalid username or password")
+				os.Exit(1)
+			}
+		} else {
+			fmt.Println("Your account has no password yet. Please set one now.")
+			user.PasswordHash = promptNewPassword()
+			if err := storage.UserStorage.Save(user); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving user: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
 		fmt.Printf("Welcome back, %s! (Timezone: %s)\n", user.Username, user.Timezone)
 		saveCurrentUser(username)
 	} else {
@@ -56,8 +72,9 @@ func runLogin(cmd *cobra.Command, args []string) {
 			fmt.Fprintf(os.Stderr, "Error creating user: %v\n", err)
 			os.Exit(1)
 		}
+		user.PasswordHash = promptNewPassword()
 
-		if err := storage.UserStorage.Save(user); err != nil {
+		if err := storage.UserStorage.Create(user); err != nil {
 			fmt.Fprintf(os.Stderr, "Error saving user: %v\n", err)
 			os.Exit(1)
 		}
@@ -67,9 +84,25 @@ func runLogin(cmd *cobra.Command, args []string) {
 	}
 }
 
+// promptNewPassword asks for a new password twice and returns its hash.
+func promptNewPassword() string {
+	password := utils.PromptPassword(fmt.Sprintf("New password (at least %d characters): ", auth.MinPasswordLength))
+	if utils.PromptPassword("Confirm password: ") != password {
+		fmt.Println("Passwords do not match")
+		os.Exit(1)
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return hash
+}
+
 func saveCurrentUser(username string) {
 	config, _ := LoadConfig()
-	storage := storage.NewStorage(config.DataDirectory)
+	storage := openStorage(config)
 	currentUserFile := fmt.Sprintf("%s/current_user", storage.GetDataDir())
 
 	if err := os.WriteFile(currentUserFile, []byte(username), 0644); err != nil {
@@ -77,9 +110,21 @@ func saveCurrentUser(username string) {
 	}
 }
 
+// clearCurrentUser forgets the logged in user.
+func clearCurrentUser() error {
+	config, _ := LoadConfig()
+	storage := openStorage(config)
+	currentUserFile := fmt.Sprintf("%s/current_user", storage.GetDataDir())
+
+	if err := os.Remove(currentUserFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 func getCurrentUser() string {
 	config, _ := LoadConfig()
-	storage := storage.NewStorage(config.DataDirectory)
+	storage := openStorage(config)
 	currentUserFile := fmt.Sprintf("%s/current_user", storage.GetDataDir())
 
 	data, err := os.ReadFile(currentUserFile)
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Log out the current user",
+	Long:  "Forget the user remembered by 'perfect-day login'. Commands that change data ask you to log in again.",
+	Run:   runLogout,
+}
+
+func runLogout(cmd *cobra.Command, args []string) {
+	currentUser := getCurrentUser()
+	if currentUser == "" {
+		fmt.Println("Not logged in")
+		return
+	}
+
+	if err := clearCurrentUser(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error logging out: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Logged out %s\n", currentUser)
+}
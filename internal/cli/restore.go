@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <ID>",
+	Short: "Restore a deleted perfect day",
+	Long:  "Take a soft-deleted perfect day out of the trash. Use 'perfect-day trash list' to find deleted perfect days.",
+	Args:  cobra.ExactArgs(1),
+	Run:   runRestore,
+}
+
+func runRestore(cmd *cobra.Command, args []string) {
+	perfectDayID := args[0]
+	currentUser := getCurrentUser()
+
+	if currentUser == "" {
+		fmt.Println("Please login first using 'perfect-day login'")
+		os.Exit(1)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage := openStorage(config)
+
+	perfectDay, err := lookupPerfectDay(storage.PerfectDayStorage, perfectDayID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading perfect day: %v\n", err)
+		os.Exit(1)
+	}
+
+	if perfectDay == nil || perfectDay.Username != currentUser {
+		fmt.Printf("Perfect day with ID '%s' not found or you don't have permission to restore it\n", perfectDayID)
+		os.Exit(1)
+	}
+
+	if !perfectDay.IsDeleted {
+		fmt.Println("Perfect day is not deleted")
+		return
+	}
+
+	perfectDay.Restore()
+	perfectDay.UpdatedBy = currentUser
+
+	if err := storage.PerfectDayStorage.Save(perfectDay); err != nil {
+		fmt.Fprintf(os.Stderr, "Error restoring perfect day: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Perfect day '%s' has been restored\n", perfectDay.Title)
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"perfect-day/pkg/utils"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var revertCmd = &cobra.Command{
+	Use:   "revert <ID> <revision>",
+	Short: "Restore a perfect day to an earlier revision",
+	Long: `Restore the content of a perfect day from an earlier revision. The restore is
+saved as a new revision, so it can itself be reverted. Use 'perfect-day history'
+to find revision numbers.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runRevert,
+}
+
+func runRevert(cmd *cobra.Command, args []string) {
+	perfectDayID := args[0]
+	revisionNumber, err := strconv.Atoi(args[1])
+	if err != nil || revisionNumber < 1 {
+		fmt.Printf("Invalid revision '%s', expected a positive number\n", args[1])
+		os.Exit(1)
+	}
+
+	currentUser := getCurrentUser()
+	if currentUser == "" {
+		fmt.Println("Please login first using 'perfect-day login'")
+		os.Exit(1)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	store := openStorage(config)
+
+	perfectDay, err := loadPerfectDayForEdit(store, currentUser, perfectDayID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading perfect day: %v\n", err)
+		os.Exit(1)
+	}
+
+	revision, err := store.PerfectDayStorage.LoadRevision(perfectDay.ID, revisionNumber)
+	if errors.Is(err, storage.ErrRevisionNotFound) {
+		fmt.Printf("Revision %d of '%s' not found\n", revisionNumber, perfectDay.Title)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading revision: %v\n", err)
+		os.Exit(1)
+	}
+
+	restored := *perfectDay
+	restored.RestoreFrom(&revision.PerfectDay)
+	restored.UpdatedBy = currentUser
+
+	changes := models.DiffPerfectDays(perfectDay, &restored)
+	if len(changes) == 0 {
+		fmt.Printf("Revision %d matches the current content, nothing to revert\n", revisionNumber)
+		return
+	}
+
+	fmt.Printf("Reverting '%s' from revision %d to revision %d:\n", perfectDay.Title, perfectDay.Revision, revisionNumber)
+	printFieldChanges(changes)
+
+	if !utils.PromptConfirm("Apply these changes?") {
+		fmt.Println("Revert cancelled")
+		return
+	}
+
+	if err := store.PerfectDayStorage.Save(&restored); err != nil {
+		if errors.Is(err, storage.ErrRevisionConflict) {
+			fmt.Println("This perfect day was changed while reverting, please try again")
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Error saving perfect day: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Perfect day restored to revision %d (saved as revision %d)\n", revisionNumber, restored.Revision)
+}
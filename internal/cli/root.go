@@ -24,11 +24,22 @@ func Execute() {
 func init() {
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(whoamiCmd)
 	rootCmd.AddCommand(createCmd)
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(searchCmd)
 	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(revertCmd)
+	rootCmd.AddCommand(storageCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(adminCmd)
+	rootCmd.AddCommand(auditCmd)
 	rootCmd.AddCommand(versionCmd)
 }
\ No newline at end of file
@@ -6,7 +6,6 @@ import (
 	"perfect-day/pkg/utils"
 	"perfect-day/pkg/models"
 	"perfect-day/pkg/search"
-	"perfect-day/pkg/storage"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -22,6 +21,7 @@ var (
 	searchSortOrder string
 	searchLimit    int
 	searchOffset   int
+	searchCursor   string
 )
 
 var searchCmd = &cobra.Command{
@@ -41,6 +41,7 @@ func init() {
 	searchCmd.Flags().StringVar(&searchSortOrder, "order", "desc", "Sort order: asc, desc")
 	searchCmd.Flags().IntVarP(&searchLimit, "limit", "l", 10, "Number of results to show")
 	searchCmd.Flags().IntVar(&searchOffset, "offset", 0, "Number of results to skip")
+	searchCmd.Flags().StringVar(&searchCursor, "cursor", "", "Continue from the cursor printed with a previous page")
 }
 
 func runSearch(cmd *cobra.Command, args []string) {
@@ -50,8 +51,8 @@ func runSearch(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	storage := storage.NewStorage(config.DataDirectory)
-	searchService := search.NewSearchService()
+	storage := openStorage(config)
+	searchService := openSearchService(config)
 
 	allPerfectDays, err := storage.PerfectDayStorage.LoadAll(false)
 	if err != nil {
@@ -69,9 +70,14 @@ func runSearch(cmd *cobra.Command, args []string) {
 		SortOrder: searchSortOrder,
 		Limit:     searchLimit,
 		Offset:    searchOffset,
+		Cursor:    searchCursor,
 	}
 
-	results := searchService.Search(allPerfectDays, criteria)
+	results, err := searchService.Page(allPerfectDays, criteria)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	if results.Total == 0 {
 		fmt.Println("No perfect days found matching your criteria")
@@ -88,11 +94,33 @@ func runSearch(cmd *cobra.Command, args []string) {
 	fmt.Println()
 
 	printSearchResults(results.PerfectDays)
+	printPageCursors(results)
+}
 
-	if results.Total > searchLimit && searchLimit > 0 {
-		fmt.Printf("\nShowing %d of %d results. Use --offset and --limit to see more.\n",
-			len(results.PerfectDays), results.Total)
+// printPageCursors tells how to page through a listing with --cursor.
+func printPageCursors(results *search.SearchResult) {
+	if results.NextCursor == "" && results.PrevCursor == "" {
+		return
+	}
+	fmt.Println()
+	if results.NextCursor != "" {
+		fmt.Printf("Next page: --cursor %s\n", results.NextCursor)
+	}
+	if results.PrevCursor != "" {
+		fmt.Printf("Previous page: --cursor %s\n", results.PrevCursor)
+	}
+}
+
+// openSearchService returns a search service whose cursors are signed with
+// the key of the data directory, so they work across invocations and with
+// the API server.
+func openSearchService(config *Config) *search.SearchService {
+	key, err := search.LoadCursorKey(config.DataDirectory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading cursor key: %v\n", err)
+		os.Exit(1)
 	}
+	return search.NewSearchServiceWithCursorKey(key)
 }
 
 func printSearchResults(perfectDays []*models.PerfectDay) {
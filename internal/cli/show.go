@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"perfect-day/pkg/utils"
@@ -27,32 +28,14 @@ func runShow(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	storage := storage.NewStorage(config.DataDirectory)
+	storage := openStorage(config)
 
-	var perfectDay *models.PerfectDay
-
-	currentUser := getCurrentUser()
-	if currentUser != "" {
-		perfectDay, err = storage.PerfectDayStorage.Load(currentUser, perfectDayID)
-		if err == nil {
-			printPerfectDayDetails(perfectDay)
-			return
-		}
-	}
-
-	allPerfectDays, err := storage.PerfectDayStorage.LoadAll(true)
+	perfectDay, err := lookupPerfectDay(storage.PerfectDayStorage, perfectDayID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading perfect days: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error loading perfect day: %v\n", err)
 		os.Exit(1)
 	}
 
-	for _, pd := range allPerfectDays {
-		if pd.ID == perfectDayID || pd.ID[:8] == perfectDayID {
-			perfectDay = pd
-			break
-		}
-	}
-
 	if perfectDay == nil {
 		fmt.Printf("Perfect day with ID '%s' not found\n", perfectDayID)
 		os.Exit(1)
@@ -61,6 +44,22 @@ func runShow(cmd *cobra.Command, args []string) {
 	printPerfectDayDetails(perfectDay)
 }
 
+// lookupPerfectDay resolves a full ID or the short ID printed by list and
+// search. It returns nil without an error when nothing matches.
+func lookupPerfectDay(perfectDays storage.PerfectDayRepository, perfectDayID string) (*models.PerfectDay, error) {
+	perfectDay, err := perfectDays.FindByIDPrefix(perfectDayID)
+	if errors.Is(err, storage.ErrPerfectDayNotFound) {
+		return nil, nil
+	}
+
+	var ambiguous *storage.AmbiguousIDError
+	if errors.As(err, &ambiguous) {
+		return nil, fmt.Errorf("ID '%s' matches %d perfect days, please use a longer ID", perfectDayID, len(ambiguous.Matches))
+	}
+
+	return perfectDay, err
+}
+
 func printPerfectDayDetails(pd *models.PerfectDay) {
 	fmt.Printf("Perfect Day: %s\n", pd.Title)
 	fmt.Printf("ID: %s\n", pd.ID)
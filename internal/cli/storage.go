@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"perfect-day/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateFrom string
+	migrateTo   string
+)
+
+var storageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Manage the storage backend",
+	Long:  "Inspect and migrate the storage backend that holds users and perfect days.",
+}
+
+var storageMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy all data from one storage backend to another",
+	Long: `Copy every user and perfect day (including deleted ones) from one storage
+backend to another within the configured data directory, e.g.
+
+  perfect-day storage migrate --from files --to sqlite
+
+Existing records in the destination are overwritten, so the command is safe
+to run again. The source is left untouched.`,
+	Run: runStorageMigrate,
+}
+
+var storageReindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the perfect day ID index",
+	Long: `Rebuild the ID index of the files backend from the perfect day files on disk.
+Run this after copying or removing perfect day files by hand.`,
+	Run: runStorageReindex,
+}
+
+var storageUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Rewrite stored records at the current schema version",
+	Long: `Rewrite every user, perfect day and revision stored at an older schema version.
+Older records are already upgraded in memory whenever they are loaded; this
+command saves the upgraded form so the data directory no longer depends on it.
+Records that cannot be read are left alone, run 'perfect-day doctor' for them.`,
+	Run: runStorageUpgrade,
+}
+
+func init() {
+	storageMigrateCmd.Flags().StringVar(&migrateFrom, "from", storage.BackendFiles, "Source backend: files, sqlite")
+	storageMigrateCmd.Flags().StringVar(&migrateTo, "to", storage.BackendSQLite, "Destination backend: files, sqlite")
+
+	storageCmd.AddCommand(storageMigrateCmd)
+	storageCmd.AddCommand(storageReindexCmd)
+	storageCmd.AddCommand(storageUpgradeCmd)
+}
+
+func runStorageMigrate(cmd *cobra.Command, args []string) {
+	if migrateFrom == migrateTo {
+		fmt.Println("Source and destination backends must differ")
+		os.Exit(1)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	src, err := storage.Open(migrateFrom, config.DataDirectory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening source storage: %v\n", err)
+		os.Exit(1)
+	}
+	defer src.Close()
+
+	dst, err := storage.Open(migrateTo, config.DataDirectory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening destination storage: %v\n", err)
+		os.Exit(1)
+	}
+	defer dst.Close()
+
+	fmt.Printf("Migrating %s -> %s in %s...\n", migrateFrom, migrateTo, config.DataDirectory)
+
+	result, err := storage.Copy(dst, src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error migrating storage: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Copied %d users, %d perfect days with %d revisions, %d sessions, %d API tokens and %d audit events\n",
+		result.Users, result.PerfectDays, result.Revisions, result.Sessions, result.Tokens, result.AuditEvents)
+	if config.StorageBackend != migrateTo {
+		fmt.Printf("Run 'perfect-day init --storage %s' to switch to the new backend\n", migrateTo)
+	}
+}
+
+func runStorageReindex(cmd *cobra.Command, args []string) {
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	store := openStorage(config)
+	defer store.Close()
+
+	fileStorage, ok := store.PerfectDayStorage.(*storage.PerfectDayStorage)
+	if !ok {
+		fmt.Println("The configured storage backend does not use an ID index")
+		return
+	}
+
+	count, err := fileStorage.RebuildIndex()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error rebuilding index: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Indexed %d perfect days\n", count)
+}
+
+func runStorageUpgrade(cmd *cobra.Command, args []string) {
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	store := openStorage(config)
+	defer store.Close()
+
+	var result *storage.UpgradeResult
+	switch perfectDayStorage := store.PerfectDayStorage.(type) {
+	case *storage.PerfectDayStorage:
+		result, err = perfectDayStorage.UpgradeDocuments()
+	case *storage.SQLitePerfectDayStorage:
+		result, err = perfectDayStorage.UpgradeDocuments()
+	default:
+		fmt.Println("The configured storage backend does not keep stored records")
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error upgrading storage: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Upgraded %d users, %d perfect days and %d revisions\n", result.Users, result.PerfectDays, result.Revisions)
+	if len(result.Skipped) > 0 {
+		fmt.Printf("Skipped %d unreadable records, run 'perfect-day doctor' for details:\n", len(result.Skipped))
+		for _, path := range result.Skipped {
+			fmt.Printf("  %s\n", path)
+		}
+		os.Exit(1)
+	}
+}
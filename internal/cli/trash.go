@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"perfect-day/pkg/storage"
+	"perfect-day/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var trashOlderThan string
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Manage deleted perfect days",
+	Long: `List or permanently purge your soft-deleted perfect days. Deleted perfect
+days can be brought back with 'perfect-day restore <ID>' until they are purged.`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your deleted perfect days",
+	Run:   runTrashList,
+}
+
+var trashPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently delete perfect days in the trash",
+	Long: `Permanently delete your soft-deleted perfect days and their revision history.
+With --older-than only those deleted longer ago are purged; it defaults to the
+configured trash retention, and without either the whole trash is emptied.`,
+	Run: runTrashPurge,
+}
+
+func init() {
+	trashPurgeCmd.Flags().StringVar(&trashOlderThan, "older-than", "", "Only purge perfect days deleted longer ago than this, e.g. 30d")
+
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashPurgeCmd)
+}
+
+func runTrashList(cmd *cobra.Command, args []string) {
+	currentUser := getCurrentUser()
+	if currentUser == "" {
+		fmt.Println("Please login first using 'perfect-day login'")
+		os.Exit(1)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	store := openStorage(config)
+
+	deleted, err := storage.ListDeleted(store.PerfectDayStorage, currentUser)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading perfect days: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(deleted) == 0 {
+		fmt.Println("Trash is empty")
+		return
+	}
+
+	retention := trashRetention(config)
+
+	fmt.Printf("Found %d deleted perfect days:\n\n", len(deleted))
+	fmt.Printf("%-8s %-20s %-12s %-17s %s\n", "ID", "Title", "Date", "Deleted", "Purge after")
+	fmt.Println(strings.Repeat("-", 80))
+
+	for _, pd := range deleted {
+		purgeAfter := "never"
+		if retention > 0 {
+			purgeAfter = pd.DeletionTime().Add(retention).Format("2006-01-02")
+		}
+		fmt.Printf("%-8s %-20s %-12s %-17s %s\n",
+			pd.ID[:8], utils.TruncateString(pd.Title, 20), pd.Date,
+			pd.DeletionTime().Format("2006-01-02 15:04"), purgeAfter)
+	}
+
+	fmt.Println("\nUse 'perfect-day restore <ID>' to restore a perfect day")
+}
+
+func runTrashPurge(cmd *cobra.Command, args []string) {
+	currentUser := getCurrentUser()
+	if currentUser == "" {
+		fmt.Println("Please login first using 'perfect-day login'")
+		os.Exit(1)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	olderThan := trashOlderThan
+	if olderThan == "" {
+		olderThan = config.TrashRetention
+	}
+
+	var age time.Duration
+	if olderThan != "" {
+		if age, err = utils.ParseAge(olderThan); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	cutoff := time.Now().Add(-age)
+
+	store := openStorage(config)
+
+	deleted, err := storage.ListDeleted(store.PerfectDayStorage, currentUser)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading perfect days: %v\n", err)
+		os.Exit(1)
+	}
+
+	count := 0
+	for _, pd := range deleted {
+		if pd.DeletionTime().Before(cutoff) {
+			count++
+		}
+	}
+	if count == 0 {
+		fmt.Println("Nothing to purge")
+		return
+	}
+
+	if !utils.PromptConfirm(fmt.Sprintf("Permanently delete %d perfect days? This cannot be undone.", count)) {
+		fmt.Println("Purge cancelled")
+		return
+	}
+
+	purged, err := storage.PurgeDeleted(store.PerfectDayStorage, currentUser, cutoff)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error purging trash: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Purged %d perfect days\n", len(purged))
+}
+
+// trashRetention returns the configured retention, or zero when deleted
+// perfect days are kept until purged by hand.
+func trashRetention(config *Config) time.Duration {
+	if config.TrashRetention == "" {
+		return 0
+	}
+	retention, err := utils.ParseAge(config.TrashRetention)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring invalid trash retention: %v\n", err)
+		return 0
+	}
+	return retention
+}
+
+// sweepTrash applies the configured retention to the user's trash. It runs
+// after every delete so CLI-only setups purge expired perfect days too.
+func sweepTrash(config *Config, store *storage.Storage, username string) {
+	retention := trashRetention(config)
+	if retention == 0 {
+		return
+	}
+
+	purged, err := storage.PurgeDeleted(store.PerfectDayStorage, username, time.Now().Add(-retention))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to purge expired trash: %v\n", err)
+		return
+	}
+	if len(purged) > 0 {
+		fmt.Printf("Purged %d perfect days deleted more than %s ago\n", len(purged), config.TrashRetention)
+	}
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the current user",
+	Long:  "Show the user remembered by 'perfect-day login'. Exits with status 1 when nobody is logged in.",
+	Run:   runWhoami,
+}
+
+func runWhoami(cmd *cobra.Command, args []string) {
+	currentUser := getCurrentUser()
+	if currentUser == "" {
+		fmt.Println("Not logged in")
+		os.Exit(1)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage := openStorage(config)
+	defer storage.Close()
+
+	user, err := storage.UserStorage.Load(currentUser)
+	if err != nil {
+		fmt.Printf("%s (account no longer exists, run 'perfect-day logout')\n", currentUser)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s (Timezone: %s)\n", user.Username, user.Timezone)
+}
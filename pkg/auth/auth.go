@@ -3,54 +3,212 @@ package auth
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"perfect-day/pkg/models"
 	"perfect-day/pkg/storage"
 	"time"
 )
 
+// ErrInvalidCredentials is returned when a username and password do not
+// match. It deliberately does not say which of the two was wrong.
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// ErrPasswordSetupRequired is returned by Login for accounts created before
+// passwords existed. They have to set one locally with the CLI first, see
+// SetInitialPassword.
+var ErrPasswordSetupRequired = errors.New("account has no password yet")
+
+// ErrUserExists is returned by Signup when the username is taken.
+var ErrUserExists = errors.New("username is already taken")
+
+// SessionTTL is how long a session stays valid after it was last used.
+const SessionTTL = 24 * time.Hour
+
+// touchInterval limits how often using a session or API token is recorded,
+// so busy clients do not rewrite it on every request.
+const touchInterval = time.Minute
+
 type AuthService struct {
-	userStorage *storage.UserStorage
-	sessions    map[string]*Session
+	userStorage    storage.UserRepository
+	sessionStorage storage.SessionRepository
+	tokenStorage   storage.TokenRepository
+	loginThrottle  *LoginThrottle
+	// reservedUsernames cannot be registered through Signup or single
+	// sign-on, see ReserveUsernames
+	reservedUsernames map[string]bool
+}
+
+func NewAuthService(userStorage storage.UserRepository, sessionStorage storage.SessionRepository, tokenStorage storage.TokenRepository) *AuthService {
+	return &AuthService{
+		userStorage:    userStorage,
+		sessionStorage: sessionStorage,
+		tokenStorage:   tokenStorage,
+		loginThrottle:  NewLoginThrottle(DefaultMaxLoginFailures, DefaultLoginLockout, DefaultMaxLoginLockout),
+	}
 }
 
-type Session struct {
-	ID       string
-	Username string
-	ExpiresAt time.Time
+// SetLoginThrottle replaces the throttle that locks accounts after failed
+// logins.
+func (as *AuthService) SetLoginThrottle(throttle *LoginThrottle) {
+	as.loginThrottle = throttle
 }
 
-func NewAuthService(userStorage *storage.UserStorage) *AuthService {
-	return &AuthService{
-		userStorage: userStorage,
-		sessions:    make(map[string]*Session),
+// ReserveUsernames keeps Signup and single sign-on from creating accounts
+// with these names. The ADMIN_USERS are reserved, so nobody can claim admin
+// rights by registering one of them before its owner does; those accounts
+// are created locally with the CLI.
+func (as *AuthService) ReserveUsernames(usernames []string) {
+	as.reservedUsernames = make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		as.reservedUsernames[username] = true
 	}
 }
 
-func (as *AuthService) Login(username string) (*models.User, *Session, error) {
+// Signup creates a user with a password and logs them in.
+func (as *AuthService) Signup(username, timezone, password string) (*models.User, *models.Session, error) {
+	if as.reservedUsernames[username] || as.userStorage.Exists(username) {
+		return nil, nil, ErrUserExists
+	}
+
+	user, err := models.NewUser(username, timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user.PasswordHash, err = HashPassword(password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Exists is only a shortcut, Create settles concurrent signups
+	if err := as.userStorage.Create(user); err != nil {
+		if errors.Is(err, storage.ErrUserExists) {
+			return nil, nil, ErrUserExists
+		}
+		return nil, nil, fmt.Errorf("failed to save user: %v", err)
+	}
+
+	session, err := as.newSession(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, session, nil
+}
+
+// Login checks a username and password and starts a session. Accounts
+// that failed to log in too often are locked for a while, see
+// LoginThrottle; while locked, the password is not even checked.
+func (as *AuthService) Login(username, password string) (*models.User, *models.Session, error) {
+	if err := as.loginThrottle.check(username, time.Now()); err != nil {
+		return nil, nil, err
+	}
+
 	user, err := as.userStorage.Load(username)
 	if err != nil {
-		return nil, nil, fmt.Errorf("user not found: %s", username)
+		CheckPassword("", password)
+		as.loginThrottle.fail(username, time.Now())
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	if !user.HasPassword() {
+		// Single sign-on accounts have no password to claim
+		if user.UsesSingleSignOn() {
+			CheckPassword("", password)
+			as.loginThrottle.fail(username, time.Now())
+			return nil, nil, ErrInvalidCredentials
+		}
+		as.loginThrottle.fail(username, time.Now())
+		return nil, nil, ErrPasswordSetupRequired
 	}
 
-	session := &Session{
-		ID:        generateSessionID(),
-		Username:  username,
-		ExpiresAt: time.Now().Add(24 * time.Hour), // 24 hour sessions
+	if !CheckPassword(user.PasswordHash, password) {
+		as.loginThrottle.fail(username, time.Now())
+		return nil, nil, ErrInvalidCredentials
 	}
+	as.loginThrottle.succeed(username)
 
-	as.sessions[session.ID] = session
+	session, err := as.newSession(username)
+	if err != nil {
+		return nil, nil, err
+	}
 	return user, session, nil
 }
 
-func (as *AuthService) ValidateSession(sessionID string) (*models.User, error) {
-	session, exists := as.sessions[sessionID]
-	if !exists {
+// SetInitialPassword sets the password of an account that has none, which
+// is how accounts created before passwords existed are claimed. Anyone who
+// knows the username could claim it this way, so it is only for callers
+// with local access to the data, such as the CLI, never the API.
+func (as *AuthService) SetInitialPassword(username, password string) error {
+	user, err := as.userStorage.Load(username)
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+
+	if user.HasPassword() {
+		return fmt.Errorf("account already has a password")
+	}
+	if user.UsesSingleSignOn() {
+		return ErrInvalidCredentials
+	}
+
+	user.PasswordHash, err = HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return as.userStorage.Save(user)
+}
+
+// ChangePassword replaces the password of username after verifying the
+// current one. Every session of the user except the one identified by
+// keepToken is logged out.
+func (as *AuthService) ChangePassword(username, currentPassword, newPassword, keepToken string) error {
+	user, err := as.userStorage.Load(username)
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+
+	if !CheckPassword(user.PasswordHash, currentPassword) {
+		return ErrInvalidCredentials
+	}
+
+	user.PasswordHash, err = HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := as.userStorage.Save(user); err != nil {
+		return fmt.Errorf("failed to save user: %v", err)
+	}
+
+	sessions, err := as.sessionStorage.ListByUser(username)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %v", err)
+	}
+	keepID := models.SessionID(keepToken)
+	for _, session := range sessions {
+		if session.ID == keepID {
+			continue
+		}
+		if err := as.sessionStorage.Delete(session.ID); err != nil {
+			return fmt.Errorf("failed to log out session: %v", err)
+		}
+	}
+	return nil
+}
+
+// ValidateSession returns the user logged in with token. Every use extends
+// the session to SessionTTL from now.
+func (as *AuthService) ValidateSession(token string) (*models.User, error) {
+	session, err := as.sessionStorage.Load(models.SessionID(token))
+	if err != nil {
 		return nil, fmt.Errorf("invalid session")
 	}
 
-	if time.Now().After(session.ExpiresAt) {
-		delete(as.sessions, sessionID)
+	now := time.Now()
+	if session.IsExpired(now) {
+		as.sessionStorage.Delete(session.ID)
 		return nil, fmt.Errorf("session expired")
 	}
 
@@ -59,15 +217,100 @@ func (as *AuthService) ValidateSession(sessionID string) (*models.User, error) {
 		return nil, fmt.Errorf("user not found: %s", session.Username)
 	}
 
+	if now.Sub(session.LastSeenAt) >= touchInterval {
+		// Touch never brings back a session logged out since it was loaded
+		if err := as.sessionStorage.Touch(session.ID, now, now.Add(SessionTTL)); err != nil {
+			if errors.Is(err, storage.ErrSessionNotFound) {
+				return nil, fmt.Errorf("invalid session")
+			}
+			return nil, fmt.Errorf("failed to extend session: %v", err)
+		}
+	}
+
 	return user, nil
 }
 
-func (as *AuthService) Logout(sessionID string) {
-	delete(as.sessions, sessionID)
+func (as *AuthService) Logout(token string) error {
+	return as.sessionStorage.Delete(models.SessionID(token))
+}
+
+// ListSessions returns the unexpired sessions of username, oldest first.
+func (as *AuthService) ListSessions(username string) ([]*models.Session, error) {
+	sessions, err := as.sessionStorage.ListByUser(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %v", err)
+	}
+
+	now := time.Now()
+	active := []*models.Session{}
+	for _, session := range sessions {
+		if !session.IsExpired(now) {
+			active = append(active, session)
+		}
+	}
+	return active, nil
 }
 
-func generateSessionID() string {
-	bytes := make([]byte, 16)
+// RevokeSession logs out the session id of username. Sessions of other
+// users are reported as not found.
+func (as *AuthService) RevokeSession(username, id string) error {
+	session, err := as.sessionStorage.Load(id)
+	if err != nil {
+		return err
+	}
+	if session.Username != username {
+		return fmt.Errorf("%w: %s", storage.ErrSessionNotFound, id)
+	}
+
+	return as.sessionStorage.Delete(id)
+}
+
+// LogoutEverywhere logs out every session of username and returns how many
+// there were.
+func (as *AuthService) LogoutEverywhere(username string) (int, error) {
+	sessions, err := as.sessionStorage.ListByUser(username)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %v", err)
+	}
+
+	for i, session := range sessions {
+		if err := as.sessionStorage.Delete(session.ID); err != nil {
+			return i, fmt.Errorf("failed to log out session: %v", err)
+		}
+	}
+	return len(sessions), nil
+}
+
+// DeleteExpiredSessions removes every expired session from storage and
+// returns how many there were.
+func (as *AuthService) DeleteExpiredSessions() (int, error) {
+	return as.sessionStorage.DeleteExpired(time.Now())
+}
+
+func (as *AuthService) newSession(username string) (*models.Session, error) {
+	token := generateSessionToken()
+	now := time.Now()
+	session := &models.Session{
+		ID:         models.SessionID(token),
+		Username:   username,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(SessionTTL),
+		Token:      token,
+	}
+
+	if err := as.sessionStorage.Save(session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %v", err)
+	}
+	return session, nil
+}
+
+func generateSessionToken() string {
+	return randomHex(32)
+}
+
+func randomHex(n int) string {
+	bytes := make([]byte, n)
 	rand.Read(bytes)
 	return hex.EncodeToString(bytes)
-}
\ No newline at end of file
+}
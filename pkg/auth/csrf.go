@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// CSRFToken returns the CSRF token of the session identified by
+// sessionToken. It is derived from the secret session token, so it needs
+// no storage and only the session's own client can know it.
+func CSRFToken(sessionToken string) string {
+	sum := sha256.Sum256([]byte("csrf:" + sessionToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckCSRFToken reports whether csrfToken belongs to the session
+// identified by sessionToken.
+func CheckCSRFToken(sessionToken, csrfToken string) bool {
+	if sessionToken == "" || csrfToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(CSRFToken(sessionToken)), []byte(csrfToken)) == 1
+}
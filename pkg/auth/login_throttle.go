@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defaults of the login throttle used by NewAuthService.
+const (
+	DefaultMaxLoginFailures = 5
+	DefaultLoginLockout     = 30 * time.Second
+	DefaultMaxLoginLockout  = 15 * time.Minute
+)
+
+// maxTrackedAccounts is how many accounts with failed logins are kept by
+// default. Failures are recorded for names nobody has registered too, so
+// beyond that the account whose last failure is the oldest is dropped.
+const maxTrackedAccounts = 1024
+
+// LoginLockedError is returned by Login while an account is locked after
+// too many failed logins.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed logins, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginThrottle locks accounts after repeated failed logins. Once an
+// account reaches maxFailures, every further failure locks it for twice as
+// long as the previous one, from lockout up to maxLockout. A successful
+// login, or maxLockout without failures, forgets them.
+//
+// Accounts are locked rather than clients so that guessing one password
+// from many addresses is slowed down too; the lockout is short so that
+// nobody can keep somebody else out for long.
+type LoginThrottle struct {
+	maxFailures int
+	lockout     time.Duration
+	maxLockout  time.Duration
+	maxTracked  int
+
+	mu       sync.Mutex
+	accounts map[string]*list.Element
+	// order holds the *loginFailures of accounts, oldest failure first
+	order *list.List
+}
+
+type loginFailures struct {
+	username    string
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func NewLoginThrottle(maxFailures int, lockout, maxLockout time.Duration) *LoginThrottle {
+	return &LoginThrottle{
+		maxFailures: maxFailures,
+		lockout:     lockout,
+		maxLockout:  maxLockout,
+		maxTracked:  maxTrackedAccounts,
+		accounts:    make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// SetMaxTracked changes how many accounts with failed logins are kept.
+func (lt *LoginThrottle) SetMaxTracked(max int) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	lt.maxTracked = max
+}
+
+// Tracked returns how many accounts with failed logins are kept.
+func (lt *LoginThrottle) Tracked() int {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	return len(lt.accounts)
+}
+
+// check returns a LoginLockedError while username is locked.
+func (lt *LoginThrottle) check(username string, now time.Time) error {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	element, ok := lt.accounts[username]
+	if !ok {
+		return nil
+	}
+	failures := element.Value.(*loginFailures)
+	if !now.Before(failures.lockedUntil) {
+		return nil
+	}
+	return &LoginLockedError{RetryAfter: failures.lockedUntil.Sub(now)}
+}
+
+// fail records a failed login of username.
+func (lt *LoginThrottle) fail(username string, now time.Time) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	// The oldest failures are the first to be forgotten
+	for oldest := lt.order.Front(); oldest != nil && lt.forgotten(oldest.Value.(*loginFailures), now); oldest = lt.order.Front() {
+		lt.remove(oldest)
+	}
+
+	element, ok := lt.accounts[username]
+	if ok {
+		lt.order.MoveToBack(element)
+	} else {
+		for lt.order.Len() > 0 && lt.order.Len() >= lt.maxTracked {
+			lt.remove(lt.order.Front())
+		}
+		element = lt.order.PushBack(&loginFailures{username: username})
+		lt.accounts[username] = element
+	}
+	failures := element.Value.(*loginFailures)
+	if lt.forgotten(failures, now) {
+		*failures = loginFailures{username: username}
+	}
+	failures.count++
+	failures.lastFailure = now
+
+	if excess := failures.count - lt.maxFailures; excess >= 0 {
+		lockout := lt.lockout
+		for i := 0; i < excess && lockout < lt.maxLockout; i++ {
+			lockout *= 2
+		}
+		if lockout > lt.maxLockout {
+			lockout = lt.maxLockout
+		}
+		failures.lockedUntil = now.Add(lockout)
+	}
+}
+
+// succeed forgets the failed logins of username.
+func (lt *LoginThrottle) succeed(username string) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	if element, ok := lt.accounts[username]; ok {
+		lt.remove(element)
+	}
+}
+
+func (lt *LoginThrottle) remove(element *list.Element) {
+	delete(lt.accounts, element.Value.(*loginFailures).username)
+	lt.order.Remove(element)
+}
+
+func (lt *LoginThrottle) forgotten(failures *loginFailures, now time.Time) bool {
+	return !now.Before(failures.lockedUntil) && now.Sub(failures.lastFailure) >= lt.maxLockout
+}
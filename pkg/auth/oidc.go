@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/oidc"
+	"perfect-day/pkg/storage"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxUsernameAttempts bounds the search for a free username when an
+// identity logs in for the first time.
+const maxUsernameAttempts = 100
+
+var invalidUsernameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// LoginWithOIDC logs in the user linked to the identity verified by an
+// OpenID Connect provider, creating an account on its first login.
+// Existing local accounts are never linked by name or email, so nobody can
+// take one over by picking a matching name at the provider.
+func (as *AuthService) LoginWithOIDC(claims *oidc.Claims) (*models.User, *models.Session, error) {
+	user, err := as.findOIDCUser(claims.Issuer, claims.Subject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user == nil {
+		user, err = as.createOIDCUser(claims)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	session, err := as.newSession(user.Username)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, session, nil
+}
+
+func (as *AuthService) findOIDCUser(issuer, subject string) (*models.User, error) {
+	users, err := as.userStorage.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %v", err)
+	}
+	for _, user := range users {
+		if user.OIDCIssuer == issuer && user.OIDCSubject == subject {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (as *AuthService) createOIDCUser(claims *oidc.Claims) (*models.User, error) {
+	base := oidcUsername(claims)
+
+	for attempt := 1; attempt <= maxUsernameAttempts; attempt++ {
+		username := base
+		if attempt > 1 {
+			username = fmt.Sprintf("%s-%d", base, attempt)
+		}
+		if as.reservedUsernames[username] || as.userStorage.Exists(username) {
+			continue
+		}
+
+		user, err := models.NewUser(username, "UTC")
+		if err != nil {
+			continue
+		}
+		user.OIDCIssuer = claims.Issuer
+		user.OIDCSubject = claims.Subject
+		if name := strings.TrimSpace(claims.Name); name != "" && utf8.RuneCountInString(name) <= models.MaxDisplayNameLength {
+			user.DisplayName = name
+		}
+
+		err = as.userStorage.Create(user)
+		if errors.Is(err, storage.ErrUserExists) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to save user: %v", err)
+		}
+		return user, nil
+	}
+
+	return nil, fmt.Errorf("no free username for %q", base)
+}
+
+// oidcUsername derives a valid username from the identity's preferred
+// username or email address, leaving room for a numeric suffix.
+func oidcUsername(claims *oidc.Claims) string {
+	candidate := claims.PreferredUsername
+	if candidate == "" {
+		candidate, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	candidate = strings.Trim(invalidUsernameChars.ReplaceAllString(candidate, "-"), "-")
+	if len(candidate) > 16 {
+		candidate = strings.TrimRight(candidate[:16], "-")
+	}
+	if len(candidate) < 3 {
+		return "user"
+	}
+	return candidate
+}
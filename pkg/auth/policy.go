@@ -0,0 +1,78 @@
+package auth
+
+import "perfect-day/pkg/models"
+
+// Actions on a perfect day that the policy decides about.
+const (
+	// ActionRead views a live perfect day and its history.
+	ActionRead = "read"
+	// ActionEdit changes the content of a live perfect day.
+	ActionEdit = "edit"
+	// ActionDelete moves a live perfect day to the trash.
+	ActionDelete = "delete"
+	// ActionPurge deletes a perfect day for good, from the trash or not.
+	ActionPurge = "purge"
+	// ActionRestore takes a perfect day out of the trash.
+	ActionRestore = "restore"
+)
+
+// Policy decides what users may do. Owners may do anything with their own
+// perfect days; beyond that, moderators may move any perfect day to the
+// trash and admins may do everything.
+type Policy struct {
+	adminUsers map[string]bool
+}
+
+// NewPolicy returns the policy. The users in adminUsers are admins
+// whatever role is stored for them, so a fresh installation can always be
+// administered.
+func NewPolicy(adminUsers []string) *Policy {
+	admins := make(map[string]bool, len(adminUsers))
+	for _, username := range adminUsers {
+		admins[username] = true
+	}
+	return &Policy{adminUsers: admins}
+}
+
+// Role returns the role user acts with.
+func (p *Policy) Role(user *models.User) string {
+	if p.adminUsers[user.Username] {
+		return models.RoleAdmin
+	}
+	return user.EffectiveRole()
+}
+
+// HasRole reports whether user has role or a more privileged one.
+func (p *Policy) HasRole(user *models.User, role string) bool {
+	return user != nil && models.RoleIncludes(p.Role(user), role)
+}
+
+// Can reports whether user may perform action on perfectDay. Anonymous
+// requests have a nil user.
+func (p *Policy) Can(user *models.User, action string, perfectDay *models.PerfectDay) bool {
+	// Only purging and restoring reach into the trash
+	if perfectDay.IsDeleted && action != ActionPurge && action != ActionRestore {
+		return false
+	}
+
+	if action == ActionRead {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	if perfectDay.Username == user.Username || p.HasRole(user, models.RoleAdmin) {
+		return true
+	}
+	return action == ActionDelete && p.HasRole(user, models.RoleModerator)
+}
+
+// CanSee reports whether user may know that perfectDay exists at all. Users
+// who cannot see a perfect day are told it is not found rather than
+// forbidden.
+func (p *Policy) CanSee(user *models.User, perfectDay *models.PerfectDay) bool {
+	if !perfectDay.IsDeleted {
+		return true
+	}
+	return user != nil && (perfectDay.Username == user.Username || p.HasRole(user, models.RoleAdmin))
+}
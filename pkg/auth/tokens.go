@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned by ValidateToken for unknown, revoked and
+// expired API tokens.
+var ErrInvalidToken = errors.New("invalid API token")
+
+// maxTokenNameLength keeps token names short enough to list.
+const maxTokenNameLength = 100
+
+// CreateToken issues a personal API token for username. A zero ttl creates
+// a token that never expires. The returned token is the only copy of the
+// secret.
+func (as *AuthService) CreateToken(username, name string, scopes []string, ttl time.Duration) (*models.APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("token name is required")
+	}
+	if len(name) > maxTokenNameLength {
+		return nil, fmt.Errorf("token name must be at most %d characters", maxTokenNameLength)
+	}
+	if err := models.ValidateScopes(scopes); err != nil {
+		return nil, err
+	}
+	if ttl < 0 {
+		return nil, fmt.Errorf("token lifetime cannot be negative")
+	}
+
+	secret := models.APITokenPrefix + randomHex(32)
+	token := &models.APIToken{
+		ID:        models.APITokenID(secret),
+		Username:  username,
+		Name:      name,
+		Scopes:    uniqueScopes(scopes),
+		CreatedAt: time.Now(),
+		Token:     secret,
+	}
+	if ttl > 0 {
+		expiresAt := token.CreatedAt.Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := as.tokenStorage.Save(token); err != nil {
+		return nil, fmt.Errorf("failed to save API token: %v", err)
+	}
+	return token, nil
+}
+
+// ValidateToken returns the user an API token belongs to along with the
+// token, recording when it was last used.
+func (as *AuthService) ValidateToken(secret string) (*models.User, *models.APIToken, error) {
+	if !strings.HasPrefix(secret, models.APITokenPrefix) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	token, err := as.tokenStorage.Load(models.APITokenID(secret))
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if token.IsExpired(now) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	user, err := as.userStorage.Load(token.Username)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= touchInterval {
+		// Touch never writes back a token revoked since it was loaded
+		if err := as.tokenStorage.Touch(token.ID, now); err != nil {
+			if errors.Is(err, storage.ErrTokenNotFound) {
+				return nil, nil, ErrInvalidToken
+			}
+			return nil, nil, fmt.Errorf("failed to record API token use: %v", err)
+		}
+		token.LastUsedAt = &now
+	}
+
+	return user, token, nil
+}
+
+// ListTokens returns the API tokens of username, oldest first, including
+// expired ones so they can be told apart from revoked ones.
+func (as *AuthService) ListTokens(username string) ([]*models.APIToken, error) {
+	tokens, err := as.tokenStorage.ListByUser(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %v", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken deletes the API token id of username. Tokens of other users
+// are reported as not found.
+func (as *AuthService) RevokeToken(username, id string) error {
+	token, err := as.tokenStorage.Load(id)
+	if err != nil {
+		return err
+	}
+	if token.Username != username {
+		return fmt.Errorf("%w: %s", storage.ErrTokenNotFound, id)
+	}
+
+	return as.tokenStorage.Delete(id)
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	unique := []string{}
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,386 @@
+// Package backup writes and restores a data directory as a single
+// gzip-compressed tar archive with a checksummed manifest.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"perfect-day/pkg/search"
+	"perfect-day/pkg/storage"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the archive layout written by Create.
+// Restore refuses archives from a newer version.
+const SchemaVersion = 1
+
+// ManifestName is the first entry of every archive.
+const ManifestName = "manifest.json"
+
+// Manifest describes the content of an archive.
+type Manifest struct {
+	SchemaVersion int         `json:"schema_version"`
+	CreatedAt     time.Time   `json:"created_at"`
+	Files         []FileEntry `json:"files"`
+}
+
+// FileEntry is one file of the archive, addressed by its slash-separated
+// path relative to the data directory.
+type FileEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// preserved lists top-level entries of the data directory that belong to
+// the installation rather than its data: they are never archived and are
+// left in place on restore. The CLI configuration may hold API keys and
+// the cursor key signs the API's pagination cursors.
+var preserved = map[string]bool{
+	storage.LockFileName:     true,
+	"config.json":            true,
+	search.CursorKeyFileName: true,
+}
+
+// transient lists SQLite's journal files. They are not archived because
+// their content is folded into the database snapshot, but they are moved
+// aside with the rest of the data on restore so they cannot be replayed
+// into the restored database.
+var transient = map[string]bool{
+	storage.SQLiteFileName + "-wal":     true,
+	storage.SQLiteFileName + "-shm":     true,
+	storage.SQLiteFileName + "-journal": true,
+}
+
+// Create writes an archive of dataDir to w. Writers of the file backend are
+// held off for the duration so users, perfect days, the ID index and
+// revisions are captured at a single point in time; a SQLite database is
+// snapshotted through SQLite itself.
+func Create(w io.Writer, dataDir string) (*Manifest, error) {
+	if _, err := os.Stat(dataDir); err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %v", err)
+	}
+
+	lock, err := storage.RLockDataDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	snapshotDir, err := os.MkdirTemp("", "perfect-day-backup-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
+	defer os.RemoveAll(snapshotDir)
+
+	// sources maps archive paths to the files their content is read from.
+	sources, err := collectFiles(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, exists := sources[storage.SQLiteFileName]; exists {
+		snapshot := filepath.Join(snapshotDir, storage.SQLiteFileName)
+		if err := storage.SnapshotSQLite(filepath.Join(dataDir, storage.SQLiteFileName), snapshot); err != nil {
+			return nil, err
+		}
+		sources[storage.SQLiteFileName] = snapshot
+	}
+
+	manifest := &Manifest{
+		SchemaVersion: SchemaVersion,
+		CreatedAt:     time.Now().UTC(),
+		Files:         []FileEntry{},
+	}
+	for archivePath, source := range sources {
+		entry, err := describeFile(archivePath, source)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, *entry)
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %v", err)
+	}
+	if err := writeEntry(tw, ManifestName, int64(len(manifestData)), bytes.NewReader(manifestData)); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range manifest.Files {
+		file, err := os.Open(sources[entry.Path])
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %v", entry.Path, err)
+		}
+		err = writeEntry(tw, entry.Path, entry.Size, file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %v", err)
+	}
+
+	return manifest, nil
+}
+
+// Restore verifies the archive read from r and replaces the content of
+// dataDir with it. Nothing in dataDir is touched unless every file matches
+// the manifest. The replaced content is moved to a sibling directory whose
+// path is returned (empty if dataDir held no data), so a restore can itself
+// be undone. No backend may have the data directory open meanwhile.
+func Restore(r io.Reader, dataDir string) (*Manifest, string, error) {
+	parent := filepath.Dir(filepath.Clean(dataDir))
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, "", fmt.Errorf("failed to create data directory: %v", err)
+	}
+
+	// Stage next to the data directory so the final moves are renames on
+	// the same file system.
+	stagingDir, err := os.MkdirTemp(parent, ".perfect-day-restore-*")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create staging directory: %v", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	manifest, err := extract(r, stagingDir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, "", fmt.Errorf("failed to create data directory: %v", err)
+	}
+
+	lock, err := storage.LockDataDir(dataDir)
+	if err != nil {
+		return nil, "", err
+	}
+	defer lock.Unlock()
+
+	previousDir := filepath.Clean(dataDir) + ".before-restore-" + time.Now().Format("20060102-150405")
+	if err := moveEntries(dataDir, previousDir); err != nil {
+		return nil, "", fmt.Errorf("failed to move current data aside: %v", err)
+	}
+	if err := moveEntries(stagingDir, dataDir); err != nil {
+		return nil, "", fmt.Errorf("failed to move restored data into place (previous data is in %s): %v", previousDir, err)
+	}
+
+	if _, err := os.Stat(previousDir); err != nil {
+		previousDir = ""
+	}
+	return manifest, previousDir, nil
+}
+
+// Verify checks an archive against its manifest without restoring it.
+func Verify(r io.Reader) (*Manifest, error) {
+	stagingDir, err := os.MkdirTemp("", "perfect-day-verify-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %v", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	return extract(r, stagingDir)
+}
+
+// collectFiles returns every archivable regular file below dataDir, keyed
+// by archive path.
+func collectFiles(dataDir string) (map[string]string, error) {
+	sources := make(map[string]string)
+
+	err := filepath.Walk(dataDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dataDir, filePath)
+		if err != nil || rel == "." {
+			return err
+		}
+		archivePath := filepath.ToSlash(rel)
+
+		if preserved[archivePath] || transient[archivePath] || strings.Contains(info.Name(), ".tmp-") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			sources[archivePath] = filePath
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %v", err)
+	}
+
+	return sources, nil
+}
+
+func describeFile(archivePath, source string) (*FileEntry, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", archivePath, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", archivePath, err)
+	}
+
+	return &FileEntry{Path: archivePath, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, content io.Reader) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	if _, err := io.CopyN(tw, content, size); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	return nil
+}
+
+// extract unpacks the archive into dir, checking every file against the
+// manifest.
+func extract(r io.Reader, dir string) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %v", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil || header.Name != ManifestName {
+		return nil, fmt.Errorf("not a backup archive: %s must be the first entry", ManifestName)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	if manifest.SchemaVersion < 1 || manifest.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("unsupported backup schema version %d (this version supports up to %d)", manifest.SchemaVersion, SchemaVersion)
+	}
+
+	expected := make(map[string]FileEntry)
+	for _, entry := range manifest.Files {
+		expected[entry.Path] = entry
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %v", err)
+		}
+
+		entry, listed := expected[header.Name]
+		if !listed {
+			return nil, fmt.Errorf("archive entry %s is not listed in the manifest", header.Name)
+		}
+		delete(expected, header.Name)
+
+		if err := extractFile(tr, dir, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(expected) > 0 {
+		missing := make([]string, 0, len(expected))
+		for name := range expected {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("archive is missing files listed in the manifest: %s", strings.Join(missing, ", "))
+	}
+
+	return &manifest, nil
+}
+
+func extractFile(content io.Reader, dir string, entry FileEntry) error {
+	clean := path.Clean(entry.Path)
+	if clean != entry.Path || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("archive entry %s has an unsafe path", entry.Path)
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(clean))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", entry.Path, err)
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", entry.Path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), content)
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %v", entry.Path, err)
+	}
+	if size != entry.Size || hex.EncodeToString(hash.Sum(nil)) != entry.SHA256 {
+		return fmt.Errorf("checksum mismatch for %s, the archive is corrupt", entry.Path)
+	}
+
+	return file.Sync()
+}
+
+// moveEntries moves every top-level entry of src that is not preserved into
+// dst, creating dst only when there is something to move.
+func moveEntries(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if preserved[entry.Name()] {
+			continue
+		}
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -12,6 +12,37 @@ type Config struct {
 	Timezone         string `json:"timezone"`
 	DataDir          string `json:"data_dir"`
 	GooglePlacesAPIKey string `json:"google_places_api_key,omitempty"`
+	StorageBackend     string `json:"storage_backend,omitempty"`
+	// TrashRetention is how long soft-deleted perfect days are kept before
+	// they are purged automatically, e.g. "30d". Empty keeps them forever.
+	TrashRetention string `json:"trash_retention,omitempty"`
+	// AdminUsers are admins whatever role is stored for them.
+	AdminUsers []string `json:"admin_users,omitempty"`
+	// OIDCIssuer enables logging in with an OpenID Connect provider. The
+	// client ID and redirect URL are required with it, the secret is
+	// optional for public clients.
+	OIDCIssuer       string `json:"oidc_issuer,omitempty"`
+	OIDCClientID     string `json:"oidc_client_id,omitempty"`
+	OIDCClientSecret string `json:"oidc_client_secret,omitempty"`
+	OIDCRedirectURL  string `json:"oidc_redirect_url,omitempty"`
+	// RateLimit, AuthRateLimit and PlacesRateLimit limit the requests per
+	// client to the API, the login and signup routes and place searches,
+	// e.g. "300/m". Empty uses the default and "off" disables the limit.
+	RateLimit       string `json:"rate_limit,omitempty"`
+	AuthRateLimit   string `json:"auth_rate_limit,omitempty"`
+	PlacesRateLimit string `json:"places_rate_limit,omitempty"`
+	// TrustedProxies may set X-Forwarded-For, which then gives the client
+	// IP used for rate limiting. Without any, the connection's address is
+	// used.
+	TrustedProxies []string `json:"trusted_proxies,omitempty"`
+	// AllowedOrigins are the browser origins that may call the API with
+	// cookies. "*" allows any origin without cookies; none disables CORS.
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+	// CookieSecure marks cookies Secure, for APIs served over HTTPS, and
+	// CookieSameSite is their SameSite mode: lax (the default), strict or
+	// none, which requires CookieSecure.
+	CookieSecure   bool   `json:"cookie_secure,omitempty"`
+	CookieSameSite string `json:"cookie_samesite,omitempty"`
 }
 
 func LoadConfig(configPath string) (*Config, error) {
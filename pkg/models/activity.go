@@ -17,19 +17,7 @@ type Activity struct {
 }
 
 func NewActivity(id, name string, location Location, startTime string, duration int, description, commentary string) (*Activity, error) {
-	if err := validateActivityTime(startTime); err != nil {
-		return nil, err
-	}
-
-	if duration <= 0 {
-		return nil, fmt.Errorf("duration must be positive")
-	}
-
-	if name == "" {
-		return nil, fmt.Errorf("activity name is required")
-	}
-
-	return &Activity{
+	activity := &Activity{
 		ID:          id,
 		Name:        name,
 		Location:    location,
@@ -38,7 +26,27 @@ func NewActivity(id, name string, location Location, startTime string, duration
 		Description: description,
 		Commentary:  commentary,
 		CreatedAt:   time.Now(),
-	}, nil
+	}
+	if err := activity.Validate(); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// Validate checks the start time, duration and name of the activity.
+func (a *Activity) Validate() error {
+	if err := validateActivityTime(a.StartTime); err != nil {
+		return err
+	}
+
+	if a.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+
+	if a.Name == "" {
+		return fmt.Errorf("activity name is required")
+	}
+	return nil
 }
 
 func validateActivityTime(timeStr string) error {
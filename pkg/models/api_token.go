@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scopes limit what a personal API token may do. Sessions are not scoped.
+const (
+	ScopePerfectDaysRead  = "perfect_days:read"
+	ScopePerfectDaysWrite = "perfect_days:write"
+	ScopeAdmin            = "admin"
+)
+
+// Scopes lists every scope a token can be given.
+var Scopes = []string{ScopePerfectDaysRead, ScopePerfectDaysWrite, ScopeAdmin}
+
+// APITokenPrefix starts every personal API token so leaked tokens are easy
+// to recognize.
+const APITokenPrefix = "pdt_"
+
+// APIToken is a long-lived personal access token for scripts and
+// integrations. Like sessions, only a hash of the token is stored.
+type APIToken struct {
+	// ID identifies the token in storage and listings. It is the SHA-256 of
+	// the token.
+	ID         string     `json:"id"`
+	Username   string     `json:"username"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	// ExpiresAt is nil for tokens that never expire.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Token is the secret sent in the Authorization header. It is only
+	// known when the token is created and is never stored.
+	Token string `json:"-"`
+}
+
+// APITokenID returns the ID of the API token token.
+func APITokenID(token string) string {
+	return SessionID(token)
+}
+
+// ValidateScopes checks that scopes is a non-empty list of known scopes.
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required (%s)", strings.Join(Scopes, ", "))
+	}
+	for _, scope := range scopes {
+		if !isKnownScope(scope) {
+			return fmt.Errorf("unknown scope %q (expected one of %s)", scope, strings.Join(Scopes, ", "))
+		}
+	}
+	return nil
+}
+
+// HasScope reports whether the token grants scope. Write access to perfect
+// days includes read access.
+func (t *APIToken) HasScope(scope string) bool {
+	for _, granted := range t.Scopes {
+		if granted == scope || (granted == ScopePerfectDaysWrite && scope == ScopePerfectDaysRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired reports whether the token is no longer valid at now.
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range Scopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Audit actions recorded by the API.
+const (
+	AuditLoginSuccess      = "login.success"
+	AuditLoginFailure      = "login.failure"
+	AuditLogout            = "logout"
+	AuditSessionRevoke     = "session.revoke"
+	AuditPasswordChange    = "password.change"
+	AuditTokenCreate       = "token.create"
+	AuditTokenRevoke       = "token.revoke"
+	AuditUserCreate        = "user.create"
+	AuditUserUpdate        = "user.update"
+	AuditUserDelete        = "user.delete"
+	AuditUserRoleChange    = "user.role_change"
+	AuditPerfectDayCreate  = "perfect_day.create"
+	AuditPerfectDayUpdate  = "perfect_day.update"
+	AuditPerfectDayDelete  = "perfect_day.delete"
+	AuditPerfectDayRestore = "perfect_day.restore"
+	AuditPerfectDayPurge   = "perfect_day.purge"
+)
+
+// AuditEvent records who did what to which target, and from where. Events
+// are only ever appended.
+type AuditEvent struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// Actor is the user that acted. It is empty for failed logins, whose
+	// target is the account the client tried to log in as.
+	Actor  string `json:"actor"`
+	Action string `json:"action"`
+	// Target identifies what was acted on, e.g. "perfect_day:<id>" or
+	// "user:<username>".
+	Target    string `json:"target,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	// Detail adds context such as the reason a login failed.
+	Detail string `json:"detail,omitempty"`
+}
+
+// AuditTarget formats the target of an event.
+func AuditTarget(kind, id string) string {
+	return kind + ":" + id
+}
+
+// AuditFilter selects audit events. Empty fields match everything; From is
+// inclusive and To exclusive.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	From   time.Time
+	To     time.Time
+	// Limit caps the number of events returned, newest first. Zero means
+	// no limit.
+	Limit int
+}
+
+// Matches reports whether event is selected by the filter, ignoring Limit.
+func (f AuditFilter) Matches(event *AuditEvent) bool {
+	if f.Actor != "" && event.Actor != f.Actor {
+		return false
+	}
+	if f.Action != "" && event.Action != f.Action {
+		return false
+	}
+	if !f.From.IsZero() && event.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !event.Time.Before(f.To) {
+		return false
+	}
+	return true
+}
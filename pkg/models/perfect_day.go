@@ -1,12 +1,17 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"time"
 )
 
+// ErrActivityNotFound is returned for an activity ID a perfect day does not
+// have.
+var ErrActivityNotFound = errors.New("activity not found")
+
 type PerfectDay struct {
 	ID          string     `json:"id"`
 	Title       string     `json:"title"`
@@ -16,8 +21,16 @@ type PerfectDay struct {
 	Areas       []string   `json:"areas"`
 	Activities  []Activity `json:"activities"`
 	IsDeleted   bool       `json:"is_deleted"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+	// UpdatedBy is the user who made the latest change; it is recorded as
+	// the author of the stored revision.
+	UpdatedBy string `json:"updated_by,omitempty"`
+	// Revision is incremented by storage on every save. A save is rejected
+	// when the stored revision no longer matches, so concurrent editors
+	// cannot silently overwrite each other.
+	Revision int `json:"revision"`
 }
 
 func NewPerfectDay(id, title, description, username, date string) (*PerfectDay, error) {
@@ -69,8 +82,14 @@ func (pd *PerfectDay) SortActivitiesByTime() {
 }
 
 func (pd *PerfectDay) updateAreas() {
+	pd.Areas = AreasFromActivities(pd.Activities)
+}
+
+// AreasFromActivities returns the sorted, de-duplicated areas visited by
+// the given activities.
+func AreasFromActivities(activities []Activity) []string {
 	areaSet := make(map[string]bool)
-	for _, activity := range pd.Activities {
+	for _, activity := range activities {
 		if activity.Location.Area != "" {
 			areaSet[activity.Location.Area] = true
 		}
@@ -81,7 +100,7 @@ func (pd *PerfectDay) updateAreas() {
 		areas = append(areas, area)
 	}
 	sort.Strings(areas)
-	pd.Areas = areas
+	return areas
 }
 
 func (pd *PerfectDay) UpdateAreas() {
@@ -89,11 +108,95 @@ func (pd *PerfectDay) UpdateAreas() {
 	pd.UpdatedAt = time.Now()
 }
 
+// FindActivity returns the activity with the given ID.
+func (pd *PerfectDay) FindActivity(id string) (*Activity, error) {
+	for i := range pd.Activities {
+		if pd.Activities[i].ID == id {
+			return &pd.Activities[i], nil
+		}
+	}
+	return nil, ErrActivityNotFound
+}
+
+// UpdateActivity replaces the activity with the given ID by changes, which
+// keeps the activity's ID, creation time and position.
+func (pd *PerfectDay) UpdateActivity(id string, changes Activity) (*Activity, error) {
+	activity, err := pd.FindActivity(id)
+	if err != nil {
+		return nil, err
+	}
+	changes.ID = activity.ID
+	changes.CreatedAt = activity.CreatedAt
+	if err := changes.Validate(); err != nil {
+		return nil, err
+	}
+
+	*activity = changes
+	pd.UpdateAreas()
+	return activity, nil
+}
+
+// RemoveActivity removes the activity with the given ID.
+func (pd *PerfectDay) RemoveActivity(id string) error {
+	for i := range pd.Activities {
+		if pd.Activities[i].ID == id {
+			pd.Activities = append(pd.Activities[:i], pd.Activities[i+1:]...)
+			pd.UpdateAreas()
+			return nil
+		}
+	}
+	return ErrActivityNotFound
+}
+
+// ReorderActivities puts the activities in the order of ids, which must
+// list the ID of every activity exactly once.
+func (pd *PerfectDay) ReorderActivities(ids []string) error {
+	if len(ids) != len(pd.Activities) {
+		return fmt.Errorf("expected %d activity IDs, got %d", len(pd.Activities), len(ids))
+	}
+
+	byID := make(map[string]Activity, len(pd.Activities))
+	for _, activity := range pd.Activities {
+		byID[activity.ID] = activity
+	}
+	reordered := make([]Activity, 0, len(ids))
+	for _, id := range ids {
+		activity, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("activity %q is not in this perfect day or listed twice", id)
+		}
+		delete(byID, id)
+		reordered = append(reordered, activity)
+	}
+
+	pd.Activities = reordered
+	pd.UpdatedAt = time.Now()
+	return nil
+}
+
 func (pd *PerfectDay) SoftDelete() {
+	now := time.Now()
 	pd.IsDeleted = true
+	pd.DeletedAt = &now
+	pd.UpdatedAt = now
+}
+
+// Restore takes a soft-deleted perfect day out of the trash.
+func (pd *PerfectDay) Restore() {
+	pd.IsDeleted = false
+	pd.DeletedAt = nil
 	pd.UpdatedAt = time.Now()
 }
 
+// DeletionTime returns when the perfect day was moved to the trash. Records
+// deleted before DeletedAt existed fall back to their last update.
+func (pd *PerfectDay) DeletionTime() time.Time {
+	if pd.DeletedAt != nil {
+		return *pd.DeletedAt
+	}
+	return pd.UpdatedAt
+}
+
 func (pd *PerfectDay) SearchableContent() string {
 	var content strings.Builder
 	content.WriteString(pd.Title + " ")
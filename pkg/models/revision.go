@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PerfectDayRevision is an immutable snapshot of a perfect day as it was
+// saved at one revision.
+type PerfectDayRevision struct {
+	Revision   int        `json:"revision"`
+	Author     string     `json:"author"`
+	CreatedAt  time.Time  `json:"created_at"`
+	PerfectDay PerfectDay `json:"perfect_day"`
+}
+
+// NewPerfectDayRevision snapshots pd, which must already carry the revision
+// number it is being saved as.
+func NewPerfectDayRevision(pd *PerfectDay) *PerfectDayRevision {
+	author := pd.UpdatedBy
+	if author == "" {
+		author = pd.Username
+	}
+
+	snapshot := *pd
+	snapshot.Areas = append([]string{}, pd.Areas...)
+	snapshot.Activities = append([]Activity{}, pd.Activities...)
+
+	return &PerfectDayRevision{
+		Revision:   pd.Revision,
+		Author:     author,
+		CreatedAt:  time.Now(),
+		PerfectDay: snapshot,
+	}
+}
+
+// FieldChange describes one field that differs between two versions of a
+// perfect day. Activity fields are named by position, e.g.
+// "activities[2].start_time".
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// DiffPerfectDays lists the user-visible fields that differ between old and
+// new. Bookkeeping fields (timestamps, revision, author) are ignored.
+func DiffPerfectDays(old, new *PerfectDay) []FieldChange {
+	changes := []FieldChange{}
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	add("title", old.Title, new.Title)
+	add("description", old.Description, new.Description)
+	add("date", old.Date, new.Date)
+	add("is_deleted", strconv.FormatBool(old.IsDeleted), strconv.FormatBool(new.IsDeleted))
+
+	count := len(old.Activities)
+	if len(new.Activities) > count {
+		count = len(new.Activities)
+	}
+	for i := 0; i < count; i++ {
+		prefix := fmt.Sprintf("activities[%d]", i)
+		if i >= len(old.Activities) {
+			add(prefix, "", activitySummary(new.Activities[i]))
+			continue
+		}
+		if i >= len(new.Activities) {
+			add(prefix, activitySummary(old.Activities[i]), "")
+			continue
+		}
+
+		oldActivity, newActivity := old.Activities[i], new.Activities[i]
+		add(prefix+".name", oldActivity.Name, newActivity.Name)
+		add(prefix+".start_time", oldActivity.StartTime, newActivity.StartTime)
+		add(prefix+".duration_minutes", strconv.Itoa(oldActivity.Duration), strconv.Itoa(newActivity.Duration))
+		add(prefix+".location", locationSummary(oldActivity.Location), locationSummary(newActivity.Location))
+		add(prefix+".description", oldActivity.Description, newActivity.Description)
+		add(prefix+".commentary", oldActivity.Commentary, newActivity.Commentary)
+	}
+
+	return changes
+}
+
+func activitySummary(activity Activity) string {
+	return fmt.Sprintf("%s %s (%dm) @ %s", activity.StartTime, activity.Name, activity.Duration, locationSummary(activity.Location))
+}
+
+func locationSummary(location Location) string {
+	parts := []string{location.Name}
+	if location.Area != "" {
+		parts = append(parts, location.Area)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RestoreFrom replaces the content of pd with that of an earlier snapshot,
+// keeping its identity, owner, creation time and current revision so the
+// restore is saved as a new revision on top of the latest one.
+func (pd *PerfectDay) RestoreFrom(snapshot *PerfectDay) {
+	pd.Title = snapshot.Title
+	pd.Description = snapshot.Description
+	pd.Date = snapshot.Date
+	pd.Activities = append([]Activity{}, snapshot.Activities...)
+	pd.updateAreas()
+	pd.UpdatedAt = time.Now()
+}
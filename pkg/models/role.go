@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Roles grant access beyond a user's own content. Each role includes the
+// permissions of the ones before it.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Roles lists every role from least to most privileged.
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// ValidateRole checks that role is one of Roles.
+func ValidateRole(role string) error {
+	if roleRank(role) < 0 {
+		return fmt.Errorf("unknown role %q (expected one of %s)", role, strings.Join(Roles, ", "))
+	}
+	return nil
+}
+
+// RoleIncludes reports whether role grants everything required does.
+func RoleIncludes(role, required string) bool {
+	return roleRank(role) >= roleRank(required) && roleRank(required) >= 0
+}
+
+func roleRank(role string) int {
+	for i, known := range Roles {
+		if role == known {
+			return i
+		}
+	}
+	return -1
+}
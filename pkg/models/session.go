@@ -0,0 +1,34 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Session is a logged in client. Only a hash of the token handed to the
+// client is stored, so a copy of the stored sessions cannot be used to log
+// in.
+type Session struct {
+	// ID identifies the session in storage and listings. It is the SHA-256
+	// of the token.
+	ID         string    `json:"id"`
+	Username   string    `json:"username"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Token is the secret sent in the session cookie. It is only known
+	// when the session is created and is never stored.
+	Token string `json:"-"`
+}
+
+// SessionID returns the ID of the session identified by token.
+func SessionID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsExpired reports whether the session is no longer valid at now.
+func (s *Session) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
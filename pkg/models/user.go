@@ -3,13 +3,37 @@ package models
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
+)
+
+// DeletedUsername owns the perfect days of deleted accounts that chose to
+// anonymize them. It is reserved so nobody can sign up as it.
+const DeletedUsername = "deleted-user"
+
+// Limits of the optional profile fields, in characters.
+const (
+	MaxDisplayNameLength = 50
+	MaxBioLength         = 500
 )
 
 type User struct {
-	Username  string    `json:"username"`
-	Timezone  string    `json:"timezone"`
+	Username    string `json:"username"`
+	Timezone    string `json:"timezone"`
+	DisplayName string `json:"display_name,omitempty"`
+	Bio         string `json:"bio,omitempty"`
+	// Role is one of Roles; users stored before roles existed have none and
+	// count as RoleUser.
+	Role      string    `json:"role,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
+	// PasswordHash is the salted bcrypt hash of the user's password. It is
+	// empty for accounts created before passwords existed.
+	PasswordHash string `json:"password_hash,omitempty"`
+	// OIDCIssuer and OIDCSubject identify the single sign-on identity the
+	// user logs in with. They are empty for local accounts.
+	OIDCIssuer  string `json:"oidc_issuer,omitempty"`
+	OIDCSubject string `json:"oidc_subject,omitempty"`
 }
 
 func NewUser(username, timezone string) (*User, error) {
@@ -28,6 +52,61 @@ func NewUser(username, timezone string) (*User, error) {
 	}, nil
 }
 
+// EffectiveRole returns the user's role, defaulting to RoleUser.
+func (u *User) EffectiveRole() string {
+	if u.Role == "" {
+		return RoleUser
+	}
+	return u.Role
+}
+
+// HasPassword reports whether the user has set a password. Accounts without
+// one must set it on their next login.
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != ""
+}
+
+// UsesSingleSignOn reports whether the user logs in with an OpenID Connect
+// provider. Such accounts have no password to set.
+func (u *User) UsesSingleSignOn() bool {
+	return u.OIDCSubject != ""
+}
+
+// UpdateProfile changes the fields that are not nil. Nothing changes unless
+// every given field is valid.
+func (u *User) UpdateProfile(timezone, displayName, bio *string) error {
+	if timezone != nil {
+		if err := validateTimezone(*timezone); err != nil {
+			return err
+		}
+	}
+
+	var trimmedName, trimmedBio string
+	if displayName != nil {
+		trimmedName = strings.TrimSpace(*displayName)
+		if utf8.RuneCountInString(trimmedName) > MaxDisplayNameLength {
+			return fmt.Errorf("display name must be at most %d characters", MaxDisplayNameLength)
+		}
+	}
+	if bio != nil {
+		trimmedBio = strings.TrimSpace(*bio)
+		if utf8.RuneCountInString(trimmedBio) > MaxBioLength {
+			return fmt.Errorf("bio must be at most %d characters", MaxBioLength)
+		}
+	}
+
+	if timezone != nil {
+		u.Timezone = *timezone
+	}
+	if displayName != nil {
+		u.DisplayName = trimmedName
+	}
+	if bio != nil {
+		u.Bio = trimmedBio
+	}
+	return nil
+}
+
 func validateUsername(username string) error {
 	if len(username) < 3 || len(username) > 20 {
 		return fmt.Errorf("username must be 3-20 characters long")
@@ -38,6 +117,10 @@ func validateUsername(username string) error {
 		return fmt.Errorf("username can only contain letters, numbers, hyphens, and underscores")
 	}
 
+	if strings.EqualFold(username, DeletedUsername) {
+		return fmt.Errorf("username %s is reserved", DeletedUsername)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/storage"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued by the
+// search service, was tampered with or belongs to a listing sorted
+// differently.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorKeyFileName is the file in the root of a data directory that holds
+// the key cursors are signed with, so they stay valid across restarts and
+// between the API server and the CLI.
+const CursorKeyFileName = "cursor.key"
+
+const cursorKeySize = 32
+
+// cursor is a position in a sorted listing: the sort key and ID of the
+// perfect day a page continues after, or before for a previous page.
+type cursor struct {
+	Sort   string `json:"s"`
+	Key    string `json:"k"`
+	ID     string `json:"i"`
+	Before bool   `json:"b,omitempty"`
+}
+
+// LoadCursorKey reads the cursor key of dataDir, creating it on first use.
+func LoadCursorKey(dataDir string) ([]byte, error) {
+	path := filepath.Join(dataDir, CursorKeyFileName)
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) < cursorKeySize {
+			return nil, fmt.Errorf("cursor key %s is too short, remove it to create a new one", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read cursor key: %v", err)
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %v", err)
+	}
+	key = make([]byte, cursorKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate cursor key: %v", err)
+	}
+	// Only complete keys appear, and the first process to create one wins,
+	// so processes starting together agree on it
+	err = storage.CreateFileAtomic(path, key, 0600)
+	if os.IsExist(err) {
+		return LoadCursorKey(dataDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cursor key: %v", err)
+	}
+	return key, nil
+}
+
+// encodeCursor returns c as an opaque string, signed so clients cannot
+// forge positions.
+func (ss *SearchService) encodeCursor(c cursor) string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(ss.sign(payload))
+}
+
+// decodeCursor checks the signature of an encoded cursor and that it
+// belongs to a listing in order o.
+func (ss *SearchService) decodeCursor(encoded string, o ordering) (*cursor, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(encoded, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, ss.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != o.String() {
+		return nil, fmt.Errorf("%w: it belongs to a listing sorted by %s", ErrInvalidCursor, c.Sort)
+	}
+	return &c, nil
+}
+
+func (ss *SearchService) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, ss.cursorKey)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -1,15 +1,36 @@
 package search
 
 import (
+	"crypto/rand"
 	"perfect-day/pkg/models"
 	"sort"
 	"strings"
 )
 
-type SearchService struct{}
+// MaxLimit is the largest page the API serves.
+const MaxLimit = 100
 
+// createdAtKeyLayout formats creation times as sort keys of a fixed width,
+// so they compare as strings in time order.
+const createdAtKeyLayout = "2006-01-02T15:04:05.000000000Z"
+
+type SearchService struct {
+	// cursorKey signs the cursors of the pages the service returns.
+	cursorKey []byte
+}
+
+// NewSearchService returns a search service whose cursors are signed with a
+// random key, so they are only valid for the life of the service.
 func NewSearchService() *SearchService {
-	return &SearchService{}
+	key := make([]byte, cursorKeySize)
+	rand.Read(key)
+	return &SearchService{cursorKey: key}
+}
+
+// NewSearchServiceWithCursorKey returns a search service whose cursors are
+// signed with key, e.g. the one LoadCursorKey keeps in the data directory.
+func NewSearchServiceWithCursorKey(key []byte) *SearchService {
+	return &SearchService{cursorKey: key}
 }
 
 type SearchCriteria struct {
@@ -22,6 +43,9 @@ type SearchCriteria struct {
 	SortOrder  string
 	Limit      int
 	Offset     int
+	// Cursor continues a listing from the NextCursor or PrevCursor of one
+	// of its pages. It takes precedence over Offset.
+	Cursor     string
 }
 
 type SearchResult struct {
@@ -29,34 +53,90 @@ type SearchResult struct {
 	Total       int                  `json:"total"`
 	Offset      int                  `json:"offset"`
 	Limit       int                  `json:"limit"`
+	// NextCursor and PrevCursor continue the listing after and before this
+	// page. They are empty at its ends.
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// Next and Prev link to the adjacent pages in API responses.
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
 }
 
+// Search filters, sorts and pages perfectDays by criteria.Offset. Page also
+// continues from a cursor.
 func (ss *SearchService) Search(perfectDays []*models.PerfectDay, criteria SearchCriteria) *SearchResult {
+	criteria.Cursor = ""
+	result, _ := ss.Page(perfectDays, criteria)
+	return result
+}
+
+// Page filters, sorts and pages perfectDays, continuing from criteria.Cursor
+// if it is set. Perfect days with the same sort key are ordered by ID, so
+// the order is the same on every call and a cursor keeps its place when
+// perfect days are added or removed before it. A Limit of 0 or less returns
+// every perfect day from the start of the page on.
+func (ss *SearchService) Page(perfectDays []*models.PerfectDay, criteria SearchCriteria) (*SearchResult, error) {
+	order := newOrdering(criteria.SortBy, criteria.SortOrder)
 	filtered := ss.filterPerfectDays(perfectDays, criteria)
-	sorted := ss.sortPerfectDays(filtered, criteria.SortBy, criteria.SortOrder)
+	sorted := ss.sortPerfectDays(filtered, order)
 
 	total := len(sorted)
-	start := criteria.Offset
-	end := start + criteria.Limit
+	limit := criteria.Limit
+	if limit <= 0 {
+		limit = total
+	}
 
+	start := criteria.Offset
+	if start < 0 {
+		start = 0
+	}
 	if start > total {
 		start = total
 	}
-	if end > total {
-		end = total
-	}
+	end := start + limit
 
-	if criteria.Limit <= 0 {
-		start = 0
+	if criteria.Cursor != "" {
+		position, err := ss.decodeCursor(criteria.Cursor, order)
+		if err != nil {
+			return nil, err
+		}
+		if position.Before {
+			// The page ends at the first perfect day not before the position
+			end = sort.Search(total, func(i int) bool {
+				return order.compare(sorted[i], position.Key, position.ID) >= 0
+			})
+			start = end - limit
+			if start < 0 {
+				start = 0
+			}
+		} else {
+			start = sort.Search(total, func(i int) bool {
+				return order.compare(sorted[i], position.Key, position.ID) > 0
+			})
+			end = start + limit
+		}
+	}
+	if end > total {
 		end = total
 	}
 
-	return &SearchResult{
+	result := &SearchResult{
 		PerfectDays: sorted[start:end],
 		Total:       total,
-		Offset:      criteria.Offset,
+		Offset:      start,
 		Limit:       criteria.Limit,
 	}
+	if start < end {
+		if end < total {
+			last := sorted[end-1]
+			result.NextCursor = ss.encodeCursor(cursor{Sort: order.String(), Key: order.key(last), ID: last.ID})
+		}
+		if start > 0 {
+			first := sorted[start]
+			result.PrevCursor = ss.encodeCursor(cursor{Sort: order.String(), Key: order.key(first), ID: first.ID, Before: true})
+		}
+	}
+	return result, nil
 }
 
 func (ss *SearchService) filterPerfectDays(perfectDays []*models.PerfectDay, criteria SearchCriteria) []*models.PerfectDay {
@@ -121,39 +201,64 @@ func (ss *SearchService) matchesQuery(pd *models.PerfectDay, query string) bool
 	return true
 }
 
-func (ss *SearchService) sortPerfectDays(perfectDays []*models.PerfectDay, sortBy, sortOrder string) []*models.PerfectDay {
+func (ss *SearchService) sortPerfectDays(perfectDays []*models.PerfectDay, order ordering) []*models.PerfectDay {
 	sorted := make([]*models.PerfectDay, len(perfectDays))
 	copy(sorted, perfectDays)
 
+	sort.Slice(sorted, func(i, j int) bool {
+		return order.compare(sorted[i], order.key(sorted[j]), sorted[j].ID) < 0
+	})
+
+	return sorted
+}
+
+// ordering is the order of a listing: its sort field and direction.
+type ordering struct {
+	by   string
+	desc bool
+}
+
+// newOrdering reads the sort criteria. Unknown fields sort by creation
+// time, newest first.
+func newOrdering(sortBy, sortOrder string) ordering {
 	switch sortBy {
+	case "date", "created_at", "title":
+		return ordering{by: sortBy, desc: sortOrder == "desc"}
+	default:
+		return ordering{by: "created_at", desc: true}
+	}
+}
+
+func (o ordering) String() string {
+	if o.desc {
+		return o.by + ":desc"
+	}
+	return o.by + ":asc"
+}
+
+// key returns the value pd is sorted by.
+func (o ordering) key(pd *models.PerfectDay) string {
+	switch o.by {
 	case "date":
-		sort.Slice(sorted, func(i, j int) bool {
-			if sortOrder == "desc" {
-				return sorted[i].Date > sorted[j].Date
-			}
-			return sorted[i].Date < sorted[j].Date
-		})
-	case "created_at":
-		sort.Slice(sorted, func(i, j int) bool {
-			if sortOrder == "desc" {
-				return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
-			}
-			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
-		})
+		return pd.Date
 	case "title":
-		sort.Slice(sorted, func(i, j int) bool {
-			if sortOrder == "desc" {
-				return sorted[i].Title > sorted[j].Title
-			}
-			return sorted[i].Title < sorted[j].Title
-		})
+		return pd.Title
 	default:
-		sort.Slice(sorted, func(i, j int) bool {
-			return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
-		})
+		return pd.CreatedAt.UTC().Format(createdAtKeyLayout)
 	}
+}
 
-	return sorted
+// compare orders pd against the position with the given sort key and ID,
+// breaking ties by ID in the direction of the listing.
+func (o ordering) compare(pd *models.PerfectDay, key, id string) int {
+	c := strings.Compare(o.key(pd), key)
+	if c == 0 {
+		c = strings.Compare(pd.ID, id)
+	}
+	if o.desc {
+		return -c
+	}
+	return c
 }
 
 func (ss *SearchService) GetUniqueAreas(perfectDays []*models.PerfectDay) []string {
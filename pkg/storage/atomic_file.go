@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic replaces path with data so that readers, and a crash at
+// any point, observe either the old or the new content but never a partial
+// file: the data is written to a temporary file in the same directory,
+// fsynced, and renamed over the target.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpPath, err := writeTempFile(path, data, perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace file: %v", err)
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// CreateFileAtomic writes a file that must not exist yet. Like
+// writeFileAtomic it writes and fsyncs a temporary file first, then
+// hard-links it to path, which fails if path exists: of several concurrent
+// creators exactly one succeeds, and readers never see a partial file. The
+// error of the others satisfies os.IsExist.
+func CreateFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpPath, err := writeTempFile(path, data, perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	if err := os.Link(tmpPath, path); err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// writeTempFile writes data to a new, fsynced temporary file next to path
+// and returns its name.
+func writeTempFile(path string, data []byte, perm os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %v", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write temporary file: %v", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to set file mode: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to sync temporary file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to close temporary file: %v", err)
+	}
+
+	return tmp.Name(), nil
+}
+
+// syncDir makes a rename inside dir durable. Some platforms cannot open or
+// fsync directories; that is not treated as an error.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer d.Close()
+
+	d.Sync()
+	return nil
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/models"
+)
+
+// AuditLogFileName is the append-only log of audit events in the data
+// directory, one JSON event per line.
+const AuditLogFileName = "audit.log"
+
+// AuditStorage appends audit events to the audit log file.
+type AuditStorage struct {
+	dataDir string
+}
+
+func NewAuditStorage(dataDir string) *AuditStorage {
+	return &AuditStorage{dataDir: dataDir}
+}
+
+func (as *AuditStorage) Append(event *models.AuditEvent) error {
+	if err := os.MkdirAll(as.dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %v", err)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %v", err)
+	}
+
+	lock, err := LockDataDir(as.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	file, err := os.OpenFile(as.filePath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %v", err)
+	}
+	return file.Sync()
+}
+
+// Query reads the whole log. Lines that cannot be parsed, such as one torn
+// by a crash, are skipped.
+func (as *AuditStorage) Query(filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	file, err := os.Open(as.filePath())
+	if os.IsNotExist(err) {
+		return []*models.AuditEvent{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	events := []*models.AuditEvent{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event models.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		if filter.Matches(&event) {
+			events = append(events, &event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+
+	return newestAuditEvents(events, filter.Limit), nil
+}
+
+func (as *AuditStorage) filePath() string {
+	return filepath.Join(as.dataDir, AuditLogFileName)
+}
+
+// newestAuditEvents reverses events, which are oldest first, and keeps at
+// most limit of them.
+func newestAuditEvents(events []*models.AuditEvent, limit int) []*models.AuditEvent {
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
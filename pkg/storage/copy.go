@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"fmt"
+	"perfect-day/pkg/models"
+)
+
+// CopyResult reports how many records Copy transferred.
+type CopyResult struct {
+	Users       int
+	PerfectDays int
+	Revisions   int
+	Sessions    int
+	Tokens      int
+	AuditEvents int
+}
+
+// Copy transfers everything in src to dst: users, perfect days, including
+// soft-deleted ones, with their revision history as it is, sessions, API
+// tokens and the audit log. Records already present in dst are replaced
+// and audit events already there are skipped, so a partially completed
+// copy can simply be run again.
+func Copy(dst, src *Storage) (*CopyResult, error) {
+	result := &CopyResult{}
+
+	users, err := src.UserStorage.List()
+	if err != nil {
+		return result, fmt.Errorf("failed to list users: %v", err)
+	}
+	for _, user := range users {
+		if err := dst.UserStorage.Save(user); err != nil {
+			return result, fmt.Errorf("failed to copy user %s: %v", user.Username, err)
+		}
+		result.Users++
+	}
+
+	perfectDays, err := src.PerfectDayStorage.LoadAll(true)
+	if err != nil {
+		return result, fmt.Errorf("failed to load perfect days: %v", err)
+	}
+	for _, perfectDay := range perfectDays {
+		revisions, err := src.PerfectDayStorage.ListRevisions(perfectDay.ID)
+		if err != nil {
+			return result, fmt.Errorf("failed to list revisions of perfect day %s: %v", perfectDay.ID, err)
+		}
+		if err := dst.PerfectDayStorage.Import(perfectDay, revisions); err != nil {
+			return result, fmt.Errorf("failed to copy perfect day %s: %v", perfectDay.ID, err)
+		}
+		result.PerfectDays++
+		result.Revisions += len(revisions)
+	}
+
+	// Sessions and tokens outlive no user, see DeleteUser
+	for _, user := range users {
+		sessions, err := src.SessionStorage.ListByUser(user.Username)
+		if err != nil {
+			return result, fmt.Errorf("failed to list sessions of %s: %v", user.Username, err)
+		}
+		for _, session := range sessions {
+			if err := dst.SessionStorage.Save(session); err != nil {
+				return result, fmt.Errorf("failed to copy session: %v", err)
+			}
+			result.Sessions++
+		}
+
+		tokens, err := src.TokenStorage.ListByUser(user.Username)
+		if err != nil {
+			return result, fmt.Errorf("failed to list API tokens of %s: %v", user.Username, err)
+		}
+		for _, token := range tokens {
+			if err := dst.TokenStorage.Save(token); err != nil {
+				return result, fmt.Errorf("failed to copy API token %s: %v", token.ID, err)
+			}
+			result.Tokens++
+		}
+	}
+
+	events, err := src.AuditStorage.Query(models.AuditFilter{})
+	if err != nil {
+		return result, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	copied, err := dst.AuditStorage.Query(models.AuditFilter{})
+	if err != nil {
+		return result, fmt.Errorf("failed to read destination audit log: %v", err)
+	}
+	seen := make(map[string]bool, len(copied))
+	for _, event := range copied {
+		seen[event.ID] = true
+	}
+	// Oldest first, so the destination log keeps the order
+	for i := len(events) - 1; i >= 0; i-- {
+		if seen[events[i].ID] {
+			continue
+		}
+		if err := dst.AuditStorage.Append(events[i]); err != nil {
+			return result, fmt.Errorf("failed to copy audit event %s: %v", events[i].ID, err)
+		}
+		result.AuditEvents++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"fmt"
+	"perfect-day/pkg/models"
+)
+
+// DeleteUserResult reports what DeleteUser removed.
+type DeleteUserResult struct {
+	// PerfectDays counts the deleted or, when anonymizing, reassigned
+	// perfect days, including those in the trash.
+	PerfectDays int
+	Sessions    int
+	Tokens      int
+}
+
+// DeleteUser removes a user together with their sessions and API tokens.
+// Their perfect days are deleted for good, or with anonymize handed over to
+// models.DeletedUsername, see PerfectDayRepository.Reassign. The user
+// record goes last, so a failed deletion can simply be run again.
+func DeleteUser(s *Storage, username string, anonymize bool) (*DeleteUserResult, error) {
+	result := &DeleteUserResult{}
+
+	sessions, err := s.SessionStorage.ListByUser(username)
+	if err != nil {
+		return result, fmt.Errorf("failed to list sessions: %v", err)
+	}
+	for _, session := range sessions {
+		if err := s.SessionStorage.Delete(session.ID); err != nil {
+			return result, fmt.Errorf("failed to delete session: %v", err)
+		}
+		result.Sessions++
+	}
+
+	tokens, err := s.TokenStorage.ListByUser(username)
+	if err != nil {
+		return result, fmt.Errorf("failed to list API tokens: %v", err)
+	}
+	for _, token := range tokens {
+		if err := s.TokenStorage.Delete(token.ID); err != nil {
+			return result, fmt.Errorf("failed to delete API token: %v", err)
+		}
+		result.Tokens++
+	}
+
+	perfectDays, err := s.PerfectDayStorage.LoadAllByUser(username, true)
+	if err != nil {
+		return result, fmt.Errorf("failed to load perfect days: %v", err)
+	}
+	for _, perfectDay := range perfectDays {
+		if anonymize {
+			if err := s.PerfectDayStorage.Reassign(perfectDay.ID, username, models.DeletedUsername); err != nil {
+				return result, fmt.Errorf("failed to anonymize perfect day %s: %v", perfectDay.ID, err)
+			}
+		} else if err := s.PerfectDayStorage.Delete(username, perfectDay.ID, 0); err != nil {
+			return result, fmt.Errorf("failed to delete perfect day %s: %v", perfectDay.ID, err)
+		}
+		result.PerfectDays++
+	}
+
+	if err := s.UserStorage.Delete(username); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
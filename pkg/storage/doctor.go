@@ -0,0 +1,421 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of problems reported by Doctor.
+const (
+	IssueUnparseable      = "unparseable"
+	IssueNewerSchema      = "newer_schema"
+	IssueUsernameMismatch = "username_mismatch"
+	IssueIDMismatch       = "id_mismatch"
+	IssueMissingUser      = "missing_user"
+	IssueDuplicateID      = "duplicate_id"
+	IssueStaleAreas       = "stale_areas"
+	IssueInvalidDate      = "invalid_date"
+	IssueInvalidTime      = "invalid_time"
+)
+
+// doctorAuthor is recorded as the author of revisions written by repairs.
+// It contains a space so it can never collide with a real username.
+const doctorAuthor = "perfect-day doctor"
+
+// DoctorIssue is one problem found in the data directory. Path is relative
+// to the data directory. Fix describes what was done about it, and is empty
+// when the problem was left alone.
+type DoctorIssue struct {
+	Kind    string
+	Path    string
+	Message string
+	Fix     string
+}
+
+// DoctorReport is the outcome of a Doctor run.
+type DoctorReport struct {
+	Users         int
+	PerfectDays   int
+	Issues        []DoctorIssue
+	QuarantineDir string
+}
+
+// Unfixed returns the number of issues that still need attention.
+func (r *DoctorReport) Unfixed() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Fix == "" {
+			count++
+		}
+	}
+	return count
+}
+
+// doctorRecord is a parsed perfect day file awaiting the cross-file checks.
+type doctorRecord struct {
+	path       string
+	dirUser    string
+	fileID     string
+	perfectDay *models.PerfectDay
+	changed    bool
+}
+
+// Doctor checks every user and perfect day file of the JSON store for the
+// problems the loaders silently skip over. With fix set, unparseable files
+// and losing duplicates are moved to quarantine/<time>/ and fields that can
+// be derived from the rest of the record are repaired as a new revision.
+// Problems that need a human, such as invalid dates, are only reported.
+func (pds *PerfectDayStorage) Doctor(fix bool) (*DoctorReport, error) {
+	if err := pds.ensureDataDir(); err != nil {
+		return nil, err
+	}
+
+	var lock *FileLock
+	var err error
+	if fix {
+		lock, err = LockDataDir(pds.dataDir)
+	} else {
+		lock, err = RLockDataDir(pds.dataDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	report := &DoctorReport{Issues: []DoctorIssue{}}
+	if fix {
+		report.QuarantineDir = filepath.Join(pds.dataDir, "quarantine", time.Now().Format("20060102-150405"))
+	}
+
+	users, err := pds.checkUsers(report, fix)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := pds.checkPerfectDayFiles(report, fix)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err = pds.checkDuplicateIDs(report, records, fix)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		pds.checkPerfectDay(report, record, users, fix)
+		if fix && record.changed {
+			if err := pds.writeRepairLocked(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if fix && len(report.Issues) > report.Unfixed() {
+		index, err := pds.scanIndex()
+		if err != nil {
+			return nil, err
+		}
+		if err := pds.writeIndex(index); err != nil {
+			return nil, err
+		}
+	}
+
+	if report.QuarantineDir != "" {
+		if _, err := os.Stat(report.QuarantineDir); err != nil {
+			report.QuarantineDir = ""
+		}
+	}
+
+	sortIssues(report.Issues)
+	return report, nil
+}
+
+// checkUsers returns the usernames that have a readable user file.
+func (pds *PerfectDayStorage) checkUsers(report *DoctorReport, fix bool) (map[string]bool, error) {
+	users := make(map[string]bool)
+
+	usersDir := filepath.Join(pds.dataDir, "users")
+	entries, err := os.ReadDir(usersDir)
+	if os.IsNotExist(err) {
+		return users, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users directory: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		report.Users++
+
+		path := filepath.Join(usersDir, entry.Name())
+		username := strings.TrimSuffix(entry.Name(), ".json")
+
+		var user *models.User
+		data, err := os.ReadFile(path)
+		if err == nil {
+			user, _, err = DecodeUser(data)
+		}
+		if errors.Is(err, ErrNewerSchema) {
+			report.Issues = append(report.Issues, DoctorIssue{Kind: IssueNewerSchema, Path: pds.relPath(path), Message: err.Error()})
+			users[username] = true
+			continue
+		}
+		if err != nil {
+			issue := DoctorIssue{Kind: IssueUnparseable, Path: pds.relPath(path), Message: fmt.Sprintf("cannot parse user file: %v", err)}
+			if fix {
+				if err := pds.quarantine(report, path); err != nil {
+					return nil, err
+				}
+				issue.Fix = "moved to quarantine"
+			}
+			report.Issues = append(report.Issues, issue)
+			continue
+		}
+
+		if user.Username != username {
+			issue := DoctorIssue{Kind: IssueUsernameMismatch, Path: pds.relPath(path), Message: fmt.Sprintf("username %q does not match the file name", user.Username)}
+			if fix {
+				user.Username = username
+				data, err := encodeUser(user)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal user: %v", err)
+				}
+				if err := writeFileAtomic(path, data, 0644); err != nil {
+					return nil, fmt.Errorf("failed to write user file: %v", err)
+				}
+				issue.Fix = fmt.Sprintf("set username to %q", username)
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+
+		users[username] = true
+	}
+
+	return users, nil
+}
+
+// checkPerfectDayFiles parses every perfect day file, quarantining the ones
+// that cannot be read when fixing.
+func (pds *PerfectDayStorage) checkPerfectDayFiles(report *DoctorReport, fix bool) ([]*doctorRecord, error) {
+	var records []*doctorRecord
+
+	perfectDaysDir := filepath.Join(pds.dataDir, "perfect-days")
+	userEntries, err := os.ReadDir(perfectDaysDir)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read perfect-days directory: %v", err)
+	}
+
+	for _, userEntry := range userEntries {
+		if !userEntry.IsDir() {
+			continue
+		}
+
+		userDir := filepath.Join(perfectDaysDir, userEntry.Name())
+		entries, err := os.ReadDir(userDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read user directory: %v", err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			report.PerfectDays++
+
+			path := filepath.Join(userDir, entry.Name())
+			var perfectDay *models.PerfectDay
+			data, err := os.ReadFile(path)
+			if err == nil {
+				perfectDay, _, err = DecodePerfectDay(data)
+			}
+			if errors.Is(err, ErrNewerSchema) {
+				// Leave it to the newer version rather than quarantining it.
+				report.Issues = append(report.Issues, DoctorIssue{Kind: IssueNewerSchema, Path: pds.relPath(path), Message: err.Error()})
+				continue
+			}
+			if err != nil {
+				issue := DoctorIssue{Kind: IssueUnparseable, Path: pds.relPath(path), Message: fmt.Sprintf("cannot parse perfect day file: %v", err)}
+				if fix {
+					if err := pds.quarantine(report, path); err != nil {
+						return nil, err
+					}
+					issue.Fix = "moved to quarantine"
+				}
+				report.Issues = append(report.Issues, issue)
+				continue
+			}
+
+			records = append(records, &doctorRecord{
+				path:       path,
+				dirUser:    userEntry.Name(),
+				fileID:     strings.TrimSuffix(entry.Name(), ".json"),
+				perfectDay: perfectDay,
+			})
+		}
+	}
+
+	return records, nil
+}
+
+// checkDuplicateIDs reports IDs stored under more than one user. The most
+// recently updated copy is kept; the others are quarantined when fixing.
+// It returns the records that remain.
+func (pds *PerfectDayStorage) checkDuplicateIDs(report *DoctorReport, records []*doctorRecord, fix bool) ([]*doctorRecord, error) {
+	byID := make(map[string][]*doctorRecord)
+	for _, record := range records {
+		byID[record.fileID] = append(byID[record.fileID], record)
+	}
+
+	var kept []*doctorRecord
+	for _, record := range records {
+		copies := byID[record.fileID]
+		if len(copies) == 1 {
+			kept = append(kept, record)
+			continue
+		}
+
+		newest := copies[0]
+		for _, candidate := range copies[1:] {
+			if candidate.perfectDay.UpdatedAt.After(newest.perfectDay.UpdatedAt) {
+				newest = candidate
+			}
+		}
+		if record == newest {
+			kept = append(kept, record)
+			continue
+		}
+
+		issue := DoctorIssue{
+			Kind:    IssueDuplicateID,
+			Path:    pds.relPath(record.path),
+			Message: fmt.Sprintf("ID is also used by %s, which was updated more recently", pds.relPath(newest.path)),
+		}
+		if fix {
+			if err := pds.quarantine(report, record.path); err != nil {
+				return nil, err
+			}
+			issue.Fix = "moved to quarantine"
+		} else {
+			kept = append(kept, record)
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	return kept, nil
+}
+
+// checkPerfectDay runs the checks that only need a single record, repairing
+// it in memory when fixing.
+func (pds *PerfectDayStorage) checkPerfectDay(report *DoctorReport, record *doctorRecord, users map[string]bool, fix bool) {
+	pd := record.perfectDay
+	path := pds.relPath(record.path)
+
+	add := func(kind, message, fixDescription string, repair func()) {
+		issue := DoctorIssue{Kind: kind, Path: path, Message: message}
+		if fix && repair != nil {
+			repair()
+			record.changed = true
+			issue.Fix = fixDescription
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	// The file location is what every lookup goes by, so it wins over the
+	// fields inside the record.
+	if pd.ID != record.fileID {
+		add(IssueIDMismatch, fmt.Sprintf("ID %q does not match the file name", pd.ID),
+			fmt.Sprintf("set ID to %q", record.fileID), func() { pd.ID = record.fileID })
+	}
+	if pd.Username != record.dirUser {
+		add(IssueUsernameMismatch, fmt.Sprintf("username %q does not match the directory", pd.Username),
+			fmt.Sprintf("set username to %q", record.dirUser), func() { pd.Username = record.dirUser })
+	}
+	// Anonymized perfect days of deleted accounts have no owner on purpose.
+	if !users[record.dirUser] && record.dirUser != models.DeletedUsername {
+		add(IssueMissingUser, fmt.Sprintf("user %q has no users/%s.json", record.dirUser, record.dirUser), "", nil)
+	}
+
+	areas := models.AreasFromActivities(pd.Activities)
+	if !reflect.DeepEqual(pd.Areas, areas) && !(len(pd.Areas) == 0 && len(areas) == 0) {
+		add(IssueStaleAreas, fmt.Sprintf("areas %v do not match the activities %v", pd.Areas, areas),
+			"recomputed areas from the activities", func() { pd.Areas = areas })
+	}
+
+	if _, err := time.Parse("2006-01-02", pd.Date); err != nil {
+		add(IssueInvalidDate, fmt.Sprintf("date %q is not YYYY-MM-DD", pd.Date), "", nil)
+	}
+	for _, activity := range pd.Activities {
+		if _, err := time.Parse("15:04", activity.StartTime); err != nil {
+			add(IssueInvalidTime, fmt.Sprintf("activity %q starts at %q, which is not HH:MM", activity.Name, activity.StartTime), "", nil)
+		}
+		if activity.Duration <= 0 {
+			add(IssueInvalidTime, fmt.Sprintf("activity %q has a duration of %d minutes", activity.Name, activity.Duration), "", nil)
+		}
+	}
+}
+
+// writeRepairLocked saves a repaired record as a new revision, like Save
+// does, but without the revision check. Callers must hold the data
+// directory lock.
+func (pds *PerfectDayStorage) writeRepairLocked(record *doctorRecord) error {
+	saved := *record.perfectDay
+	saved.Revision++
+	saved.UpdatedBy = doctorAuthor
+	saved.UpdatedAt = time.Now()
+
+	if err := pds.writeRevisionLocked(&saved); err != nil {
+		return err
+	}
+
+	data, err := encodePerfectDay(&saved)
+	if err != nil {
+		return fmt.Errorf("failed to marshal perfect day: %v", err)
+	}
+
+	if err := writeFileAtomic(record.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write perfect day file: %v", err)
+	}
+
+	return nil
+}
+
+// quarantine moves path below the report's quarantine directory, keeping
+// its location relative to the data directory.
+func (pds *PerfectDayStorage) quarantine(report *DoctorReport, path string) error {
+	target := filepath.Join(report.QuarantineDir, pds.relPath(path))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %v", err)
+	}
+	if err := os.Rename(path, target); err != nil {
+		return fmt.Errorf("failed to quarantine %s: %v", pds.relPath(path), err)
+	}
+	return nil
+}
+
+func (pds *PerfectDayStorage) relPath(path string) string {
+	rel, err := filepath.Rel(pds.dataDir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// sortIssues orders issues by path so reports are stable.
+func sortIssues(issues []DoctorIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Path < issues[j].Path
+	})
+}
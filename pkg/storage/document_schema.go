@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"perfect-day/pkg/models"
+)
+
+// Every user, perfect day and revision document written by the files
+// backend (and every revision snapshot kept by the SQLite backend) carries a
+// schema_version. Documents from before versioning have none and count as
+// version 0. Loading runs the migration steps above a document's version in
+// order, so older documents are upgraded in memory; `perfect-day storage
+// upgrade` writes the upgraded documents back.
+//
+// To change the layout of a document, append a step to its migration list
+// and bump the matching version constant, which must always equal the
+// length of the list.
+
+// Current schema versions of the stored documents.
+const (
+	UserSchemaVersion       = 1
+	PerfectDaySchemaVersion = 1
+)
+
+// documentMigration upgrades a decoded JSON document by one version.
+type documentMigration struct {
+	description string
+	migrate     func(doc map[string]interface{})
+}
+
+// userMigrations[i] upgrades a user document from version i to i+1.
+var userMigrations = []documentMigration{
+	{"default a missing timezone to UTC", migrateUserV0},
+}
+
+// perfectDayMigrations[i] upgrades a perfect day document from version i
+// to i+1. Revision documents are upgraded with the same steps, applied to
+// their snapshot.
+var perfectDayMigrations = []documentMigration{
+	{"record when deleted perfect days were deleted and replace null lists", migratePerfectDayV0},
+}
+
+func migrateUserV0(doc map[string]interface{}) {
+	if timezone, _ := doc["timezone"].(string); timezone == "" {
+		doc["timezone"] = "UTC"
+	}
+}
+
+func migratePerfectDayV0(doc map[string]interface{}) {
+	// Trash retention counts from deleted_at, which did not exist yet; the
+	// last update is the best estimate of when the deletion happened.
+	if deleted, _ := doc["is_deleted"].(bool); deleted && doc["deleted_at"] == nil {
+		doc["deleted_at"] = doc["updated_at"]
+	}
+	for _, key := range []string{"areas", "activities"} {
+		if doc[key] == nil {
+			doc[key] = []interface{}{}
+		}
+	}
+}
+
+// Versioned wrappers put schema_version in front of the record's fields.
+type userDocument struct {
+	SchemaVersion int `json:"schema_version"`
+	*models.User
+}
+
+type perfectDayDocument struct {
+	SchemaVersion int `json:"schema_version"`
+	*models.PerfectDay
+}
+
+type revisionDocument struct {
+	SchemaVersion int `json:"schema_version"`
+	*models.PerfectDayRevision
+}
+
+func encodeUser(user *models.User) ([]byte, error) {
+	return json.MarshalIndent(userDocument{UserSchemaVersion, user}, "", "  ")
+}
+
+func encodePerfectDay(perfectDay *models.PerfectDay) ([]byte, error) {
+	return json.MarshalIndent(perfectDayDocument{PerfectDaySchemaVersion, perfectDay}, "", "  ")
+}
+
+func encodeRevision(revision *models.PerfectDayRevision) ([]byte, error) {
+	return json.MarshalIndent(revisionDocument{PerfectDaySchemaVersion, revision}, "", "  ")
+}
+
+// DecodeUser parses a stored user document of any supported version. It
+// also returns the version the document was stored as.
+func DecodeUser(data []byte) (*models.User, int, error) {
+	data, version, err := upgradeDocument(data, UserSchemaVersion, func(doc map[string]interface{}, from int) {
+		runMigrations(doc, userMigrations, from)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var user models.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, 0, err
+	}
+	return &user, version, nil
+}
+
+// DecodePerfectDay parses a stored perfect day document of any supported
+// version. It also returns the version the document was stored as.
+func DecodePerfectDay(data []byte) (*models.PerfectDay, int, error) {
+	data, version, err := upgradeDocument(data, PerfectDaySchemaVersion, func(doc map[string]interface{}, from int) {
+		runMigrations(doc, perfectDayMigrations, from)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var perfectDay models.PerfectDay
+	if err := json.Unmarshal(data, &perfectDay); err != nil {
+		return nil, 0, err
+	}
+	return &perfectDay, version, nil
+}
+
+// DecodeRevision parses a stored revision document of any supported
+// version. It also returns the version the document was stored as.
+func DecodeRevision(data []byte) (*models.PerfectDayRevision, int, error) {
+	data, version, err := upgradeDocument(data, PerfectDaySchemaVersion, func(doc map[string]interface{}, from int) {
+		if snapshot, ok := doc["perfect_day"].(map[string]interface{}); ok {
+			runMigrations(snapshot, perfectDayMigrations, from)
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var revision models.PerfectDayRevision
+	if err := json.Unmarshal(data, &revision); err != nil {
+		return nil, 0, err
+	}
+	return &revision, version, nil
+}
+
+// upgradeDocument returns data upgraded to version current by migrate,
+// along with the version it was stored as. Documents that are already
+// current are returned unchanged.
+func upgradeDocument(data []byte, current int, migrate func(doc map[string]interface{}, from int)) ([]byte, int, error) {
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, 0, err
+	}
+
+	version := header.SchemaVersion
+	if version > current {
+		return nil, 0, fmt.Errorf("%w: schema version %d, this version supports up to %d", ErrNewerSchema, version, current)
+	}
+	if version == current {
+		return data, version, nil
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	migrate(doc, version)
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	return upgraded, version, nil
+}
+
+func runMigrations(doc map[string]interface{}, migrations []documentMigration, from int) {
+	for _, step := range migrations[from:] {
+		step.migrate(doc)
+	}
+	delete(doc, "schema_version")
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UpgradeResult counts the documents rewritten at the current schema
+// version by UpgradeDocuments.
+type UpgradeResult struct {
+	Users       int
+	PerfectDays int
+	Revisions   int
+	// Skipped lists the documents that could not be read and were left
+	// alone; `perfect-day doctor` explains what is wrong with them.
+	Skipped []string
+}
+
+// UpgradeDocuments rewrites every user, perfect day and revision document
+// stored at an older schema version. Content is not changed otherwise, so
+// revisions and ETags stay the same.
+func (pds *PerfectDayStorage) UpgradeDocuments() (*UpgradeResult, error) {
+	if err := pds.ensureDataDir(); err != nil {
+		return nil, err
+	}
+
+	lock, err := LockDataDir(pds.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	result := &UpgradeResult{Skipped: []string{}}
+
+	upgradeUser := func(data []byte) ([]byte, bool, error) {
+		user, version, err := DecodeUser(data)
+		if err != nil || version == UserSchemaVersion {
+			return nil, false, err
+		}
+		data, err = encodeUser(user)
+		return data, true, err
+	}
+	upgradePerfectDay := func(data []byte) ([]byte, bool, error) {
+		perfectDay, version, err := DecodePerfectDay(data)
+		if err != nil || version == PerfectDaySchemaVersion {
+			return nil, false, err
+		}
+		data, err = encodePerfectDay(perfectDay)
+		return data, true, err
+	}
+	upgradeRevision := func(data []byte) ([]byte, bool, error) {
+		revision, version, err := DecodeRevision(data)
+		if err != nil || version == PerfectDaySchemaVersion {
+			return nil, false, err
+		}
+		data, err = encodeRevision(revision)
+		return data, true, err
+	}
+
+	err = pds.upgradeFiles(filepath.Join(pds.dataDir, "users"), false, upgradeUser, &result.Users, result)
+	if err == nil {
+		err = pds.upgradeFiles(filepath.Join(pds.dataDir, "perfect-days"), true, upgradePerfectDay, &result.PerfectDays, result)
+	}
+	if err == nil {
+		err = pds.upgradeFiles(filepath.Join(pds.dataDir, "revisions"), true, upgradeRevision, &result.Revisions, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// upgradeFiles applies upgrade to the JSON documents in dir, or in its
+// subdirectories when nested is set, counting the rewritten ones.
+func (pds *PerfectDayStorage) upgradeFiles(dir string, nested bool, upgrade func(data []byte) ([]byte, bool, error), count *int, result *UpgradeResult) error {
+	dirs := []string{dir}
+	if nested {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", pds.relPath(dir), err)
+		}
+		dirs = dirs[:0]
+		for _, entry := range entries {
+			if entry.IsDir() {
+				dirs = append(dirs, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	for _, documentDir := range dirs {
+		entries, err := os.ReadDir(documentDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", pds.relPath(documentDir), err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+
+			path := filepath.Join(documentDir, entry.Name())
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", pds.relPath(path), err)
+			}
+
+			upgraded, changed, err := upgrade(data)
+			if err != nil {
+				result.Skipped = append(result.Skipped, pds.relPath(path))
+				continue
+			}
+			if !changed {
+				continue
+			}
+
+			if err := writeFileAtomic(path, upgraded, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %v", pds.relPath(path), err)
+			}
+			*count++
+		}
+	}
+
+	return nil
+}
+
+// UpgradeDocuments rewrites the revision snapshots stored at an older
+// schema version. Users and perfect days live in tables that are migrated
+// when the database is opened.
+func (pds *SQLitePerfectDayStorage) UpgradeDocuments() (*UpgradeResult, error) {
+	result := &UpgradeResult{Skipped: []string{}}
+
+	tx, err := pds.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT perfect_day_id, revision, snapshot FROM perfect_day_revisions")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %v", err)
+	}
+
+	type upgradedSnapshot struct {
+		id       string
+		revision int
+		snapshot string
+	}
+	var upgraded []upgradedSnapshot
+	for rows.Next() {
+		var id, snapshot string
+		var revision int
+		if err := rows.Scan(&id, &revision, &snapshot); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read revision: %v", err)
+		}
+
+		perfectDay, version, err := DecodePerfectDay([]byte(snapshot))
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s revision %d", id, revision))
+			continue
+		}
+		if version == PerfectDaySchemaVersion {
+			continue
+		}
+
+		data, err := json.Marshal(perfectDayDocument{PerfectDaySchemaVersion, perfectDay})
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to marshal revision: %v", err)
+		}
+		upgraded = append(upgraded, upgradedSnapshot{id, revision, string(data)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %v", err)
+	}
+
+	for _, snapshot := range upgraded {
+		_, err := tx.Exec("UPDATE perfect_day_revisions SET snapshot = ? WHERE perfect_day_id = ? AND revision = ?",
+			snapshot.snapshot, snapshot.id, snapshot.revision)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update revision: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit upgrade: %v", err)
+	}
+
+	result.Revisions = len(upgraded)
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"perfect-day/pkg/models"
+	"strings"
+)
+
+// ErrPerfectDayNotFound is returned (wrapped) when no perfect day matches a
+// lookup by ID or ID prefix.
+var ErrPerfectDayNotFound = errors.New("perfect day not found")
+
+// ErrUserExists is returned (wrapped) by Create when the username is taken.
+var ErrUserExists = errors.New("user already exists")
+
+// ErrRevisionConflict is returned (wrapped) by Save when the perfect day was
+// changed by someone else after the caller loaded it.
+var ErrRevisionConflict = errors.New("perfect day was modified concurrently")
+
+// ErrRevisionNotFound is returned (wrapped) by LoadRevision when the
+// requested revision was never recorded.
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// ErrSessionNotFound is returned (wrapped) when a session ID is unknown.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrTokenNotFound is returned (wrapped) when an API token ID is unknown.
+var ErrTokenNotFound = errors.New("API token not found")
+
+// ErrNewerSchema is returned (wrapped) when a stored document was written
+// by a newer version of perfect-day than the one reading it.
+var ErrNewerSchema = errors.New("document was written by a newer version of perfect-day")
+
+// AmbiguousIDError is returned by FindByIDPrefix when a short ID matches
+// more than one perfect day.
+type AmbiguousIDError struct {
+	Prefix  string
+	Matches []string
+}
+
+func (e *AmbiguousIDError) Error() string {
+	return fmt.Sprintf("ID prefix '%s' is ambiguous, it matches: %s", e.Prefix, strings.Join(e.Matches, ", "))
+}
+
+// checkRevision rejects a save of perfectDay unless it was based on the
+// stored revision. Records that are not stored yet accept any revision so
+// imports and copies keep their history.
+func checkRevision(perfectDay *models.PerfectDay, stored int, exists bool) error {
+	if exists && stored != perfectDay.Revision {
+		return fmt.Errorf("%w: %s is at revision %d, not %d", ErrRevisionConflict, perfectDay.ID, stored, perfectDay.Revision)
+	}
+	return nil
+}
+
+// checkDeleteRevision rejects deleting perfect day id unless it is stored at
+// the expected revision. Zero expects any revision.
+func checkDeleteRevision(id string, expected, stored int) error {
+	if expected != 0 && stored != expected {
+		return fmt.Errorf("%w: %s is at revision %d, not %d", ErrRevisionConflict, id, stored, expected)
+	}
+	return nil
+}
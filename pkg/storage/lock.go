@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LockFileName is the advisory lock file kept in the root of a data
+// directory. Every writer of the file backend holds it exclusively while it
+// modifies records, so the CLI and API server can share a data directory.
+const LockFileName = ".lock"
+
+// FileLock is an advisory lock on a file, held until Unlock is called.
+// Locks conflict across processes as well as between goroutines of the
+// same process, because each acquisition opens its own file descriptor.
+type FileLock struct {
+	file *os.File
+}
+
+// LockDataDir blocks until it holds the exclusive lock on dataDir.
+func LockDataDir(dataDir string) (*FileLock, error) {
+	return lockFile(filepath.Join(dataDir, LockFileName), true)
+}
+
+// RLockDataDir blocks until it holds a shared lock on dataDir, which keeps
+// writers out while allowing other readers in.
+func RLockDataDir(dataDir string) (*FileLock, error) {
+	return lockFile(filepath.Join(dataDir, LockFileName), false)
+}
+
+func lockFile(path string, exclusive bool) (*FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %v", err)
+	}
+
+	if err := flock(file, exclusive); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+	}
+
+	return &FileLock{file: file}, nil
+}
+
+func (l *FileLock) Unlock() error {
+	if err := funlock(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package storage
+
+import (
+	"os"
+	"sync"
+)
+
+// Without flock(2) the lock only serialises access inside this process, and
+// shared locks are treated as exclusive.
+var processLock sync.Mutex
+
+func flock(file *os.File, exclusive bool) error {
+	processLock.Lock()
+	return nil
+}
+
+func funlock(file *os.File) error {
+	processLock.Unlock()
+	return nil
+}
@@ -0,0 +1,526 @@
+package storage
+
+import (
+	"fmt"
+	"perfect-day/pkg/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryUserStorage keeps users in memory. It is intended for tests and
+// ephemeral servers; nothing survives a restart.
+type MemoryUserStorage struct {
+	mu    sync.RWMutex
+	users map[string]*models.User
+}
+
+func NewMemoryUserStorage() *MemoryUserStorage {
+	return &MemoryUserStorage{users: make(map[string]*models.User)}
+}
+
+func (ms *MemoryUserStorage) Save(user *models.User) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	copied := *user
+	ms.users[user.Username] = &copied
+	return nil
+}
+
+func (ms *MemoryUserStorage) Create(user *models.User) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, exists := ms.users[user.Username]; exists {
+		return fmt.Errorf("%w: %s", ErrUserExists, user.Username)
+	}
+	copied := *user
+	ms.users[user.Username] = &copied
+	return nil
+}
+
+func (ms *MemoryUserStorage) Load(username string) (*models.User, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	user, exists := ms.users[username]
+	if !exists {
+		return nil, fmt.Errorf("user not found: %s", username)
+	}
+
+	copied := *user
+	return &copied, nil
+}
+
+func (ms *MemoryUserStorage) List() ([]*models.User, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(ms.users))
+	for _, user := range ms.users {
+		copied := *user
+		users = append(users, &copied)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+	return users, nil
+}
+
+func (ms *MemoryUserStorage) Exists(username string) bool {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	_, exists := ms.users[username]
+	return exists
+}
+
+func (ms *MemoryUserStorage) Delete(username string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, exists := ms.users[username]; !exists {
+		return fmt.Errorf("user not found: %s", username)
+	}
+	delete(ms.users, username)
+	return nil
+}
+
+// MemoryPerfectDayStorage keeps perfect days in memory, keyed by username
+// and ID like the file layout.
+type MemoryPerfectDayStorage struct {
+	mu          sync.RWMutex
+	perfectDays map[string]map[string]*models.PerfectDay
+	revisions   map[string][]*models.PerfectDayRevision
+}
+
+func NewMemoryPerfectDayStorage() *MemoryPerfectDayStorage {
+	return &MemoryPerfectDayStorage{
+		perfectDays: make(map[string]map[string]*models.PerfectDay),
+		revisions:   make(map[string][]*models.PerfectDayRevision),
+	}
+}
+
+func (ms *MemoryPerfectDayStorage) Save(perfectDay *models.PerfectDay) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	userDays, exists := ms.perfectDays[perfectDay.Username]
+	if !exists {
+		userDays = make(map[string]*models.PerfectDay)
+		ms.perfectDays[perfectDay.Username] = userDays
+	}
+
+	stored, exists := userDays[perfectDay.ID]
+	var storedRevision int
+	if exists {
+		storedRevision = stored.Revision
+	}
+	if err := checkRevision(perfectDay, storedRevision, exists); err != nil {
+		return err
+	}
+
+	perfectDay.Revision++
+	userDays[perfectDay.ID] = clonePerfectDay(perfectDay)
+	ms.revisions[perfectDay.ID] = append(ms.revisions[perfectDay.ID], cloneRevision(models.NewPerfectDayRevision(perfectDay)))
+	return nil
+}
+
+func (ms *MemoryPerfectDayStorage) Load(username, id string) (*models.PerfectDay, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	perfectDay, exists := ms.perfectDays[username][id]
+	if !exists {
+		return nil, fmt.Errorf("perfect day not found: %s/%s", username, id)
+	}
+
+	return clonePerfectDay(perfectDay), nil
+}
+
+func (ms *MemoryPerfectDayStorage) LoadByID(id string) (*models.PerfectDay, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, userDays := range ms.perfectDays {
+		if perfectDay, exists := userDays[id]; exists {
+			return clonePerfectDay(perfectDay), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrPerfectDayNotFound, id)
+}
+
+func (ms *MemoryPerfectDayStorage) FindByIDPrefix(prefix string) (*models.PerfectDay, error) {
+	ms.mu.RLock()
+	ids := make(map[string]string)
+	for username, userDays := range ms.perfectDays {
+		for id := range userDays {
+			ids[id] = username
+		}
+	}
+	ms.mu.RUnlock()
+
+	id, err := resolveIDPrefix(prefix, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.LoadByID(id)
+}
+
+func (ms *MemoryPerfectDayStorage) LoadAllByUser(username string, includeDeleted bool) ([]*models.PerfectDay, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return ms.loadAllByUser(username, includeDeleted), nil
+}
+
+func (ms *MemoryPerfectDayStorage) LoadAll(includeDeleted bool) ([]*models.PerfectDay, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	usernames := make([]string, 0, len(ms.perfectDays))
+	for username := range ms.perfectDays {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	allPerfectDays := []*models.PerfectDay{}
+	for _, username := range usernames {
+		allPerfectDays = append(allPerfectDays, ms.loadAllByUser(username, includeDeleted)...)
+	}
+
+	return allPerfectDays, nil
+}
+
+func (ms *MemoryPerfectDayStorage) Delete(username, id string, revision int) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if stored, exists := ms.perfectDays[username][id]; exists {
+		if err := checkDeleteRevision(id, revision, stored.Revision); err != nil {
+			return err
+		}
+		delete(ms.perfectDays[username], id)
+		delete(ms.revisions, id)
+	}
+	return nil
+}
+
+func (ms *MemoryPerfectDayStorage) Reassign(id, from, to string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	stored, exists := ms.perfectDays[from][id]
+	if !exists {
+		return fmt.Errorf("perfect day not found: %s/%s", from, id)
+	}
+	perfectDay := clonePerfectDay(stored)
+	perfectDay.Username = to
+	perfectDay.UpdatedBy = to
+	perfectDay.Revision = 1
+
+	if ms.perfectDays[to] == nil {
+		ms.perfectDays[to] = make(map[string]*models.PerfectDay)
+	}
+	ms.perfectDays[to][id] = perfectDay
+	if from != to {
+		delete(ms.perfectDays[from], id)
+	}
+	ms.revisions[id] = []*models.PerfectDayRevision{cloneRevision(models.NewPerfectDayRevision(perfectDay))}
+	return nil
+}
+
+func (ms *MemoryPerfectDayStorage) Import(perfectDay *models.PerfectDay, revisions []*models.PerfectDayRevision) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, userDays := range ms.perfectDays {
+		delete(userDays, perfectDay.ID)
+	}
+	if ms.perfectDays[perfectDay.Username] == nil {
+		ms.perfectDays[perfectDay.Username] = make(map[string]*models.PerfectDay)
+	}
+	ms.perfectDays[perfectDay.Username][perfectDay.ID] = clonePerfectDay(perfectDay)
+
+	history := make([]*models.PerfectDayRevision, 0, len(revisions))
+	for _, revision := range revisions {
+		history = append(history, cloneRevision(revision))
+	}
+	ms.revisions[perfectDay.ID] = history
+	return nil
+}
+
+func (ms *MemoryPerfectDayStorage) ListRevisions(id string) ([]*models.PerfectDayRevision, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	revisions := []*models.PerfectDayRevision{}
+	for _, revision := range ms.revisions[id] {
+		revisions = append(revisions, cloneRevision(revision))
+	}
+	return revisions, nil
+}
+
+func (ms *MemoryPerfectDayStorage) LoadRevision(id string, revision int) (*models.PerfectDayRevision, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, stored := range ms.revisions[id] {
+		if stored.Revision == revision {
+			return cloneRevision(stored), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s revision %d", ErrRevisionNotFound, id, revision)
+}
+
+// loadAllByUser returns copies ordered by ID, mirroring the directory order
+// of the file backend. Callers must hold ms.mu.
+func (ms *MemoryPerfectDayStorage) loadAllByUser(username string, includeDeleted bool) []*models.PerfectDay {
+	perfectDays := []*models.PerfectDay{}
+	for _, perfectDay := range ms.perfectDays[username] {
+		if !includeDeleted && perfectDay.IsDeleted {
+			continue
+		}
+		perfectDays = append(perfectDays, clonePerfectDay(perfectDay))
+	}
+
+	sort.Slice(perfectDays, func(i, j int) bool {
+		return perfectDays[i].ID < perfectDays[j].ID
+	})
+	return perfectDays
+}
+
+// clonePerfectDay returns a deep copy so callers can never mutate stored
+// state without going through Save.
+func clonePerfectDay(perfectDay *models.PerfectDay) *models.PerfectDay {
+	copied := *perfectDay
+
+	copied.Areas = append([]string{}, perfectDay.Areas...)
+	if perfectDay.DeletedAt != nil {
+		deletedAt := *perfectDay.DeletedAt
+		copied.DeletedAt = &deletedAt
+	}
+	copied.Activities = make([]models.Activity, len(perfectDay.Activities))
+	for i, activity := range perfectDay.Activities {
+		copied.Activities[i] = activity
+		if activity.Location.Coordinates != nil {
+			coords := *activity.Location.Coordinates
+			copied.Activities[i].Location.Coordinates = &coords
+		}
+	}
+
+	return &copied
+}
+
+func cloneRevision(revision *models.PerfectDayRevision) *models.PerfectDayRevision {
+	copied := *revision
+	copied.PerfectDay = *clonePerfectDay(&revision.PerfectDay)
+	return &copied
+}
+
+// MemorySessionStorage keeps sessions in memory; every session is lost on
+// restart.
+type MemorySessionStorage struct {
+	mu       sync.RWMutex
+	sessions map[string]*models.Session
+}
+
+func NewMemorySessionStorage() *MemorySessionStorage {
+	return &MemorySessionStorage{sessions: make(map[string]*models.Session)}
+}
+
+func (ms *MemorySessionStorage) Save(session *models.Session) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	copied := *session
+	copied.Token = ""
+	ms.sessions[session.ID] = &copied
+	return nil
+}
+
+func (ms *MemorySessionStorage) Load(id string) (*models.Session, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	session, exists := ms.sessions[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+
+	copied := *session
+	return &copied, nil
+}
+
+func (ms *MemorySessionStorage) Touch(id string, lastSeenAt, expiresAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	session, exists := ms.sessions[id]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	session.LastSeenAt = lastSeenAt
+	session.ExpiresAt = expiresAt
+	return nil
+}
+
+func (ms *MemorySessionStorage) Delete(id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.sessions, id)
+	return nil
+}
+
+func (ms *MemorySessionStorage) ListByUser(username string) ([]*models.Session, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	sessions := []*models.Session{}
+	for _, session := range ms.sessions {
+		if session.Username == username {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+func (ms *MemorySessionStorage) DeleteExpired(now time.Time) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	deleted := 0
+	for id, session := range ms.sessions {
+		if session.IsExpired(now) {
+			delete(ms.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// MemoryTokenStorage keeps personal API tokens in memory; every token is
+// lost on restart.
+type MemoryTokenStorage struct {
+	mu     sync.RWMutex
+	tokens map[string]*models.APIToken
+}
+
+func NewMemoryTokenStorage() *MemoryTokenStorage {
+	return &MemoryTokenStorage{tokens: make(map[string]*models.APIToken)}
+}
+
+func (ms *MemoryTokenStorage) Save(token *models.APIToken) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.tokens[token.ID] = copyAPIToken(token)
+	return nil
+}
+
+func (ms *MemoryTokenStorage) Load(id string) (*models.APIToken, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	token, exists := ms.tokens[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+	return copyAPIToken(token), nil
+}
+
+func (ms *MemoryTokenStorage) Touch(id string, lastUsedAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	token, exists := ms.tokens[id]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+	token.LastUsedAt = &lastUsedAt
+	return nil
+}
+
+func (ms *MemoryTokenStorage) Delete(id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.tokens, id)
+	return nil
+}
+
+func (ms *MemoryTokenStorage) ListByUser(username string) ([]*models.APIToken, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	tokens := []*models.APIToken{}
+	for _, token := range ms.tokens {
+		if token.Username == username {
+			tokens = append(tokens, copyAPIToken(token))
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+	})
+	return tokens, nil
+}
+
+// copyAPIToken copies token without its secret, so callers cannot change
+// stored tokens through the pointers they hold.
+func copyAPIToken(token *models.APIToken) *models.APIToken {
+	copied := *token
+	copied.Token = ""
+	copied.Scopes = append([]string(nil), token.Scopes...)
+	if token.LastUsedAt != nil {
+		lastUsedAt := *token.LastUsedAt
+		copied.LastUsedAt = &lastUsedAt
+	}
+	if token.ExpiresAt != nil {
+		expiresAt := *token.ExpiresAt
+		copied.ExpiresAt = &expiresAt
+	}
+	return &copied
+}
+
+// MemoryAuditStorage keeps the audit log in memory; it is lost on restart.
+type MemoryAuditStorage struct {
+	mu     sync.RWMutex
+	events []models.AuditEvent
+}
+
+func NewMemoryAuditStorage() *MemoryAuditStorage {
+	return &MemoryAuditStorage{}
+}
+
+func (ms *MemoryAuditStorage) Append(event *models.AuditEvent) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.events = append(ms.events, *event)
+	return nil
+}
+
+func (ms *MemoryAuditStorage) Query(filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	events := []*models.AuditEvent{}
+	for i := range ms.events {
+		if filter.Matches(&ms.events[i]) {
+			event := ms.events[i]
+			events = append(events, &event)
+		}
+	}
+	return newestAuditEvents(events, filter.Limit), nil
+}
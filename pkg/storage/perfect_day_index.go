@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"sort"
+	"strings"
+)
+
+// perfectDayIndex maps every perfect day ID to the username whose directory
+// holds it, so lookups by ID do not have to walk the whole tree. It is kept
+// in perfect-days/index.json and can always be rebuilt from the directory
+// layout.
+type perfectDayIndex struct {
+	IDs map[string]string `json:"ids"`
+}
+
+func (pds *PerfectDayStorage) indexPath() string {
+	return filepath.Join(pds.dataDir, "perfect-days", "index.json")
+}
+
+func (pds *PerfectDayStorage) LoadByID(id string) (*models.PerfectDay, error) {
+	index, err := pds.readIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	username, exists := index.IDs[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrPerfectDayNotFound, id)
+	}
+
+	perfectDay, err := pds.Load(username, id)
+	if err != nil {
+		if _, statErr := os.Stat(pds.filePath(username, id)); os.IsNotExist(statErr) {
+			// The file was removed behind our back; drop the stale entry.
+			pds.dropStaleIndexEntry(id, username)
+			return nil, fmt.Errorf("%w: %s", ErrPerfectDayNotFound, id)
+		}
+		return nil, err
+	}
+
+	return perfectDay, nil
+}
+
+func (pds *PerfectDayStorage) FindByIDPrefix(prefix string) (*models.PerfectDay, error) {
+	index, err := pds.readIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := resolveIDPrefix(prefix, index.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return pds.LoadByID(id)
+}
+
+// RebuildIndex discards the ID index and recreates it from the files on
+// disk. It returns the number of perfect days indexed.
+func (pds *PerfectDayStorage) RebuildIndex() (int, error) {
+	lock, err := LockDataDir(pds.dataDir)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+
+	index, err := pds.scanIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := pds.writeIndex(index); err != nil {
+		return 0, err
+	}
+
+	return len(index.IDs), nil
+}
+
+// readIndex loads the index for a lookup. It only takes the data directory
+// lock when the index has to be built, since the index file itself is
+// always replaced atomically.
+func (pds *PerfectDayStorage) readIndex() (*perfectDayIndex, error) {
+	index, err := pds.readIndexFile()
+	if err != nil || index != nil {
+		return index, err
+	}
+
+	lock, err := LockDataDir(pds.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	return pds.readIndexLocked()
+}
+
+// readIndexFile returns nil without an error when there is no usable index
+// on disk.
+func (pds *PerfectDayStorage) readIndexFile() (*perfectDayIndex, error) {
+	data, err := os.ReadFile(pds.indexPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read perfect day index: %v", err)
+	}
+
+	var index perfectDayIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		// A damaged index is only a cache; rebuild it from the files.
+		return nil, nil
+	}
+	if index.IDs == nil {
+		index.IDs = make(map[string]string)
+	}
+
+	return &index, nil
+}
+
+// readIndexLocked loads the index, building it from disk the first time it
+// is needed (e.g. for data written before the index existed). Callers must
+// hold the data directory lock.
+func (pds *PerfectDayStorage) readIndexLocked() (*perfectDayIndex, error) {
+	index, err := pds.readIndexFile()
+	if err != nil || index != nil {
+		return index, err
+	}
+
+	index, err = pds.scanIndex()
+	if err != nil {
+		return nil, err
+	}
+	if len(index.IDs) > 0 {
+		if err := pds.writeIndex(index); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
+
+// updateIndexLocked applies change to the index and persists it if change
+// reports a modification. Callers must hold the data directory lock.
+func (pds *PerfectDayStorage) updateIndexLocked(change func(ids map[string]string) bool) error {
+	index, err := pds.readIndexLocked()
+	if err != nil {
+		return err
+	}
+
+	if !change(index.IDs) {
+		return nil
+	}
+
+	return pds.writeIndex(index)
+}
+
+func (pds *PerfectDayStorage) dropStaleIndexEntry(id, username string) {
+	lock, err := LockDataDir(pds.dataDir)
+	if err != nil {
+		return
+	}
+	defer lock.Unlock()
+
+	pds.updateIndexLocked(func(ids map[string]string) bool {
+		if ids[id] != username {
+			return false
+		}
+		if _, err := os.Stat(pds.filePath(username, id)); err == nil {
+			return false
+		}
+		delete(ids, id)
+		return true
+	})
+}
+
+func (pds *PerfectDayStorage) scanIndex() (*perfectDayIndex, error) {
+	index := &perfectDayIndex{IDs: make(map[string]string)}
+
+	perfectDaysDir := filepath.Join(pds.dataDir, "perfect-days")
+	userEntries, err := os.ReadDir(perfectDaysDir)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read perfect-days directory: %v", err)
+	}
+
+	for _, userEntry := range userEntries {
+		if !userEntry.IsDir() {
+			continue
+		}
+
+		entries, err := os.ReadDir(filepath.Join(perfectDaysDir, userEntry.Name()))
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			index.IDs[strings.TrimSuffix(entry.Name(), ".json")] = userEntry.Name()
+		}
+	}
+
+	return index, nil
+}
+
+func (pds *PerfectDayStorage) writeIndex(index *perfectDayIndex) error {
+	if err := os.MkdirAll(filepath.Dir(pds.indexPath()), 0755); err != nil {
+		return fmt.Errorf("failed to create perfect-days directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal perfect day index: %v", err)
+	}
+
+	if err := writeFileAtomic(pds.indexPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write perfect day index: %v", err)
+	}
+
+	return nil
+}
+
+// resolveIDPrefix picks the single ID identified by prefix out of ids, which
+// maps IDs to owners. It is shared by every backend so ambiguity is reported
+// the same way everywhere.
+func resolveIDPrefix(prefix string, ids map[string]string) (string, error) {
+	if prefix == "" {
+		return "", fmt.Errorf("%w: empty ID", ErrPerfectDayNotFound)
+	}
+
+	if _, exists := ids[prefix]; exists {
+		return prefix, nil
+	}
+
+	var matches []string
+	for id := range ids {
+		if strings.HasPrefix(id, prefix) {
+			matches = append(matches, id)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrPerfectDayNotFound, prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		sort.Strings(matches)
+		return "", &AmbiguousIDError{Prefix: prefix, Matches: matches}
+	}
+}
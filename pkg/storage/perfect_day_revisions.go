@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Revisions of the file backend live in revisions/<id>/<revision>.json,
+// one immutable snapshot per save, outside the perfect-days tree so they
+// never show up as perfect days themselves.
+
+func (pds *PerfectDayStorage) revisionsDir(id string) string {
+	return filepath.Join(pds.dataDir, "revisions", id)
+}
+
+func (pds *PerfectDayStorage) revisionPath(id string, revision int) string {
+	return filepath.Join(pds.revisionsDir(id), strconv.Itoa(revision)+".json")
+}
+
+func (pds *PerfectDayStorage) ListRevisions(id string) ([]*models.PerfectDayRevision, error) {
+	entries, err := os.ReadDir(pds.revisionsDir(id))
+	if os.IsNotExist(err) {
+		return []*models.PerfectDayRevision{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revisions directory: %v", err)
+	}
+
+	revisions := []*models.PerfectDayRevision{}
+	for _, entry := range entries {
+		number, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		revision, err := pds.LoadRevision(id, number)
+		if err != nil {
+			continue
+		}
+		revisions = append(revisions, revision)
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+func (pds *PerfectDayStorage) LoadRevision(id string, revision int) (*models.PerfectDayRevision, error) {
+	data, err := os.ReadFile(pds.revisionPath(id, revision))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s revision %d", ErrRevisionNotFound, id, revision)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision file: %v", err)
+	}
+
+	stored, _, err := DecodeRevision(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal revision: %v", err)
+	}
+
+	return stored, nil
+}
+
+// writeRevisionLocked records the snapshot of a save. Callers must hold the
+// data directory lock.
+func (pds *PerfectDayStorage) writeRevisionLocked(perfectDay *models.PerfectDay) error {
+	if err := os.MkdirAll(pds.revisionsDir(perfectDay.ID), 0755); err != nil {
+		return fmt.Errorf("failed to create revisions directory: %v", err)
+	}
+
+	data, err := encodeRevision(models.NewPerfectDayRevision(perfectDay))
+	if err != nil {
+		return fmt.Errorf("failed to marshal revision: %v", err)
+	}
+
+	if err := writeFileAtomic(pds.revisionPath(perfectDay.ID, perfectDay.Revision), data, 0644); err != nil {
+		return fmt.Errorf("failed to write revision file: %v", err)
+	}
+
+	return nil
+}
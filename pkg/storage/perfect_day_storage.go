@@ -22,26 +22,53 @@ func (pds *PerfectDayStorage) Save(perfectDay *models.PerfectDay) error {
 		return err
 	}
 
+	lock, err := LockDataDir(pds.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
 	userDir := filepath.Join(pds.dataDir, "perfect-days", perfectDay.Username)
 	if err := os.MkdirAll(userDir, 0755); err != nil {
 		return fmt.Errorf("failed to create user directory: %v", err)
 	}
 
-	filePath := filepath.Join(userDir, perfectDay.ID+".json")
-	data, err := json.MarshalIndent(perfectDay, "", "  ")
+	filePath := pds.filePath(perfectDay.Username, perfectDay.ID)
+	stored, exists := pds.storedRevision(filePath)
+	if err := checkRevision(perfectDay, stored, exists); err != nil {
+		return err
+	}
+
+	saved := *perfectDay
+	saved.Revision++
+
+	// The snapshot goes first: if the process dies before the perfect day
+	// itself is replaced, the next save simply rewrites the same revision.
+	if err := pds.writeRevisionLocked(&saved); err != nil {
+		return err
+	}
+
+	data, err := encodePerfectDay(&saved)
 	if err != nil {
 		return fmt.Errorf("failed to marshal perfect day: %v", err)
 	}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	if err := writeFileAtomic(filePath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write perfect day file: %v", err)
 	}
+	perfectDay.Revision = saved.Revision
 
-	return nil
+	return pds.updateIndexLocked(func(ids map[string]string) bool {
+		if ids[perfectDay.ID] == perfectDay.Username {
+			return false
+		}
+		ids[perfectDay.ID] = perfectDay.Username
+		return true
+	})
 }
 
 func (pds *PerfectDayStorage) Load(username, id string) (*models.PerfectDay, error) {
-	filePath := filepath.Join(pds.dataDir, "perfect-days", username, id+".json")
+	filePath := pds.filePath(username, id)
 
 	data, err := os.ReadFile(filePath)
 	if os.IsNotExist(err) {
@@ -51,12 +78,12 @@ func (pds *PerfectDayStorage) Load(username, id string) (*models.PerfectDay, err
 		return nil, fmt.Errorf("failed to read perfect day file: %v", err)
 	}
 
-	var perfectDay models.PerfectDay
-	if err := json.Unmarshal(data, &perfectDay); err != nil {
+	perfectDay, _, err := DecodePerfectDay(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal perfect day: %v", err)
 	}
 
-	return &perfectDay, nil
+	return perfectDay, nil
 }
 
 func (pds *PerfectDayStorage) LoadAllByUser(username string, includeDeleted bool) ([]*models.PerfectDay, error) {
@@ -121,14 +148,175 @@ func (pds *PerfectDayStorage) LoadAll(includeDeleted bool) ([]*models.PerfectDay
 	return allPerfectDays, nil
 }
 
-func (pds *PerfectDayStorage) Delete(username, id string) error {
-	filePath := filepath.Join(pds.dataDir, "perfect-days", username, id+".json")
+func (pds *PerfectDayStorage) Delete(username, id string, revision int) error {
+	if err := pds.ensureDataDir(); err != nil {
+		return err
+	}
+
+	lock, err := LockDataDir(pds.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	filePath := pds.filePath(username, id)
+	if stored, exists := pds.storedRevision(filePath); exists {
+		if err := checkDeleteRevision(id, revision, stored); err != nil {
+			return err
+		}
+	}
 
-	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+	err = os.Remove(filePath)
+	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete perfect day file: %v", err)
 	}
 
-	return nil
+	// Only drop the history when this user really owned the perfect day.
+	if err == nil {
+		if err := os.RemoveAll(pds.revisionsDir(id)); err != nil {
+			return fmt.Errorf("failed to delete perfect day revisions: %v", err)
+		}
+	}
+
+	return pds.updateIndexLocked(func(ids map[string]string) bool {
+		if ids[id] != username {
+			return false
+		}
+		delete(ids, id)
+		return true
+	})
+}
+
+func (pds *PerfectDayStorage) Reassign(id, from, to string) error {
+	if err := pds.ensureDataDir(); err != nil {
+		return err
+	}
+
+	lock, err := LockDataDir(pds.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	perfectDay, err := pds.Load(from, id)
+	if err != nil {
+		return err
+	}
+	perfectDay.Username = to
+	perfectDay.UpdatedBy = to
+	perfectDay.Revision = 1
+
+	if err := os.RemoveAll(pds.revisionsDir(id)); err != nil {
+		return fmt.Errorf("failed to delete perfect day revisions: %v", err)
+	}
+	if err := pds.writeRevisionLocked(perfectDay); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(pds.dataDir, "perfect-days", to), 0755); err != nil {
+		return fmt.Errorf("failed to create user directory: %v", err)
+	}
+	data, err := encodePerfectDay(perfectDay)
+	if err != nil {
+		return fmt.Errorf("failed to marshal perfect day: %v", err)
+	}
+	if err := writeFileAtomic(pds.filePath(to, id), data, 0644); err != nil {
+		return fmt.Errorf("failed to write perfect day file: %v", err)
+	}
+
+	if from != to {
+		if err := os.Remove(pds.filePath(from, id)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete perfect day file: %v", err)
+		}
+	}
+
+	return pds.updateIndexLocked(func(ids map[string]string) bool {
+		if ids[id] == to {
+			return false
+		}
+		ids[id] = to
+		return true
+	})
+}
+
+func (pds *PerfectDayStorage) Import(perfectDay *models.PerfectDay, revisions []*models.PerfectDayRevision) error {
+	if err := pds.ensureDataDir(); err != nil {
+		return err
+	}
+
+	lock, err := LockDataDir(pds.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := os.RemoveAll(pds.revisionsDir(perfectDay.ID)); err != nil {
+		return fmt.Errorf("failed to delete perfect day revisions: %v", err)
+	}
+	if err := os.MkdirAll(pds.revisionsDir(perfectDay.ID), 0755); err != nil {
+		return fmt.Errorf("failed to create revisions directory: %v", err)
+	}
+	for _, revision := range revisions {
+		data, err := encodeRevision(revision)
+		if err != nil {
+			return fmt.Errorf("failed to marshal revision: %v", err)
+		}
+		if err := writeFileAtomic(pds.revisionPath(perfectDay.ID, revision.Revision), data, 0644); err != nil {
+			return fmt.Errorf("failed to write revision file: %v", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(pds.dataDir, "perfect-days", perfectDay.Username), 0755); err != nil {
+		return fmt.Errorf("failed to create user directory: %v", err)
+	}
+	data, err := encodePerfectDay(perfectDay)
+	if err != nil {
+		return fmt.Errorf("failed to marshal perfect day: %v", err)
+	}
+	if err := writeFileAtomic(pds.filePath(perfectDay.Username, perfectDay.ID), data, 0644); err != nil {
+		return fmt.Errorf("failed to write perfect day file: %v", err)
+	}
+
+	// A copy stored under another owner is replaced, not duplicated
+	index, err := pds.readIndexLocked()
+	if err != nil {
+		return err
+	}
+	if owner, exists := index.IDs[perfectDay.ID]; exists && owner != perfectDay.Username {
+		if err := os.Remove(pds.filePath(owner, perfectDay.ID)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete perfect day file: %v", err)
+		}
+	}
+
+	return pds.updateIndexLocked(func(ids map[string]string) bool {
+		if ids[perfectDay.ID] == perfectDay.Username {
+			return false
+		}
+		ids[perfectDay.ID] = perfectDay.Username
+		return true
+	})
+}
+
+// storedRevision reports the revision of the perfect day file at filePath.
+// An unreadable file counts as missing: nobody can have loaded it, so there
+// is no edit to protect.
+func (pds *PerfectDayStorage) storedRevision(filePath string) (int, bool) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, false
+	}
+
+	var stored struct {
+		Revision int `json:"revision"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return 0, false
+	}
+	return stored.Revision, true
+}
+
+func (pds *PerfectDayStorage) filePath(username, id string) string {
+	return filepath.Join(pds.dataDir, "perfect-days", username, id+".json")
 }
 
 func (pds *PerfectDayStorage) ensureDataDir() error {
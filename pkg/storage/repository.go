@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"perfect-day/pkg/models"
+	"time"
+)
+
+// UserRepository is implemented by every backend that can persist users.
+type UserRepository interface {
+	Save(user *models.User) error
+	// Create saves a new user, or returns ErrUserExists without changing
+	// anything when the username is taken, even by a concurrent Create.
+	Create(user *models.User) error
+	Load(username string) (*models.User, error)
+	List() ([]*models.User, error)
+	Exists(username string) bool
+	// Delete removes the user record only; DeleteUser also removes what
+	// belongs to the user.
+	Delete(username string) error
+}
+
+// PerfectDayRepository is implemented by every backend that can persist
+// perfect days.
+type PerfectDayRepository interface {
+	Save(perfectDay *models.PerfectDay) error
+	Load(username, id string) (*models.PerfectDay, error)
+	// LoadByID returns the perfect day with exactly this ID regardless of
+	// owner or deletion state.
+	LoadByID(id string) (*models.PerfectDay, error)
+	// FindByIDPrefix resolves a full or shortened ID. An exact match always
+	// wins; otherwise the prefix must identify a single perfect day or an
+	// *AmbiguousIDError is returned.
+	FindByIDPrefix(prefix string) (*models.PerfectDay, error)
+	LoadAllByUser(username string, includeDeleted bool) ([]*models.PerfectDay, error)
+	LoadAll(includeDeleted bool) ([]*models.PerfectDay, error)
+	// Delete removes a perfect day and its revision history for good. Like
+	// Save it returns ErrRevisionConflict unless the stored copy is at
+	// revision; zero deletes whatever revision is stored.
+	Delete(username, id string, revision int) error
+	// Reassign hands the perfect day id of user from over to user to, as
+	// its owner and last editor. Its revision history starts again at
+	// revision 1, since the earlier revisions record the former owner. The
+	// new copy is stored before the original is removed, so a failure
+	// never loses the perfect day.
+	Reassign(id, from, to string) error
+	// Import stores perfectDay as it is, at its own revision, with
+	// revisions as its history, replacing any stored copy and history of
+	// the same ID. It is meant for copying data between backends.
+	Import(perfectDay *models.PerfectDay, revisions []*models.PerfectDayRevision) error
+	// ListRevisions returns the stored revisions of a perfect day, oldest
+	// first. Every successful Save records one.
+	ListRevisions(id string) ([]*models.PerfectDayRevision, error)
+	LoadRevision(id string, revision int) (*models.PerfectDayRevision, error)
+}
+
+// SessionRepository is implemented by every backend that can persist login
+// sessions. Implementations must be safe for concurrent use.
+type SessionRepository interface {
+	// Save creates the session or replaces the stored one with the same ID.
+	Save(session *models.Session) error
+	Load(id string) (*models.Session, error)
+	// Touch records when the session was last used and extends it to
+	// expiresAt. Unlike Save it never creates a session, so a session
+	// logged out meanwhile stays logged out; it returns ErrSessionNotFound
+	// instead.
+	Touch(id string, lastSeenAt, expiresAt time.Time) error
+	Delete(id string) error
+	// ListByUser returns the stored sessions of a user, oldest first,
+	// including expired ones that have not been evicted yet.
+	ListByUser(username string) ([]*models.Session, error)
+	// DeleteExpired removes every session expired at now and returns how
+	// many were removed.
+	DeleteExpired(now time.Time) (int, error)
+}
+
+// TokenRepository is implemented by every backend that can persist personal
+// API tokens. Implementations must be safe for concurrent use.
+type TokenRepository interface {
+	// Save creates the token or replaces the stored one with the same ID.
+	Save(token *models.APIToken) error
+	Load(id string) (*models.APIToken, error)
+	// Touch records when the token was last used. Unlike Save it never
+	// creates a token, so a revoked token stays revoked; it returns
+	// ErrTokenNotFound instead.
+	Touch(id string, lastUsedAt time.Time) error
+	Delete(id string) error
+	// ListByUser returns the tokens of a user, oldest first.
+	ListByUser(username string) ([]*models.APIToken, error)
+}
+
+// AuditRepository is implemented by every backend that can keep the audit
+// log. Implementations must be safe for concurrent use.
+type AuditRepository interface {
+	// Append records event. Stored events are never changed or removed.
+	Append(event *models.AuditEvent) error
+	// Query returns the events matching filter, newest first.
+	Query(filter models.AuditFilter) ([]*models.AuditEvent, error)
+}
+
+var (
+	_ UserRepository       = (*UserStorage)(nil)
+	_ UserRepository       = (*MemoryUserStorage)(nil)
+	_ UserRepository       = (*SQLiteUserStorage)(nil)
+	_ PerfectDayRepository = (*PerfectDayStorage)(nil)
+	_ PerfectDayRepository = (*MemoryPerfectDayStorage)(nil)
+	_ PerfectDayRepository = (*SQLitePerfectDayStorage)(nil)
+	_ SessionRepository    = (*SessionStorage)(nil)
+	_ SessionRepository    = (*MemorySessionStorage)(nil)
+	_ SessionRepository    = (*SQLiteSessionStorage)(nil)
+	_ TokenRepository      = (*TokenStorage)(nil)
+	_ TokenRepository      = (*MemoryTokenStorage)(nil)
+	_ TokenRepository      = (*SQLiteTokenStorage)(nil)
+	_ AuditRepository      = (*AuditStorage)(nil)
+	_ AuditRepository      = (*MemoryAuditStorage)(nil)
+	_ AuditRepository      = (*SQLiteAuditStorage)(nil)
+)
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SessionStorage keeps one sessions/<id>.json file per login session.
+type SessionStorage struct {
+	dataDir string
+}
+
+func NewSessionStorage(dataDir string) *SessionStorage {
+	return &SessionStorage{dataDir: dataDir}
+}
+
+func (ss *SessionStorage) Save(session *models.Session) error {
+	if err := os.MkdirAll(ss.sessionsDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %v", err)
+	}
+
+	lock, err := LockDataDir(ss.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %v", err)
+	}
+
+	if err := writeFileAtomic(ss.filePath(session.ID), data, 0600); err != nil {
+		return fmt.Errorf("failed to write session file: %v", err)
+	}
+
+	return nil
+}
+
+func (ss *SessionStorage) Load(id string) (*models.Session, error) {
+	if !validHashID(id) {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+
+	data, err := os.ReadFile(ss.filePath(id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %v", err)
+	}
+
+	var session models.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %v", err)
+	}
+
+	return &session, nil
+}
+
+func (ss *SessionStorage) Touch(id string, lastSeenAt, expiresAt time.Time) error {
+	if !validHashID(id) {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+
+	lock, err := LockDataDir(ss.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	session, err := ss.Load(id)
+	if err != nil {
+		return err
+	}
+	session.LastSeenAt = lastSeenAt
+	session.ExpiresAt = expiresAt
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %v", err)
+	}
+
+	if err := writeFileAtomic(ss.filePath(id), data, 0600); err != nil {
+		return fmt.Errorf("failed to write session file: %v", err)
+	}
+
+	return nil
+}
+
+func (ss *SessionStorage) Delete(id string) error {
+	if !validHashID(id) {
+		return nil
+	}
+
+	lock, err := LockDataDir(ss.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := os.Remove(ss.filePath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete session file: %v", err)
+	}
+	return nil
+}
+
+func (ss *SessionStorage) ListByUser(username string) ([]*models.Session, error) {
+	sessions, err := ss.loadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	userSessions := []*models.Session{}
+	for _, session := range sessions {
+		if session.Username == username {
+			userSessions = append(userSessions, session)
+		}
+	}
+
+	sort.Slice(userSessions, func(i, j int) bool {
+		return userSessions[i].CreatedAt.Before(userSessions[j].CreatedAt)
+	})
+	return userSessions, nil
+}
+
+func (ss *SessionStorage) DeleteExpired(now time.Time) (int, error) {
+	if _, err := os.Stat(ss.sessionsDir()); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	lock, err := LockDataDir(ss.dataDir)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+
+	sessions, err := ss.loadAll()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, session := range sessions {
+		if !session.IsExpired(now) {
+			continue
+		}
+		if err := os.Remove(ss.filePath(session.ID)); err != nil && !os.IsNotExist(err) {
+			return deleted, fmt.Errorf("failed to delete session file: %v", err)
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// loadAll returns every readable session file.
+func (ss *SessionStorage) loadAll() ([]*models.Session, error) {
+	entries, err := os.ReadDir(ss.sessionsDir())
+	if os.IsNotExist(err) {
+		return []*models.Session{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions directory: %v", err)
+	}
+
+	var sessions []*models.Session
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		session, err := ss.Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (ss *SessionStorage) sessionsDir() string {
+	return filepath.Join(ss.dataDir, "sessions")
+}
+
+func (ss *SessionStorage) filePath(id string) string {
+	return filepath.Join(ss.sessionsDir(), id+".json")
+}
+
+// validHashID keeps session and API token IDs that come from clients from
+// naming files outside their directory. IDs are hex encoded hashes.
+func validHashID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
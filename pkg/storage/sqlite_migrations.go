@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqliteMigration is one forward-only step of the SQLite schema. Versions
+// must be unique and increasing; a migration is never edited once released,
+// changes go into a new entry instead.
+type sqliteMigration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+var sqliteMigrations = []sqliteMigration{
+	{
+		Version: 1,
+		Name:    "initial schema",
+		SQL: `
+CREATE TABLE users (
+	username   TEXT PRIMARY KEY,
+	timezone   TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+CREATE TABLE perfect_days (
+	id          TEXT PRIMARY KEY,
+	username    TEXT NOT NULL,
+	title       TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	date        TEXT NOT NULL,
+	is_deleted  INTEGER NOT NULL DEFAULT 0,
+	created_at  TEXT NOT NULL,
+	updated_at  TEXT NOT NULL
+);
+
+CREATE INDEX idx_perfect_days_username ON perfect_days (username);
+
+CREATE TABLE locations (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	type      TEXT NOT NULL,
+	place_id  TEXT NOT NULL DEFAULT '',
+	name      TEXT NOT NULL,
+	address   TEXT NOT NULL DEFAULT '',
+	area      TEXT NOT NULL DEFAULT '',
+	latitude  REAL,
+	longitude REAL
+);
+
+CREATE TABLE activities (
+	perfect_day_id   TEXT NOT NULL REFERENCES perfect_days (id) ON DELETE CASCADE,
+	position         INTEGER NOT NULL,
+	id               TEXT NOT NULL,
+	name             TEXT NOT NULL,
+	location_id      INTEGER NOT NULL REFERENCES locations (id),
+	start_time       TEXT NOT NULL,
+	duration_minutes INTEGER NOT NULL,
+	description      TEXT NOT NULL DEFAULT '',
+	commentary       TEXT NOT NULL DEFAULT '',
+	created_at       TEXT NOT NULL,
+	PRIMARY KEY (perfect_day_id, position)
+);
+`,
+	},
+	{
+		Version: 2,
+		Name:    "perfect day revisions",
+		SQL:     `ALTER TABLE perfect_days ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;`,
+	},
+	{
+		Version: 3,
+		Name:    "perfect day revision history",
+		SQL: `
+ALTER TABLE perfect_days ADD COLUMN updated_by TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE perfect_day_revisions (
+	perfect_day_id TEXT NOT NULL,
+	revision       INTEGER NOT NULL,
+	author         TEXT NOT NULL,
+	created_at     TEXT NOT NULL,
+	snapshot       TEXT NOT NULL,
+	PRIMARY KEY (perfect_day_id, revision)
+);
+`,
+	},
+	{
+		Version: 4,
+		Name:    "perfect day deletion time",
+		SQL:     `ALTER TABLE perfect_days ADD COLUMN deleted_at TEXT;`,
+	},
+	{
+		Version: 5,
+		Name:    "user passwords",
+		SQL:     `ALTER TABLE users ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';`,
+	},
+	{
+		Version: 6,
+		Name:    "sessions",
+		SQL: `
+CREATE TABLE sessions (
+	id           TEXT PRIMARY KEY,
+	username     TEXT NOT NULL,
+	created_at   TEXT NOT NULL,
+	last_seen_at TEXT NOT NULL,
+	expires_at   TEXT NOT NULL
+);
+
+CREATE INDEX sessions_username ON sessions (username);
+`,
+	},
+	{
+		Version: 7,
+		Name:    "personal API tokens",
+		SQL: `
+CREATE TABLE api_tokens (
+	id           TEXT PRIMARY KEY,
+	username     TEXT NOT NULL,
+	name         TEXT NOT NULL,
+	scopes       TEXT NOT NULL,
+	created_at   TEXT NOT NULL,
+	last_used_at TEXT,
+	expires_at   TEXT
+);
+
+CREATE INDEX api_tokens_username ON api_tokens (username);
+`,
+	},
+	{
+		Version: 8,
+		Name:    "user profiles",
+		SQL: `
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+`,
+	},
+	{
+		Version: 9,
+		Name:    "user roles",
+		SQL:     `ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT '';`,
+	},
+	{
+		Version: 10,
+		Name:    "single sign-on identities",
+		SQL: `
+ALTER TABLE users ADD COLUMN oidc_issuer TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN oidc_subject TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX users_oidc_identity ON users (oidc_issuer, oidc_subject) WHERE oidc_subject != '';
+`,
+	},
+	{
+		Version: 11,
+		Name:    "audit log",
+		SQL: `
+CREATE TABLE audit_events (
+	seq        INTEGER PRIMARY KEY AUTOINCREMENT,
+	id         TEXT NOT NULL UNIQUE,
+	time       TEXT NOT NULL,
+	actor      TEXT NOT NULL,
+	action     TEXT NOT NULL,
+	target     TEXT NOT NULL DEFAULT '',
+	ip         TEXT NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	detail     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_events_actor ON audit_events (actor);
+CREATE INDEX audit_events_time ON audit_events (time);
+`,
+	},
+}
+
+// migrateSQLite applies every migration newer than the database's current
+// version, each in its own transaction.
+func migrateSQLite(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    INTEGER PRIMARY KEY,
+	name       TEXT NOT NULL,
+	applied_at TEXT NOT NULL
+)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+
+	current, err := sqliteSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range sqliteMigrations {
+		if migration.Version <= current {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %v", migration.Version, err)
+		}
+
+		if _, err := tx.Exec(migration.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d (%s): %v", migration.Version, migration.Name, err)
+		}
+
+		if _, err := tx.Exec(
+			"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+			migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339Nano),
+		); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %v", migration.Version, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %v", migration.Version, err)
+		}
+	}
+
+	return nil
+}
+
+func sqliteSchemaVersion(db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %v", err)
+	}
+	return int(version.Int64), nil
+}
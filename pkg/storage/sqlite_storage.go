@@ -0,0 +1,902 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteFileName is the database file created inside the data directory by
+// the sqlite backend.
+const SQLiteFileName = "perfect-day.db"
+
+// OpenSQLite opens (creating if needed) the database at path and brings its
+// schema up to date.
+func OpenSQLite(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %v", err)
+	}
+
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// SQLite serialises writers anyway; a single connection avoids
+	// SQLITE_BUSY between our own goroutines.
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+type SQLiteUserStorage struct {
+	db *sql.DB
+}
+
+func NewSQLiteUserStorage(db *sql.DB) *SQLiteUserStorage {
+	return &SQLiteUserStorage{db: db}
+}
+
+func (us *SQLiteUserStorage) Save(user *models.User) error {
+	_, err := us.db.Exec(`
+INSERT INTO users (username, timezone, display_name, bio, role, created_at, password_hash, oidc_issuer, oidc_subject)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (username) DO UPDATE SET timezone = excluded.timezone, display_name = excluded.display_name, bio = excluded.bio,
+	role = excluded.role, created_at = excluded.created_at, password_hash = excluded.password_hash,
+	oidc_issuer = excluded.oidc_issuer, oidc_subject = excluded.oidc_subject`,
+		user.Username, user.Timezone, user.DisplayName, user.Bio, user.Role, formatSQLiteTime(user.CreatedAt), user.PasswordHash,
+		user.OIDCIssuer, user.OIDCSubject,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save user: %v", err)
+	}
+	return nil
+}
+
+func (us *SQLiteUserStorage) Create(user *models.User) error {
+	result, err := us.db.Exec(`
+INSERT INTO users (username, timezone, display_name, bio, role, created_at, password_hash, oidc_issuer, oidc_subject)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (username) DO NOTHING`,
+		user.Username, user.Timezone, user.DisplayName, user.Bio, user.Role, formatSQLiteTime(user.CreatedAt), user.PasswordHash,
+		user.OIDCIssuer, user.OIDCSubject,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %v", err)
+	}
+	created, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to create user: %v", err)
+	}
+	if created == 0 {
+		return fmt.Errorf("%w: %s", ErrUserExists, user.Username)
+	}
+	return nil
+}
+
+func (us *SQLiteUserStorage) Load(username string) (*models.User, error) {
+	users, err := us.query("WHERE username = ?", username)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user not found: %s", username)
+	}
+	return users[0], nil
+}
+
+func (us *SQLiteUserStorage) List() ([]*models.User, error) {
+	return us.query("")
+}
+
+func (us *SQLiteUserStorage) Exists(username string) bool {
+	var count int
+	if err := us.db.QueryRow("SELECT COUNT(*) FROM users WHERE username = ?", username).Scan(&count); err != nil {
+		return false
+	}
+	return count > 0
+}
+
+func (us *SQLiteUserStorage) Delete(username string) error {
+	result, err := us.db.Exec("DELETE FROM users WHERE username = ?", username)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return fmt.Errorf("user not found: %s", username)
+	}
+	return nil
+}
+
+func (us *SQLiteUserStorage) query(where string, args ...interface{}) ([]*models.User, error) {
+	rows, err := us.db.Query("SELECT username, timezone, display_name, bio, role, created_at, password_hash, oidc_issuer, oidc_subject FROM users "+where+" ORDER BY username", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %v", err)
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		var user models.User
+		var createdAt string
+		if err := rows.Scan(&user.Username, &user.Timezone, &user.DisplayName, &user.Bio, &user.Role, &createdAt, &user.PasswordHash, &user.OIDCIssuer, &user.OIDCSubject); err != nil {
+			return nil, fmt.Errorf("failed to read user: %v", err)
+		}
+		user.CreatedAt = parseSQLiteTime(createdAt)
+		users = append(users, &user)
+	}
+
+	return users, rows.Err()
+}
+
+type SQLitePerfectDayStorage struct {
+	db *sql.DB
+}
+
+func NewSQLitePerfectDayStorage(db *sql.DB) *SQLitePerfectDayStorage {
+	return &SQLitePerfectDayStorage{db: db}
+}
+
+func (pds *SQLitePerfectDayStorage) Save(perfectDay *models.PerfectDay) error {
+	tx, err := pds.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var stored int
+	err = tx.QueryRow("SELECT revision FROM perfect_days WHERE id = ?", perfectDay.ID).Scan(&stored)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read perfect day revision: %v", err)
+	}
+	if err := checkRevision(perfectDay, stored, err == nil); err != nil {
+		return err
+	}
+	revision := perfectDay.Revision + 1
+
+	if err := writeSQLitePerfectDay(tx, perfectDay, revision); err != nil {
+		return err
+	}
+
+	saved := *perfectDay
+	saved.Revision = revision
+	if err := insertSQLiteRevision(tx, models.NewPerfectDayRevision(&saved)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit perfect day: %v", err)
+	}
+	perfectDay.Revision = revision
+	return nil
+}
+
+func (pds *SQLitePerfectDayStorage) Load(username, id string) (*models.PerfectDay, error) {
+	perfectDays, err := pds.query("WHERE username = ? AND id = ?", username, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(perfectDays) == 0 {
+		return nil, fmt.Errorf("perfect day not found: %s/%s", username, id)
+	}
+	return perfectDays[0], nil
+}
+
+func (pds *SQLitePerfectDayStorage) LoadByID(id string) (*models.PerfectDay, error) {
+	perfectDays, err := pds.query("WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(perfectDays) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPerfectDayNotFound, id)
+	}
+	return perfectDays[0], nil
+}
+
+func (pds *SQLitePerfectDayStorage) FindByIDPrefix(prefix string) (*models.PerfectDay, error) {
+	rows, err := pds.db.Query("SELECT id, username FROM perfect_days WHERE substr(id, 1, ?) = ?", len(prefix), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query perfect days: %v", err)
+	}
+
+	ids := make(map[string]string)
+	for rows.Next() {
+		var id, username string
+		if err := rows.Scan(&id, &username); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read perfect day: %v", err)
+		}
+		ids[id] = username
+	}
+	rows.Close()
+
+	id, err := resolveIDPrefix(prefix, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return pds.LoadByID(id)
+}
+
+func (pds *SQLitePerfectDayStorage) LoadAllByUser(username string, includeDeleted bool) ([]*models.PerfectDay, error) {
+	if includeDeleted {
+		return pds.query("WHERE username = ?", username)
+	}
+	return pds.query("WHERE username = ? AND is_deleted = 0", username)
+}
+
+func (pds *SQLitePerfectDayStorage) LoadAll(includeDeleted bool) ([]*models.PerfectDay, error) {
+	if includeDeleted {
+		return pds.query("")
+	}
+	return pds.query("WHERE is_deleted = 0")
+}
+
+func (pds *SQLitePerfectDayStorage) Delete(username, id string, revision int) error {
+	tx, err := pds.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var stored int
+	err = tx.QueryRow("SELECT revision FROM perfect_days WHERE username = ? AND id = ?", username, id).Scan(&stored)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up perfect day: %v", err)
+	}
+	if err := checkDeleteRevision(id, revision, stored); err != nil {
+		return err
+	}
+
+	if err := deleteSQLiteActivities(tx, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM perfect_days WHERE username = ? AND id = ?", username, id); err != nil {
+		return fmt.Errorf("failed to delete perfect day: %v", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM perfect_day_revisions WHERE perfect_day_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete perfect day revisions: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit delete: %v", err)
+	}
+	return nil
+}
+
+func (pds *SQLitePerfectDayStorage) Reassign(id, from, to string) error {
+	perfectDay, err := pds.Load(from, id)
+	if err != nil {
+		return err
+	}
+	stored := perfectDay.Revision
+	perfectDay.Username = to
+	perfectDay.UpdatedBy = to
+	perfectDay.Revision = 1
+
+	tx, err := pds.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE perfect_days SET username = ?, updated_by = ?, revision = 1 WHERE id = ? AND username = ? AND revision = ?",
+		to, to, id, from, stored)
+	if err != nil {
+		return fmt.Errorf("failed to reassign perfect day: %v", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to reassign perfect day: %v", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: %s", ErrRevisionConflict, id)
+	}
+
+	if _, err := tx.Exec("DELETE FROM perfect_day_revisions WHERE perfect_day_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete perfect day revisions: %v", err)
+	}
+	if err := insertSQLiteRevision(tx, models.NewPerfectDayRevision(perfectDay)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reassignment: %v", err)
+	}
+	return nil
+}
+
+func (pds *SQLitePerfectDayStorage) Import(perfectDay *models.PerfectDay, revisions []*models.PerfectDayRevision) error {
+	tx, err := pds.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM perfect_day_revisions WHERE perfect_day_id = ?", perfectDay.ID); err != nil {
+		return fmt.Errorf("failed to delete perfect day revisions: %v", err)
+	}
+	if err := writeSQLitePerfectDay(tx, perfectDay, perfectDay.Revision); err != nil {
+		return err
+	}
+	for _, revision := range revisions {
+		if err := insertSQLiteRevision(tx, revision); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit import: %v", err)
+	}
+	return nil
+}
+
+func (pds *SQLitePerfectDayStorage) ListRevisions(id string) ([]*models.PerfectDayRevision, error) {
+	return pds.queryRevisions("WHERE perfect_day_id = ?", id)
+}
+
+func (pds *SQLitePerfectDayStorage) LoadRevision(id string, revision int) (*models.PerfectDayRevision, error) {
+	revisions, err := pds.queryRevisions("WHERE perfect_day_id = ? AND revision = ?", id, revision)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("%w: %s revision %d", ErrRevisionNotFound, id, revision)
+	}
+	return revisions[0], nil
+}
+
+func (pds *SQLitePerfectDayStorage) queryRevisions(where string, args ...interface{}) ([]*models.PerfectDayRevision, error) {
+	rows, err := pds.db.Query("SELECT revision, author, created_at, snapshot FROM perfect_day_revisions "+where+" ORDER BY revision", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %v", err)
+	}
+	defer rows.Close()
+
+	revisions := []*models.PerfectDayRevision{}
+	for rows.Next() {
+		var revision models.PerfectDayRevision
+		var createdAt, snapshot string
+		if err := rows.Scan(&revision.Revision, &revision.Author, &createdAt, &snapshot); err != nil {
+			return nil, fmt.Errorf("failed to read revision: %v", err)
+		}
+		perfectDay, _, err := DecodePerfectDay([]byte(snapshot))
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal revision: %v", err)
+		}
+		revision.PerfectDay = *perfectDay
+		revision.CreatedAt = parseSQLiteTime(createdAt)
+		revisions = append(revisions, &revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %v", err)
+	}
+
+	return revisions, nil
+}
+
+// query loads the perfect days matching where, ordered like the file
+// backend (by username, then ID), together with their activities.
+func (pds *SQLitePerfectDayStorage) query(where string, args ...interface{}) ([]*models.PerfectDay, error) {
+	rows, err := pds.db.Query(`
+SELECT id, username, title, description, date, is_deleted, deleted_at, created_at, updated_at, updated_by, revision
+FROM perfect_days `+where+` ORDER BY username, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query perfect days: %v", err)
+	}
+
+	perfectDays := []*models.PerfectDay{}
+	byID := make(map[string]*models.PerfectDay)
+	for rows.Next() {
+		var pd models.PerfectDay
+		var createdAt, updatedAt string
+		var deletedAt sql.NullString
+		if err := rows.Scan(&pd.ID, &pd.Username, &pd.Title, &pd.Description, &pd.Date, &pd.IsDeleted, &deletedAt,
+			&createdAt, &updatedAt, &pd.UpdatedBy, &pd.Revision); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read perfect day: %v", err)
+		}
+		pd.CreatedAt = parseSQLiteTime(createdAt)
+		pd.UpdatedAt = parseSQLiteTime(updatedAt)
+		if deletedAt.Valid {
+			t := parseSQLiteTime(deletedAt.String)
+			pd.DeletedAt = &t
+		}
+		pd.Activities = []models.Activity{}
+		perfectDays = append(perfectDays, &pd)
+		byID[pd.ID] = &pd
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query perfect days: %v", err)
+	}
+
+	if len(perfectDays) == 0 {
+		return perfectDays, nil
+	}
+
+	activityRows, err := pds.db.Query(`
+SELECT a.perfect_day_id, a.id, a.name, a.start_time, a.duration_minutes, a.description, a.commentary, a.created_at,
+	l.type, l.place_id, l.name, l.address, l.area, l.latitude, l.longitude
+FROM activities a
+JOIN locations l ON l.id = a.location_id
+WHERE a.perfect_day_id IN (SELECT id FROM perfect_days `+where+`)
+ORDER BY a.perfect_day_id, a.position`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activities: %v", err)
+	}
+	defer activityRows.Close()
+
+	for activityRows.Next() {
+		var perfectDayID, createdAt, locationType string
+		var activity models.Activity
+		var latitude, longitude sql.NullFloat64
+		if err := activityRows.Scan(
+			&perfectDayID, &activity.ID, &activity.Name, &activity.StartTime, &activity.Duration,
+			&activity.Description, &activity.Commentary, &createdAt,
+			&locationType, &activity.Location.PlaceID, &activity.Location.Name, &activity.Location.Address,
+			&activity.Location.Area, &latitude, &longitude,
+		); err != nil {
+			return nil, fmt.Errorf("failed to read activity: %v", err)
+		}
+		activity.CreatedAt = parseSQLiteTime(createdAt)
+		activity.Location.Type = models.LocationType(locationType)
+		if latitude.Valid && longitude.Valid {
+			activity.Location.Coordinates = &models.Coordinates{Latitude: latitude.Float64, Longitude: longitude.Float64}
+		}
+
+		if pd, exists := byID[perfectDayID]; exists {
+			pd.Activities = append(pd.Activities, activity)
+		}
+	}
+	if err := activityRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query activities: %v", err)
+	}
+
+	for _, pd := range perfectDays {
+		pd.Areas = models.AreasFromActivities(pd.Activities)
+	}
+
+	return perfectDays, nil
+}
+
+// writeSQLitePerfectDay inserts or replaces perfectDay and its activities
+// at revision.
+func writeSQLitePerfectDay(tx *sql.Tx, perfectDay *models.PerfectDay, revision int) error {
+	if err := deleteSQLiteActivities(tx, perfectDay.ID); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`
+INSERT INTO perfect_days (id, username, title, description, date, is_deleted, deleted_at, created_at, updated_at, updated_by, revision)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+	username = excluded.username,
+	title = excluded.title,
+	description = excluded.description,
+	date = excluded.date,
+	is_deleted = excluded.is_deleted,
+	deleted_at = excluded.deleted_at,
+	created_at = excluded.created_at,
+	updated_at = excluded.updated_at,
+	updated_by = excluded.updated_by,
+	revision = excluded.revision`,
+		perfectDay.ID, perfectDay.Username, perfectDay.Title, perfectDay.Description, perfectDay.Date,
+		perfectDay.IsDeleted, formatSQLiteNullTime(perfectDay.DeletedAt),
+		formatSQLiteTime(perfectDay.CreatedAt), formatSQLiteTime(perfectDay.UpdatedAt), perfectDay.UpdatedBy, revision,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save perfect day: %v", err)
+	}
+
+	for position, activity := range perfectDay.Activities {
+		location := activity.Location
+		var latitude, longitude sql.NullFloat64
+		if location.Coordinates != nil {
+			latitude = sql.NullFloat64{Float64: location.Coordinates.Latitude, Valid: true}
+			longitude = sql.NullFloat64{Float64: location.Coordinates.Longitude, Valid: true}
+		}
+
+		result, err := tx.Exec(
+			"INSERT INTO locations (type, place_id, name, address, area, latitude, longitude) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			string(location.Type), location.PlaceID, location.Name, location.Address, location.Area, latitude, longitude,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save location: %v", err)
+		}
+		locationID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to save location: %v", err)
+		}
+
+		_, err = tx.Exec(`
+INSERT INTO activities (perfect_day_id, position, id, name, location_id, start_time, duration_minutes, description, commentary, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			perfectDay.ID, position, activity.ID, activity.Name, locationID, activity.StartTime,
+			activity.Duration, activity.Description, activity.Commentary, formatSQLiteTime(activity.CreatedAt),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save activity: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// insertSQLiteRevision records the snapshot of a save.
+func insertSQLiteRevision(tx *sql.Tx, snapshot *models.PerfectDayRevision) error {
+	data, err := json.Marshal(perfectDayDocument{PerfectDaySchemaVersion, &snapshot.PerfectDay})
+	if err != nil {
+		return fmt.Errorf("failed to marshal revision: %v", err)
+	}
+	_, err = tx.Exec(
+		"INSERT INTO perfect_day_revisions (perfect_day_id, revision, author, created_at, snapshot) VALUES (?, ?, ?, ?, ?)",
+		snapshot.PerfectDay.ID, snapshot.Revision, snapshot.Author, formatSQLiteTime(snapshot.CreatedAt), string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save revision: %v", err)
+	}
+	return nil
+}
+
+// deleteSQLiteActivities removes a perfect day's activities together with
+// the locations they own.
+func deleteSQLiteActivities(tx *sql.Tx, perfectDayID string) error {
+	rows, err := tx.Query("SELECT location_id FROM activities WHERE perfect_day_id = ?", perfectDayID)
+	if err != nil {
+		return fmt.Errorf("failed to query activities: %v", err)
+	}
+
+	var locationIDs []interface{}
+	for rows.Next() {
+		var locationID int64
+		if err := rows.Scan(&locationID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read activity: %v", err)
+		}
+		locationIDs = append(locationIDs, locationID)
+	}
+	rows.Close()
+
+	if _, err := tx.Exec("DELETE FROM activities WHERE perfect_day_id = ?", perfectDayID); err != nil {
+		return fmt.Errorf("failed to delete activities: %v", err)
+	}
+
+	if len(locationIDs) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(locationIDs)), ",")
+		if _, err := tx.Exec("DELETE FROM locations WHERE id IN ("+placeholders+")", locationIDs...); err != nil {
+			return fmt.Errorf("failed to delete locations: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// SnapshotSQLite writes a consistent copy of the database at path to dst,
+// including transactions still held in the write-ahead log. dst must not
+// exist yet.
+func SnapshotSQLite(path, dst string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open sqlite database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("VACUUM INTO ?", dst); err != nil {
+		return fmt.Errorf("failed to snapshot sqlite database: %v", err)
+	}
+	return nil
+}
+
+type SQLiteSessionStorage struct {
+	db *sql.DB
+}
+
+func NewSQLiteSessionStorage(db *sql.DB) *SQLiteSessionStorage {
+	return &SQLiteSessionStorage{db: db}
+}
+
+func (ss *SQLiteSessionStorage) Save(session *models.Session) error {
+	_, err := ss.db.Exec(`
+INSERT INTO sessions (id, username, created_at, last_seen_at, expires_at) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET username = excluded.username, created_at = excluded.created_at,
+	last_seen_at = excluded.last_seen_at, expires_at = excluded.expires_at`,
+		session.ID, session.Username, formatSQLiteTime(session.CreatedAt),
+		formatSQLiteTime(session.LastSeenAt), formatSQLiteTime(session.ExpiresAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save session: %v", err)
+	}
+	return nil
+}
+
+func (ss *SQLiteSessionStorage) Load(id string) (*models.Session, error) {
+	sessions, err := ss.query("WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	return sessions[0], nil
+}
+
+func (ss *SQLiteSessionStorage) Touch(id string, lastSeenAt, expiresAt time.Time) error {
+	result, err := ss.db.Exec("UPDATE sessions SET last_seen_at = ?, expires_at = ? WHERE id = ?",
+		formatSQLiteTime(lastSeenAt), formatSQLiteTime(expiresAt), id)
+	if err != nil {
+		return fmt.Errorf("failed to extend session: %v", err)
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	return nil
+}
+
+func (ss *SQLiteSessionStorage) Delete(id string) error {
+	if _, err := ss.db.Exec("DELETE FROM sessions WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete session: %v", err)
+	}
+	return nil
+}
+
+func (ss *SQLiteSessionStorage) ListByUser(username string) ([]*models.Session, error) {
+	sessions, err := ss.query("WHERE username = ?", username)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+// DeleteExpired compares expiry times in Go: RFC 3339 strings with
+// fractional seconds do not sort chronologically.
+func (ss *SQLiteSessionStorage) DeleteExpired(now time.Time) (int, error) {
+	sessions, err := ss.query("")
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, session := range sessions {
+		if !session.IsExpired(now) {
+			continue
+		}
+		if err := ss.Delete(session.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (ss *SQLiteSessionStorage) query(where string, args ...interface{}) ([]*models.Session, error) {
+	rows, err := ss.db.Query("SELECT id, username, created_at, last_seen_at, expires_at FROM sessions "+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		var session models.Session
+		var createdAt, lastSeenAt, expiresAt string
+		if err := rows.Scan(&session.ID, &session.Username, &createdAt, &lastSeenAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to read session: %v", err)
+		}
+		session.CreatedAt = parseSQLiteTime(createdAt)
+		session.LastSeenAt = parseSQLiteTime(lastSeenAt)
+		session.ExpiresAt = parseSQLiteTime(expiresAt)
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, rows.Err()
+}
+
+type SQLiteTokenStorage struct {
+	db *sql.DB
+}
+
+func NewSQLiteTokenStorage(db *sql.DB) *SQLiteTokenStorage {
+	return &SQLiteTokenStorage{db: db}
+}
+
+// Save stores the scopes space-separated, as in OAuth scope strings.
+func (ts *SQLiteTokenStorage) Save(token *models.APIToken) error {
+	_, err := ts.db.Exec(`
+INSERT INTO api_tokens (id, username, name, scopes, created_at, last_used_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET username = excluded.username, name = excluded.name, scopes = excluded.scopes,
+	created_at = excluded.created_at, last_used_at = excluded.last_used_at, expires_at = excluded.expires_at`,
+		token.ID, token.Username, token.Name, strings.Join(token.Scopes, " "), formatSQLiteTime(token.CreatedAt),
+		formatSQLiteNullTime(token.LastUsedAt), formatSQLiteNullTime(token.ExpiresAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save API token: %v", err)
+	}
+	return nil
+}
+
+func (ts *SQLiteTokenStorage) Load(id string) (*models.APIToken, error) {
+	tokens, err := ts.query("WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+	return tokens[0], nil
+}
+
+func (ts *SQLiteTokenStorage) Touch(id string, lastUsedAt time.Time) error {
+	result, err := ts.db.Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", formatSQLiteTime(lastUsedAt), id)
+	if err != nil {
+		return fmt.Errorf("failed to record API token use: %v", err)
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+	return nil
+}
+
+func (ts *SQLiteTokenStorage) Delete(id string) error {
+	if _, err := ts.db.Exec("DELETE FROM api_tokens WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete API token: %v", err)
+	}
+	return nil
+}
+
+func (ts *SQLiteTokenStorage) ListByUser(username string) ([]*models.APIToken, error) {
+	tokens, err := ts.query("WHERE username = ?", username)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+	})
+	return tokens, nil
+}
+
+func (ts *SQLiteTokenStorage) query(where string, args ...interface{}) ([]*models.APIToken, error) {
+	rows, err := ts.db.Query("SELECT id, username, name, scopes, created_at, last_used_at, expires_at FROM api_tokens "+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API tokens: %v", err)
+	}
+	defer rows.Close()
+
+	tokens := []*models.APIToken{}
+	for rows.Next() {
+		var token models.APIToken
+		var scopes, createdAt string
+		var lastUsedAt, expiresAt sql.NullString
+		if err := rows.Scan(&token.ID, &token.Username, &token.Name, &scopes, &createdAt, &lastUsedAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to read API token: %v", err)
+		}
+		token.Scopes = strings.Fields(scopes)
+		token.CreatedAt = parseSQLiteTime(createdAt)
+		if lastUsedAt.Valid {
+			t := parseSQLiteTime(lastUsedAt.String)
+			token.LastUsedAt = &t
+		}
+		if expiresAt.Valid {
+			t := parseSQLiteTime(expiresAt.String)
+			token.ExpiresAt = &t
+		}
+		tokens = append(tokens, &token)
+	}
+
+	return tokens, rows.Err()
+}
+
+type SQLiteAuditStorage struct {
+	db *sql.DB
+}
+
+func NewSQLiteAuditStorage(db *sql.DB) *SQLiteAuditStorage {
+	return &SQLiteAuditStorage{db: db}
+}
+
+// sqliteAuditTimeFormat has a fixed width and is always UTC, so event
+// times compare as strings.
+const sqliteAuditTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+func (as *SQLiteAuditStorage) Append(event *models.AuditEvent) error {
+	_, err := as.db.Exec(
+		"INSERT INTO audit_events (id, time, actor, action, target, ip, user_agent, detail) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		event.ID, event.Time.UTC().Format(sqliteAuditTimeFormat), event.Actor, event.Action, event.Target,
+		event.IP, event.UserAgent, event.Detail,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append audit event: %v", err)
+	}
+	return nil
+}
+
+func (as *SQLiteAuditStorage) Query(filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "time >= ?")
+		args = append(args, filter.From.UTC().Format(sqliteAuditTimeFormat))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "time < ?")
+		args = append(args, filter.To.UTC().Format(sqliteAuditTimeFormat))
+	}
+
+	query := "SELECT id, time, actor, action, target, ip, user_agent, detail FROM audit_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY seq DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := as.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit events: %v", err)
+	}
+	defer rows.Close()
+
+	events := []*models.AuditEvent{}
+	for rows.Next() {
+		var event models.AuditEvent
+		var eventTime string
+		if err := rows.Scan(&event.ID, &eventTime, &event.Actor, &event.Action, &event.Target,
+			&event.IP, &event.UserAgent, &event.Detail); err != nil {
+			return nil, fmt.Errorf("failed to read audit event: %v", err)
+		}
+		event.Time, _ = time.Parse(sqliteAuditTimeFormat, eventTime)
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
+
+func formatSQLiteTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func formatSQLiteNullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatSQLiteTime(*t), Valid: true}
+}
+
+func parseSQLiteTime(value string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, value)
+	return t
+}
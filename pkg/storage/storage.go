@@ -1,33 +1,114 @@
 package storage
 
 import (
+	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
+// Backend names accepted by Open.
+const (
+	BackendFiles  = "files"
+	BackendSQLite = "sqlite"
+	BackendMemory = "memory"
+)
+
 type Storage struct {
-	UserStorage       *UserStorage
-	PerfectDayStorage *PerfectDayStorage
+	UserStorage       UserRepository
+	PerfectDayStorage PerfectDayRepository
+	SessionStorage    SessionRepository
+	TokenStorage      TokenRepository
+	AuditStorage      AuditRepository
 	dataDir           string
+	db                *sql.DB
 }
 
+// NewStorage returns a Storage backed by the JSON file layout under dataDir.
 func NewStorage(dataDir string) *Storage {
-	if dataDir == "" {
-		homeDir, _ := os.UserHomeDir()
-		dataDir = filepath.Join(homeDir, ".perfect-day")
-	}
+	dataDir = defaultDataDir(dataDir)
 
 	return &Storage{
 		UserStorage:       NewUserStorage(dataDir),
 		PerfectDayStorage: NewPerfectDayStorage(dataDir),
+		SessionStorage:    NewSessionStorage(dataDir),
+		TokenStorage:      NewTokenStorage(dataDir),
+		AuditStorage:      NewAuditStorage(dataDir),
 		dataDir:           dataDir,
 	}
 }
 
+// NewMemoryStorage returns a Storage whose repositories live in memory.
+// It has no data directory.
+func NewMemoryStorage() *Storage {
+	return &Storage{
+		UserStorage:       NewMemoryUserStorage(),
+		PerfectDayStorage: NewMemoryPerfectDayStorage(),
+		SessionStorage:    NewMemorySessionStorage(),
+		TokenStorage:      NewMemoryTokenStorage(),
+		AuditStorage:      NewMemoryAuditStorage(),
+	}
+}
+
+// NewSQLiteStorage returns a Storage backed by a SQLite database inside
+// dataDir, applying any pending schema migrations.
+func NewSQLiteStorage(dataDir string) (*Storage, error) {
+	dataDir = defaultDataDir(dataDir)
+
+	db, err := OpenSQLite(filepath.Join(dataDir, SQLiteFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Storage{
+		UserStorage:       NewSQLiteUserStorage(db),
+		PerfectDayStorage: NewSQLitePerfectDayStorage(db),
+		SessionStorage:    NewSQLiteSessionStorage(db),
+		TokenStorage:      NewSQLiteTokenStorage(db),
+		AuditStorage:      NewSQLiteAuditStorage(db),
+		dataDir:           dataDir,
+		db:                db,
+	}, nil
+}
+
+// Open returns the storage for the named backend. An empty backend selects
+// the JSON file layout.
+func Open(backend, dataDir string) (*Storage, error) {
+	switch backend {
+	case "", BackendFiles:
+		return NewStorage(dataDir), nil
+	case BackendSQLite:
+		return NewSQLiteStorage(dataDir)
+	case BackendMemory:
+		return NewMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", backend)
+	}
+}
+
 func (s *Storage) GetDataDir() string {
 	return s.dataDir
 }
 
 func (s *Storage) Initialize() error {
+	if s.dataDir == "" {
+		return nil
+	}
 	return os.MkdirAll(s.dataDir, 0755)
-}
\ No newline at end of file
+}
+
+// Close releases resources held by the backend, such as a database handle.
+func (s *Storage) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func defaultDataDir(dataDir string) string {
+	if dataDir == "" {
+		homeDir, _ := os.UserHomeDir()
+		dataDir = filepath.Join(homeDir, ".perfect-day")
+	}
+	return dataDir
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TokenStorage keeps one tokens/<id>.json file per personal API token.
+type TokenStorage struct {
+	dataDir string
+}
+
+func NewTokenStorage(dataDir string) *TokenStorage {
+	return &TokenStorage{dataDir: dataDir}
+}
+
+func (ts *TokenStorage) Save(token *models.APIToken) error {
+	if err := os.MkdirAll(ts.tokensDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create tokens directory: %v", err)
+	}
+
+	lock, err := LockDataDir(ts.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal API token: %v", err)
+	}
+
+	if err := writeFileAtomic(ts.filePath(token.ID), data, 0600); err != nil {
+		return fmt.Errorf("failed to write API token file: %v", err)
+	}
+
+	return nil
+}
+
+func (ts *TokenStorage) Load(id string) (*models.APIToken, error) {
+	if !validHashID(id) {
+		return nil, fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+
+	data, err := os.ReadFile(ts.filePath(id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API token file: %v", err)
+	}
+
+	var token models.APIToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal API token: %v", err)
+	}
+
+	return &token, nil
+}
+
+func (ts *TokenStorage) Touch(id string, lastUsedAt time.Time) error {
+	if !validHashID(id) {
+		return fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+
+	lock, err := LockDataDir(ts.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	token, err := ts.Load(id)
+	if err != nil {
+		return err
+	}
+	token.LastUsedAt = &lastUsedAt
+
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal API token: %v", err)
+	}
+
+	if err := writeFileAtomic(ts.filePath(id), data, 0600); err != nil {
+		return fmt.Errorf("failed to write API token file: %v", err)
+	}
+
+	return nil
+}
+
+func (ts *TokenStorage) Delete(id string) error {
+	if !validHashID(id) {
+		return nil
+	}
+
+	lock, err := LockDataDir(ts.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := os.Remove(ts.filePath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete API token file: %v", err)
+	}
+	return nil
+}
+
+func (ts *TokenStorage) ListByUser(username string) ([]*models.APIToken, error) {
+	entries, err := os.ReadDir(ts.tokensDir())
+	if os.IsNotExist(err) {
+		return []*models.APIToken{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens directory: %v", err)
+	}
+
+	tokens := []*models.APIToken{}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		token, err := ts.Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil || token.Username != username {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+	})
+	return tokens, nil
+}
+
+func (ts *TokenStorage) tokensDir() string {
+	return filepath.Join(ts.dataDir, "tokens")
+}
+
+func (ts *TokenStorage) filePath(id string) string {
+	return filepath.Join(ts.tokensDir(), id+".json")
+}
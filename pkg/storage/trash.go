@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"perfect-day/pkg/models"
+	"sort"
+	"time"
+)
+
+// ListDeleted returns the soft-deleted perfect days of username, or of every
+// user when username is empty, oldest deletion first.
+func ListDeleted(perfectDays PerfectDayRepository, username string) ([]*models.PerfectDay, error) {
+	var all []*models.PerfectDay
+	var err error
+	if username == "" {
+		all, err = perfectDays.LoadAll(true)
+	} else {
+		all, err = perfectDays.LoadAllByUser(username, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []*models.PerfectDay{}
+	for _, perfectDay := range all {
+		if perfectDay.IsDeleted {
+			deleted = append(deleted, perfectDay)
+		}
+	}
+
+	sort.SliceStable(deleted, func(i, j int) bool {
+		return deleted[i].DeletionTime().Before(deleted[j].DeletionTime())
+	})
+	return deleted, nil
+}
+
+// PurgeDeleted permanently removes soft-deleted perfect days that went to
+// the trash before cutoff, together with their revision history. Perfect
+// days restored or changed meanwhile are left alone. It returns the purged
+// perfect days; on error, those purged so far.
+func PurgeDeleted(perfectDays PerfectDayRepository, username string, cutoff time.Time) ([]*models.PerfectDay, error) {
+	deleted, err := ListDeleted(perfectDays, username)
+	if err != nil {
+		return nil, err
+	}
+
+	purged := []*models.PerfectDay{}
+	for _, perfectDay := range deleted {
+		if !perfectDay.DeletionTime().Before(cutoff) {
+			continue
+		}
+		err := perfectDays.Delete(perfectDay.Username, perfectDay.ID, perfectDay.Revision)
+		if errors.Is(err, ErrRevisionConflict) {
+			continue
+		}
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge perfect day %s: %v", perfectDay.ID, err)
+		}
+		purged = append(purged, perfectDay)
+	}
+
+	return purged, nil
+}
@@ -1,11 +1,11 @@
 package storage
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"perfect-day/pkg/models"
+	"strings"
 )
 
 type UserStorage struct {
@@ -21,17 +21,55 @@ func (us *UserStorage) Save(user *models.User) error {
 		return err
 	}
 
+	lock, err := LockDataDir(us.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	filePath := filepath.Join(us.dataDir, "users", user.Username+".json")
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create user directory: %v", err)
+	}
+
+	data, err := encodeUser(user)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user: %v", err)
+	}
+
+	if err := writeFileAtomic(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write user file: %v", err)
+	}
+
+	return nil
+}
+
+func (us *UserStorage) Create(user *models.User) error {
+	if err := us.ensureDataDir(); err != nil {
+		return err
+	}
+
+	lock, err := LockDataDir(us.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
 	filePath := filepath.Join(us.dataDir, "users", user.Username+".json")
 	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 		return fmt.Errorf("failed to create user directory: %v", err)
 	}
 
-	data, err := json.MarshalIndent(user, "", "  ")
+	data, err := encodeUser(user)
 	if err != nil {
 		return fmt.Errorf("failed to marshal user: %v", err)
 	}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	err = CreateFileAtomic(filePath, data, 0644)
+	if os.IsExist(err) {
+		return fmt.Errorf("%w: %s", ErrUserExists, user.Username)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to write user file: %v", err)
 	}
 
@@ -49,12 +87,38 @@ func (us *UserStorage) Load(username string) (*models.User, error) {
 		return nil, fmt.Errorf("failed to read user file: %v", err)
 	}
 
-	var user models.User
-	if err := json.Unmarshal(data, &user); err != nil {
+	user, _, err := DecodeUser(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal user: %v", err)
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+func (us *UserStorage) List() ([]*models.User, error) {
+	entries, err := os.ReadDir(filepath.Join(us.dataDir, "users"))
+	if os.IsNotExist(err) {
+		return []*models.User{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users directory: %v", err)
+	}
+
+	var users []*models.User
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		user, err := us.Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
 }
 
 func (us *UserStorage) Exists(username string) bool {
@@ -63,6 +127,25 @@ func (us *UserStorage) Exists(username string) bool {
 	return !os.IsNotExist(err)
 }
 
+func (us *UserStorage) Delete(username string) error {
+	lock, err := LockDataDir(us.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	filePath := filepath.Join(us.dataDir, "users", username+".json")
+	err = os.Remove(filePath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("user not found: %s", username)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete user file: %v", err)
+	}
+
+	return nil
+}
+
 func (us *UserStorage) ensureDataDir() error {
 	return os.MkdirAll(us.dataDir, 0755)
 }
\ No newline at end of file
@@ -4,21 +4,45 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/term"
 )
 
 func GenerateID() string {
 	return uuid.New().String()
 }
 
+// stdin is shared by every prompt: a reader per prompt could buffer input
+// meant for the next one when stdin is not a terminal.
+var stdin = bufio.NewReader(os.Stdin)
+
 func PromptInput(prompt string) string {
 	fmt.Print(prompt)
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	return strings.TrimSpace(scanner.Text())
+	return strings.TrimSpace(readLine())
+}
+
+// PromptPassword asks for a password without echoing it when stdin is a
+// terminal.
+func PromptPassword(prompt string) string {
+	fmt.Print(prompt)
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return strings.TrimRight(readLine(), "\r\n")
+	}
+
+	password, _ := term.ReadPassword(fd)
+	fmt.Println()
+	return string(password)
+}
+
+func readLine() string {
+	line, _ := stdin.ReadString('\n')
+	return line
 }
 
 func PromptConfirm(prompt string) bool {
@@ -61,4 +85,23 @@ func PadString(s string, width int) string {
 		return s
 	}
 	return s + strings.Repeat(" ", width-len(s))
-}
\ No newline at end of file
+}
+
+// ParseAge parses an age such as "30d", "12h" or "90m". Besides the units
+// understood by time.ParseDuration it accepts whole days ("d").
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q, expected e.g. 30d", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. 30d or 12h", value)
+	}
+	return age, nil
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"perfect-day/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActivityEndpoints(t *testing.T) {
+	srv := setupTestServer(t)
+	createTestUser(srv, "testuser")
+	createTestUser(srv, "otheruser")
+	sessionID := loginUser(srv, "testuser")
+	otherSessionID := loginUser(srv, "otheruser")
+
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	pd, _ := models.NewPerfectDay("test-id-activities", "Tokyo Day", "A day", "testuser", "2025-01-15")
+	for _, activity := range []struct{ id, name, area string }{{"act-1", "Coffee", "Shibuya"}, {"act-2", "Walk", "Harajuku"}} {
+		a, _ := models.NewActivity(activity.id, activity.name, *models.NewCustomTextLocation(activity.name+" place", activity.area), "09:00", 60, "", "")
+		a.CreatedAt = created
+		pd.AddActivity(*a)
+	}
+	srv.Storage.PerfectDayStorage.Save(pd)
+
+	send := func(method, path, body, sessionID string) (*httptest.ResponseRecorder, json.RawMessage, string) {
+		req := httptest.NewRequest(method, "/api/v1/perfect-days/test-id-activities"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			addSession(req, sessionID)
+		}
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+
+		var response struct {
+			Data  json.RawMessage `json:"data"`
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response.Data, response.Error.Code
+	}
+	stored := func() *models.PerfectDay {
+		pd, err := srv.Storage.PerfectDayStorage.LoadByID("test-id-activities")
+		if err != nil {
+			t.Fatalf("Failed to load perfect day: %v", err)
+		}
+		return pd
+	}
+
+	t.Run("list_and_get", func(t *testing.T) {
+		rr, data, _ := send("GET", "/activities", "", "")
+		var activities []models.Activity
+		json.Unmarshal(data, &activities)
+		if rr.Code != http.StatusOK || len(activities) != 2 || activities[0].ID != "act-1" {
+			t.Fatalf("Expected both activities, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if rr.Header().Get("ETag") == "" {
+			t.Error("Expected the ETag of the perfect day")
+		}
+
+		rr, data, _ = send("GET", "/activities/act-2", "", "")
+		var activity models.Activity
+		json.Unmarshal(data, &activity)
+		if rr.Code != http.StatusOK || activity.Name != "Walk" {
+			t.Errorf("Expected the walk, got %d: %s", rr.Code, rr.Body.String())
+		}
+
+		rr, _, code := send("GET", "/activities/missing", "", "")
+		if rr.Code != http.StatusNotFound || code != "ACTIVITY_NOT_FOUND" {
+			t.Errorf("Expected 404 ACTIVITY_NOT_FOUND, got %d %s", rr.Code, code)
+		}
+	})
+
+	var addedID string
+	t.Run("create", func(t *testing.T) {
+		body := `{"name": "Dinner", "location": {"type": "custom_text", "name": "Izakaya", "area": "Ebisu"}, "start_time": "19:00", "duration": 90}`
+		rr, data, _ := send("POST", "/activities", body, sessionID)
+		var activity models.Activity
+		json.Unmarshal(data, &activity)
+		if rr.Code != http.StatusCreated || activity.ID == "" || activity.Name != "Dinner" {
+			t.Fatalf("Expected the created activity, got %d: %s", rr.Code, rr.Body.String())
+		}
+		addedID = activity.ID
+
+		pd := stored()
+		if len(pd.Activities) != 3 || pd.Activities[2].ID != addedID || pd.Areas[0] != "Ebisu" {
+			t.Errorf("Expected the activity at the end and its area, got %+v %v", pd.Activities, pd.Areas)
+		}
+		if pd.UpdatedBy != "testuser" || rr.Header().Get("ETag") != `"test-id-activities-2"` {
+			t.Errorf("Expected a new revision by testuser, got %s %s", pd.UpdatedBy, rr.Header().Get("ETag"))
+		}
+
+		rr, _, code := send("POST", "/activities", `{"name": "Dinner"}`, sessionID)
+		if rr.Code != http.StatusBadRequest || code != "VALIDATION_ERROR" {
+			t.Errorf("Expected 400 VALIDATION_ERROR, got %d %s", rr.Code, code)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		body := `{"name": "Long walk", "location": {"type": "custom_text", "name": "Yoyogi Park", "area": "Harajuku"}, "start_time": "10:00", "duration": 120}`
+		rr, data, _ := send("PUT", "/activities/act-2", body, sessionID)
+		var activity models.Activity
+		json.Unmarshal(data, &activity)
+		if rr.Code != http.StatusOK || activity.ID != "act-2" || activity.Name != "Long walk" || !activity.CreatedAt.Equal(created) {
+			t.Fatalf("Expected act-2 to keep its ID and creation time, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if pd := stored(); pd.Activities[1].ID != "act-2" || pd.Activities[1].Duration != 120 {
+			t.Errorf("Expected act-2 to be updated in place, got %+v", pd.Activities)
+		}
+
+		rr, _, code := send("PUT", "/activities/missing", body, sessionID)
+		if rr.Code != http.StatusNotFound || code != "ACTIVITY_NOT_FOUND" {
+			t.Errorf("Expected 404 ACTIVITY_NOT_FOUND, got %d %s", rr.Code, code)
+		}
+	})
+
+	t.Run("reorder", func(t *testing.T) {
+		rr, _, code := send("POST", "/activities:reorder", `{"activity_ids": ["act-2", "act-1"]}`, sessionID)
+		if rr.Code != http.StatusBadRequest || code != "VALIDATION_ERROR" {
+			t.Errorf("Expected an incomplete order to be rejected, got %d %s", rr.Code, code)
+		}
+
+		rr, data, _ := send("POST", "/activities:reorder", `{"activity_ids": ["`+addedID+`", "act-2", "act-1"]}`, sessionID)
+		var activities []models.Activity
+		json.Unmarshal(data, &activities)
+		if rr.Code != http.StatusOK || len(activities) != 3 || activities[0].ID != addedID || activities[2].ID != "act-1" {
+			t.Fatalf("Expected the new order, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if pd := stored(); pd.Activities[0].ID != addedID || pd.Activities[1].ID != "act-2" {
+			t.Errorf("Expected the new order to be saved, got %+v", pd.Activities)
+		}
+
+		// Unknown custom methods are 404 for everyone, before any access check
+		for _, session := range []string{sessionID, otherSessionID, ""} {
+			rr, _, _ = send("POST", "/activities:shuffle", `{}`, session)
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("Expected an unknown custom method to be 404, got %d", rr.Code)
+			}
+		}
+		req := httptest.NewRequest("POST", "/api/v1/perfect-days/missing/activities:shuffle", strings.NewReader(`{}`))
+		rr = httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected an unknown custom method on a missing perfect day to be 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		rr, _, _ := send("DELETE", "/activities/act-1", "", sessionID)
+		if rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+		}
+		if pd := stored(); len(pd.Activities) != 2 || len(pd.Areas) != 2 {
+			t.Errorf("Expected the coffee and Shibuya to be gone, got %+v %v", pd.Activities, pd.Areas)
+		}
+
+		rr, _, code := send("DELETE", "/activities/act-1", "", sessionID)
+		if rr.Code != http.StatusNotFound || code != "ACTIVITY_NOT_FOUND" {
+			t.Errorf("Expected 404 ACTIVITY_NOT_FOUND, got %d %s", rr.Code, code)
+		}
+	})
+
+	t.Run("if_match", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/api/v1/perfect-days/test-id-activities/activities/act-2", nil)
+		req.Header.Set("If-Match", `"test-id-activities-1"`)
+		addSession(req, sessionID)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		if rr.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status %d for a stale ETag, got %d", http.StatusPreconditionFailed, rr.Code)
+		}
+	})
+
+	t.Run("ownership", func(t *testing.T) {
+		before := stored().Revision
+		requests := []struct{ method, path, body string }{
+			{"POST", "/activities", `{"name": "Dinner", "location": {"type": "custom_text", "name": "Izakaya", "area": "Ebisu"}, "start_time": "19:00", "duration": 90}`},
+			{"PUT", "/activities/act-2", `{"name": "Run", "location": {"type": "custom_text", "name": "Park", "area": "Harajuku"}, "start_time": "10:00", "duration": 30}`},
+			{"DELETE", "/activities/act-2", ""},
+			{"POST", "/activities:reorder", `{"activity_ids": ["act-2", "` + addedID + `"]}`},
+		}
+		for _, r := range requests {
+			if rr, _, _ := send(r.method, r.path, r.body, otherSessionID); rr.Code != http.StatusForbidden {
+				t.Errorf("Expected %s %s by another user to be forbidden, got %d", r.method, r.path, rr.Code)
+			}
+			if rr, _, _ := send(r.method, r.path, r.body, ""); rr.Code != http.StatusUnauthorized {
+				t.Errorf("Expected %s %s without a session to be unauthorized, got %d", r.method, r.path, rr.Code)
+			}
+		}
+		if after := stored().Revision; after != before {
+			t.Errorf("Expected no changes, revision went from %d to %d", before, after)
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"perfect-day/pkg/backup"
+	"perfect-day/pkg/config"
+	"perfect-day/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestAdminBackup(t *testing.T) {
+	srv := newTestServer(t, &config.Config{
+		DataDir:    t.TempDir(),
+		AdminUsers: []string{"admin"},
+	})
+	createTestUser(srv, "admin")
+	createTestUser(srv, "alice")
+
+	pd, _ := models.NewPerfectDay("admin001-0000-0000-0000-000000000001", "Backed Up", "", "alice", "2024-01-01")
+	srv.Storage.PerfectDayStorage.Save(pd)
+
+	t.Run("requires_auth", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/admin/backup", nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("forbidden_for_non_admin", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/admin/backup", nil)
+		addSession(req, loginUser(srv, "alice"))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("admin_downloads_archive", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/admin/backup", nil)
+		addSession(req, loginUser(srv, "admin"))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "application/gzip" {
+			t.Errorf("Expected application/gzip, got %s", w.Header().Get("Content-Type"))
+		}
+		if !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment;") {
+			t.Errorf("Expected an attachment, got %s", w.Header().Get("Content-Disposition"))
+		}
+
+		manifest, err := backup.Verify(bytes.NewReader(w.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("Downloaded archive does not verify: %v", err)
+		}
+		found := false
+		for _, entry := range manifest.Files {
+			if entry.Path == "perfect-days/alice/"+pd.ID+".json" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected the perfect day in the archive, got %+v", manifest.Files)
+		}
+	})
+}
+
+func TestRoleBasedAccess(t *testing.T) {
+	srv := newTestServer(t, &config.Config{
+		DataDir:    t.TempDir(),
+		AdminUsers: []string{"admin"},
+	})
+	createTestUser(srv, "admin")
+	createTestUser(srv, "alice")
+	createTestUser(srv, "bob")
+	createTestUser(srv, "mod")
+
+	moderator, _ := srv.Storage.UserStorage.Load("mod")
+	moderator.Role = models.RoleModerator
+	srv.Storage.UserStorage.Save(moderator)
+
+	sessions := map[string]string{}
+	for _, username := range []string{"admin", "alice", "bob", "mod"} {
+		sessions[username] = loginUser(srv, username)
+	}
+
+	send := func(method, path, body, username string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if username != "" {
+			addSession(req, sessions[username])
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	newPerfectDay := func(id, title string) *models.PerfectDay {
+		pd, _ := models.NewPerfectDay(id, title, "", "alice", "2024-01-01")
+		srv.Storage.PerfectDayStorage.Save(pd)
+		return pd
+	}
+	update := `{"title":"Edited","date":"2024-01-01","activities":[]}`
+
+	t.Run("moderator_deletes_but_cannot_edit", func(t *testing.T) {
+		pd := newPerfectDay("rbac0001-0000-0000-0000-000000000001", "Moderated")
+
+		if w := send("PUT", "/api/v1/perfect-days/"+pd.ID, update, "mod"); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d editing as a moderator, got %d", http.StatusForbidden, w.Code)
+		}
+		if w := send("DELETE", "/api/v1/perfect-days/"+pd.ID, "", "bob"); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d deleting another user's perfect day, got %d", http.StatusForbidden, w.Code)
+		}
+		if w := send("DELETE", "/api/v1/perfect-days/"+pd.ID, "", "mod"); w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d deleting as a moderator, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+		}
+		if w := send("POST", "/api/v1/perfect-days/"+pd.ID+"/restore", "", "mod"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected the trash to stay hidden from moderators, got %d", w.Code)
+		}
+		if w := send("POST", "/api/v1/perfect-days/"+pd.ID+"/restore", "", "alice"); w.Code != http.StatusOK {
+			t.Errorf("Expected the owner to restore the perfect day, got %d", w.Code)
+		}
+	})
+
+	t.Run("admin_routes_require_admin_role", func(t *testing.T) {
+		for _, username := range []string{"alice", "mod"} {
+			if w := send("GET", "/api/v1/admin/users", "", username); w.Code != http.StatusForbidden {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusForbidden, username, w.Code)
+			}
+		}
+	})
+
+	t.Run("admin_lists_users_and_sets_roles", func(t *testing.T) {
+		w := send("GET", "/api/v1/admin/users", "", "admin")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data []struct {
+				Username string `json:"username"`
+				Role     string `json:"role"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		roles := map[string]string{}
+		for _, user := range response.Data {
+			roles[user.Username] = user.Role
+		}
+		if roles["admin"] != models.RoleAdmin || roles["mod"] != models.RoleModerator || roles["bob"] != models.RoleUser {
+			t.Errorf("Expected every user with their role, got %v", roles)
+		}
+
+		if w := send("PUT", "/api/v1/admin/users/bob/role", `{"role":"owner"}`, "admin"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an unknown role, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := send("PUT", "/api/v1/admin/users/nobody/role", `{"role":"admin"}`, "admin"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for an unknown user, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := send("PUT", "/api/v1/admin/users/bob/role", `{"role":"admin"}`, "admin"); w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d setting a role, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w := send("GET", "/api/v1/admin/users", "", "bob"); w.Code != http.StatusOK {
+			t.Errorf("Expected the new admin to use admin routes, got %d", w.Code)
+		}
+	})
+
+	t.Run("admin_manages_trash", func(t *testing.T) {
+		trashed := newPerfectDay("rbac0002-0000-0000-0000-000000000002", "Trashed")
+		trashed.SoftDelete()
+		srv.Storage.PerfectDayStorage.Save(trashed)
+		live := newPerfectDay("rbac0003-0000-0000-0000-000000000003", "Live")
+
+		w := send("GET", "/api/v1/admin/trash", "", "admin")
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), trashed.ID) {
+			t.Errorf("Expected the trashed perfect day in the admin trash, got %d: %s", w.Code, w.Body.String())
+		}
+
+		if w := send("POST", "/api/v1/admin/perfect-days/"+trashed.ID+"/restore", "", "admin"); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d restoring as an admin, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w := send("DELETE", "/api/v1/admin/perfect-days/"+live.ID, "", "admin"); w.Code != http.StatusNoContent {
+			t.Errorf("Expected status %d force-deleting, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+		}
+		if _, err := srv.Storage.PerfectDayStorage.LoadByID(live.ID); err == nil {
+			t.Error("Expected the force-deleted perfect day to be gone")
+		}
+		if w := send("DELETE", "/api/v1/admin/perfect-days/"+trashed.ID, "", "alice"); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d force-deleting as a user, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+	t.Run("only_owner_and_admin_list_trash", func(t *testing.T) {
+		trashed := newPerfectDay("rbac0004-0000-0000-0000-000000000004", "Hidden Trash")
+		trashed.SoftDelete()
+		srv.Storage.PerfectDayStorage.Save(trashed)
+
+		// Public routes authenticate with API tokens only
+		for username, visible := range map[string]bool{"alice": true, "admin": true, "mod": false, "": false} {
+			req := httptest.NewRequest("GET", "/api/v1/users/alice/perfect-days?include_deleted=true&limit=100", nil)
+			if username != "" {
+				token, err := srv.AuthService.CreateToken(username, "script", []string{models.ScopePerfectDaysRead}, 0)
+				if err != nil {
+					t.Fatalf("Failed to create token: %v", err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token.Token)
+			}
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d for %q, got %d: %s", http.StatusOK, username, w.Code, w.Body.String())
+			}
+			if got := strings.Contains(w.Body.String(), trashed.ID); got != visible {
+				t.Errorf("Expected trash visible to %q to be %v, got %v", username, visible, got)
+			}
+		}
+	})
+}
+
+func TestAdminUsersCannotBeClaimed(t *testing.T) {
+	srv := newTestServer(t, &config.Config{
+		DataDir:    t.TempDir(),
+		AdminUsers: []string{"root"},
+	})
+
+	for _, path := range []string{"/api/v1/auth/signup", "/api/v1/users"} {
+		body := `{"username":"root","password":"` + testPassword + `","timezone":"UTC"}`
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d signing up as an admin user at %s, got %d: %s", http.StatusConflict, path, w.Code, w.Body.String())
+		}
+	}
+	if srv.Storage.UserStorage.Exists("root") {
+		t.Fatal("Expected no account to be created for a reserved admin user")
+	}
+
+	// Once the owner creates the account locally, it is an admin
+	createTestUser(srv, "root")
+	req := httptest.NewRequest("GET", "/api/v1/admin/users", nil)
+	addSession(req, loginUser(srv, "root"))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected root to be an admin, got status %d", w.Code)
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"perfect-day/pkg/config"
+	"perfect-day/pkg/models"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	srv := newTestServer(t, &config.Config{
+		DataDir:    t.TempDir(),
+		AdminUsers: []string{"admin"},
+	})
+	createTestUser(srv, "admin")
+	createTestUser(srv, "alice")
+
+	send := func(method, path string, body interface{}, sessionID string) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "audit-test/1.0")
+		req.RemoteAddr = "192.0.2.7:1234"
+		if sessionID != "" {
+			addSession(req, sessionID)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	query := func(params url.Values, sessionID string) []models.AuditEvent {
+		w := send("GET", "/api/v1/admin/audit?"+params.Encode(), nil, sessionID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d from the audit log, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data struct {
+				Events []models.AuditEvent `json:"events"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data.Events
+	}
+
+	// Generate some history
+	send("POST", "/api/v1/auth/login", map[string]string{"username": "alice", "password": "wrong-password"}, "")
+	alice := loginUser(srv, "alice")
+	w := send("POST", "/api/v1/perfect-days", map[string]interface{}{"title": "Audited Day", "date": "2025-01-15", "activities": []interface{}{}}, alice)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create perfect day: %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data models.PerfectDay `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	send("DELETE", "/api/v1/perfect-days/"+created.Data.ID, nil, alice)
+	// Reads are not audited
+	send("GET", "/api/v1/users/alice", nil, alice)
+	send("GET", "/api/v1/users/alice", nil, "")
+	send("PATCH", "/api/v1/users/alice", map[string]string{"display_name": "Alice A"}, alice)
+	admin := loginUser(srv, "admin")
+
+	t.Run("forbidden_for_non_admin", func(t *testing.T) {
+		if w := send("GET", "/api/v1/admin/audit", nil, alice); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("records_actions", func(t *testing.T) {
+		events := query(url.Values{"actor": {"alice"}}, admin)
+		var actions []string
+		for i := len(events) - 1; i >= 0; i-- {
+			actions = append(actions, events[i].Action)
+		}
+		expected := []string{
+			models.AuditLoginSuccess,
+			models.AuditPerfectDayCreate,
+			models.AuditPerfectDayDelete,
+			models.AuditUserUpdate,
+		}
+		if len(actions) != len(expected) {
+			t.Fatalf("Expected actions %v, got %v", expected, actions)
+		}
+		for i := range expected {
+			if actions[i] != expected[i] {
+				t.Fatalf("Expected actions %v, got %v", expected, actions)
+			}
+		}
+	})
+
+	t.Run("skips_reads", func(t *testing.T) {
+		events := query(url.Values{"action": {models.AuditUserUpdate}}, admin)
+		if len(events) != 1 || events[0].Actor != "alice" {
+			t.Errorf("Expected only alice's profile change, got %+v", events)
+		}
+	})
+
+	t.Run("records_client", func(t *testing.T) {
+		events := query(url.Values{"action": {models.AuditPerfectDayDelete}}, admin)
+		if len(events) != 1 {
+			t.Fatalf("Expected 1 delete, got %d", len(events))
+		}
+		event := events[0]
+		if event.Actor != "alice" || event.Target != "perfect_day:"+created.Data.ID || event.IP != "192.0.2.7" || event.UserAgent != "audit-test/1.0" {
+			t.Errorf("Unexpected audit event %+v", event)
+		}
+	})
+
+	t.Run("failed_login_detail", func(t *testing.T) {
+		events := query(url.Values{"action": {models.AuditLoginFailure}}, admin)
+		if len(events) != 1 || events[0].Actor != "" || events[0].Target != "user:alice" || events[0].Detail != "invalid credentials" {
+			t.Errorf("Expected an anonymous failed login as alice, got %+v", events)
+		}
+	})
+
+	t.Run("time_range", func(t *testing.T) {
+		if events := query(url.Values{"to": {"2000-01-01"}}, admin); len(events) != 0 {
+			t.Errorf("Expected no events before 2000, got %d", len(events))
+		}
+		if events := query(url.Values{"from": {"2000-01-01T00:00:00Z"}, "limit": {"2"}}, admin); len(events) != 2 {
+			t.Errorf("Expected the limit to apply, got %d", len(events))
+		}
+	})
+
+	t.Run("invalid_time", func(t *testing.T) {
+		if w := send("GET", "/api/v1/admin/audit?from=yesterday", nil, admin); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
@@ -6,9 +6,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"perfect-day/internal/api/server"
+	"perfect-day/pkg/auth"
 	"perfect-day/pkg/config"
 	"perfect-day/pkg/models"
+	"strings"
 	"testing"
 	"time"
 )
@@ -22,13 +23,14 @@ func TestAuthenticationWorkflow(t *testing.T) {
 		DataDir: testDataDir,
 	}
 
-	testServer := server.NewServer(cfg)
+	testServer := newTestServer(t, cfg)
 
 	// Create a test user
 	user, err := models.NewUser("testuser", "UTC")
 	if err != nil {
 		t.Fatalf("Failed to create test user: %v", err)
 	}
+	user.PasswordHash, _ = auth.HashPassword(testPassword)
 
 	err = testServer.Storage.UserStorage.Save(user)
 	if err != nil {
@@ -61,6 +63,7 @@ func TestAuthenticationWorkflow(t *testing.T) {
 	t.Run("login", func(t *testing.T) {
 		loginReq := map[string]string{
 			"username": "testuser",
+			"password": testPassword,
 		}
 
 		reqBody, _ := json.Marshal(loginReq)
@@ -101,7 +104,7 @@ func TestAuthenticationWorkflow(t *testing.T) {
 		reqBody, _ := json.Marshal(perfectDayReq)
 		req := httptest.NewRequest("POST", "/api/v1/perfect-days", bytes.NewBuffer(reqBody))
 		req.Header.Set("Content-Type", "application/json")
-		req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionCookie})
+		addSession(req, sessionCookie)
 		w := httptest.NewRecorder()
 
 		testServer.ServeHTTP(w, req)
@@ -129,7 +132,7 @@ func TestAuthenticationWorkflow(t *testing.T) {
 		reqBody, _ := json.Marshal(updateReq)
 		req := httptest.NewRequest("PUT", "/api/v1/perfect-days/"+createdPerfectDayID, bytes.NewBuffer(reqBody))
 		req.Header.Set("Content-Type", "application/json")
-		req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionCookie})
+		addSession(req, sessionCookie)
 		w := httptest.NewRecorder()
 
 		testServer.ServeHTTP(w, req)
@@ -163,7 +166,7 @@ func TestAuthenticationWorkflow(t *testing.T) {
 	// Test 6: Delete perfect day with authentication (should succeed)
 	t.Run("delete_with_auth", func(t *testing.T) {
 		req := httptest.NewRequest("DELETE", "/api/v1/perfect-days/"+createdPerfectDayID, nil)
-		req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionCookie})
+		addSession(req, sessionCookie)
 		w := httptest.NewRecorder()
 
 		testServer.ServeHTTP(w, req)
@@ -195,11 +198,13 @@ func TestOwnershipVerification(t *testing.T) {
 		DataDir: testDataDir,
 	}
 
-	testServer := server.NewServer(cfg)
+	testServer := newTestServer(t, cfg)
 
 	// Create two test users
 	user1, _ := models.NewUser("user1", "UTC")
 	user2, _ := models.NewUser("user2", "UTC")
+	user1.PasswordHash, _ = auth.HashPassword(testPassword)
+	user2.PasswordHash, _ = auth.HashPassword(testPassword)
 
 	testServer.Storage.UserStorage.Save(user1)
 	testServer.Storage.UserStorage.Save(user2)
@@ -209,7 +214,7 @@ func TestOwnershipVerification(t *testing.T) {
 	var perfectDayID string
 
 	// Login user1
-	loginReq := map[string]string{"username": "user1"}
+	loginReq := map[string]string{"username": "user1", "password": testPassword}
 	reqBody, _ := json.Marshal(loginReq)
 	req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -225,7 +230,7 @@ func TestOwnershipVerification(t *testing.T) {
 	}
 
 	// Login user2
-	loginReq = map[string]string{"username": "user2"}
+	loginReq = map[string]string{"username": "user2", "password": testPassword}
 	reqBody, _ = json.Marshal(loginReq)
 	req = httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -251,7 +256,7 @@ func TestOwnershipVerification(t *testing.T) {
 	reqBody, _ = json.Marshal(perfectDayReq)
 	req = httptest.NewRequest("POST", "/api/v1/perfect-days", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "session_id", Value: user1Session})
+	addSession(req, user1Session)
 	w = httptest.NewRecorder()
 	testServer.ServeHTTP(w, req)
 
@@ -272,7 +277,7 @@ func TestOwnershipVerification(t *testing.T) {
 		reqBody, _ := json.Marshal(updateReq)
 		req := httptest.NewRequest("PUT", "/api/v1/perfect-days/"+perfectDayID, bytes.NewBuffer(reqBody))
 		req.Header.Set("Content-Type", "application/json")
-		req.AddCookie(&http.Cookie{Name: "session_id", Value: user2Session})
+		addSession(req, user2Session)
 		w := httptest.NewRecorder()
 
 		testServer.ServeHTTP(w, req)
@@ -285,7 +290,7 @@ func TestOwnershipVerification(t *testing.T) {
 	// Test: User2 tries to delete user1's perfect day (should fail)
 	t.Run("cross_user_delete_forbidden", func(t *testing.T) {
 		req := httptest.NewRequest("DELETE", "/api/v1/perfect-days/"+perfectDayID, nil)
-		req.AddCookie(&http.Cookie{Name: "session_id", Value: user2Session})
+		addSession(req, user2Session)
 		w := httptest.NewRecorder()
 
 		testServer.ServeHTTP(w, req)
@@ -294,4 +299,250 @@ func TestOwnershipVerification(t *testing.T) {
 			t.Errorf("Expected status %d, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
 		}
 	})
-}
\ No newline at end of file
+}
+func TestPasswordAuthentication(t *testing.T) {
+	srv := setupTestServer(t)
+
+	send := func(method, path string, body interface{}, sessionID string) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			addSession(req, sessionID)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	errorCode := func(w *httptest.ResponseRecorder) string {
+		var response map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		code, _ := response["error"]["code"].(string)
+		return code
+	}
+
+	t.Run("signup", func(t *testing.T) {
+		w := send("POST", "/api/v1/auth/signup", map[string]string{"username": "newbie", "password": testPassword, "timezone": "Asia/Tokyo"}, "")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		if strings.Contains(w.Body.String(), "password") {
+			t.Error("The response must not contain the password hash")
+		}
+
+		w = send("POST", "/api/v1/auth/signup", map[string]string{"username": "newbie", "password": testPassword}, "")
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for a taken username, got %d", http.StatusConflict, w.Code)
+		}
+
+		w = send("POST", "/api/v1/auth/signup", map[string]string{"username": "shorty", "password": "short"}, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for a short password, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("login_checks_password", func(t *testing.T) {
+		w := send("POST", "/api/v1/auth/login", map[string]string{"username": "newbie", "password": "wrong-password"}, "")
+		if w.Code != http.StatusUnauthorized || errorCode(w) != "INVALID_CREDENTIALS" {
+			t.Errorf("Expected 401 INVALID_CREDENTIALS, got %d %s", w.Code, errorCode(w))
+		}
+
+		w = send("POST", "/api/v1/auth/login", map[string]string{"username": "nobody", "password": testPassword}, "")
+		if w.Code != http.StatusUnauthorized || errorCode(w) != "INVALID_CREDENTIALS" {
+			t.Errorf("Expected unknown users to look like a wrong password, got %d %s", w.Code, errorCode(w))
+		}
+
+		if session := loginUser(srv, "newbie"); session == "" {
+			t.Error("Login with the right password should succeed")
+		}
+	})
+
+	t.Run("legacy_user_cannot_be_claimed_over_api", func(t *testing.T) {
+		legacy, _ := models.NewUser("legacy", "UTC")
+		srv.Storage.UserStorage.Save(legacy)
+
+		w := send("POST", "/api/v1/auth/login", map[string]string{"username": "legacy"}, "")
+		if w.Code != http.StatusForbidden || errorCode(w) != "PASSWORD_SETUP_REQUIRED" {
+			t.Fatalf("Expected 403 PASSWORD_SETUP_REQUIRED, got %d %s", w.Code, errorCode(w))
+		}
+
+		// Only the local CLI sets initial passwords
+		w = send("POST", "/api/v1/auth/login", map[string]string{"username": "legacy", "new_password": testPassword}, "")
+		if w.Code != http.StatusForbidden || errorCode(w) != "PASSWORD_SETUP_REQUIRED" {
+			t.Errorf("Expected new_password to be ignored, got %d %s", w.Code, errorCode(w))
+		}
+		if user, _ := srv.Storage.UserStorage.Load("legacy"); user.HasPassword() {
+			t.Fatal("Expected the account to still have no password")
+		}
+
+		// Attempts count towards the lockout like wrong passwords
+		for i := 0; i < auth.DefaultMaxLoginFailures; i++ {
+			w = send("POST", "/api/v1/auth/login", map[string]string{"username": "legacy"}, "")
+		}
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected the account to be locked, got %d %s", w.Code, errorCode(w))
+		}
+	})
+
+	t.Run("change_password", func(t *testing.T) {
+		session := loginUser(srv, "newbie")
+		otherSession := loginUser(srv, "newbie")
+
+		w := send("PUT", "/api/v1/auth/password", map[string]string{"current_password": "wrong-password", "new_password": "brand-new-password"}, session)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d for a wrong current password, got %d", http.StatusUnauthorized, w.Code)
+		}
+
+		w = send("PUT", "/api/v1/auth/password", map[string]string{"current_password": testPassword, "new_password": "brand-new-password"}, session)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		if _, err := srv.AuthService.ValidateSession(session); err != nil {
+			t.Error("The session that changed the password should stay logged in")
+		}
+		if _, err := srv.AuthService.ValidateSession(otherSession); err == nil {
+			t.Error("Other sessions should be logged out")
+		}
+		if loginUser(srv, "newbie") != "" {
+			t.Error("The old password should no longer work")
+		}
+	})
+}
+
+func TestSessionSurvivesRestart(t *testing.T) {
+	cfg := &config.Config{DataDir: t.TempDir()}
+
+	srv := newTestServer(t, cfg)
+	createTestUser(srv, "alice")
+	session := loginUser(srv, "alice")
+	if session == "" {
+		t.Fatal("Login failed")
+	}
+
+	restarted := newTestServer(t, cfg)
+
+	req := httptest.NewRequest("GET", "/api/v1/auth/me", nil)
+	addSession(req, session)
+	w := httptest.NewRecorder()
+	restarted.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the session to survive a restart, got %d: %s", w.Code, w.Body.String())
+	}
+	renewed := false
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "session_id" && cookie.Value == session && cookie.MaxAge == int(auth.SessionTTL.Seconds()) {
+			renewed = true
+		}
+	}
+	if !renewed {
+		t.Error("Authenticated requests should renew the session cookie")
+	}
+
+	stored, err := restarted.Storage.SessionStorage.ListByUser("alice")
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("Expected one stored session, got %d (%v)", len(stored), err)
+	}
+	if stored[0].ID == session {
+		t.Error("The session token must not be stored as is")
+	}
+}
+
+func TestSessionManagement(t *testing.T) {
+	srv := setupTestServer(t)
+	createTestUser(srv, "alice")
+	createTestUser(srv, "bob")
+
+	request := func(method, path, sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if sessionID != "" {
+			addSession(req, sessionID)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	listSessions := func(sessionID string) []map[string]interface{} {
+		w := request("GET", "/api/v1/auth/sessions", sessionID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d listing sessions, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data
+	}
+
+	t.Run("logout", func(t *testing.T) {
+		session := loginUser(srv, "alice")
+
+		w := request("POST", "/api/v1/auth/logout", session)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+		}
+		if request("GET", "/api/v1/auth/me", session).Code != http.StatusUnauthorized {
+			t.Error("The session should be logged out")
+		}
+	})
+
+	t.Run("list_and_revoke", func(t *testing.T) {
+		laptop := loginUser(srv, "alice")
+		phone := loginUser(srv, "alice")
+		bobSession := loginUser(srv, "bob")
+
+		sessions := listSessions(laptop)
+		if len(sessions) != 2 {
+			t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+		}
+		var phoneID string
+		for _, session := range sessions {
+			if session["current"] == true {
+				continue
+			}
+			phoneID, _ = session["id"].(string)
+		}
+		if phoneID == "" || strings.Contains(fmt.Sprint(sessions), laptop) {
+			t.Fatal("Expected the other session to be listed by ID, without tokens")
+		}
+
+		if w := request("DELETE", "/api/v1/auth/sessions/"+phoneID, bobSession); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d revoking another user's session, got %d", http.StatusNotFound, w.Code)
+		}
+
+		if w := request("DELETE", "/api/v1/auth/sessions/"+phoneID, laptop); w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+		}
+		if request("GET", "/api/v1/auth/me", phone).Code != http.StatusUnauthorized {
+			t.Error("The revoked session should be logged out")
+		}
+		if request("GET", "/api/v1/auth/me", laptop).Code != http.StatusOK {
+			t.Error("The revoking session should stay logged in")
+		}
+
+		if w := request("DELETE", "/api/v1/auth/sessions/"+phoneID, laptop); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for a revoked session, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("logout_everywhere", func(t *testing.T) {
+		first := loginUser(srv, "alice")
+		second := loginUser(srv, "alice")
+		bobSession := loginUser(srv, "bob")
+
+		w := request("DELETE", "/api/v1/auth/sessions", first)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		for _, session := range []string{first, second} {
+			if request("GET", "/api/v1/auth/me", session).Code != http.StatusUnauthorized {
+				t.Error("Every session of the user should be logged out")
+			}
+		}
+		if request("GET", "/api/v1/auth/me", bobSession).Code != http.StatusOK {
+			t.Error("Other users should stay logged in")
+		}
+	})
+}
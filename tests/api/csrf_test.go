@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"perfect-day/internal/api/cookies"
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/config"
+	"strings"
+	"testing"
+)
+
+func TestCSRFProtection(t *testing.T) {
+	srv := setupTestServer(t)
+	createTestUser(srv, "alice")
+	session := loginUser(srv, "alice")
+
+	send := func(method, path string, body interface{}, csrfToken, bearer string) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		} else {
+			req.AddCookie(&http.Cookie{Name: "session_id", Value: session})
+		}
+		if csrfToken != "" {
+			req.Header.Set("X-CSRF-Token", csrfToken)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("rejects_missing_token", func(t *testing.T) {
+		w := send("POST", "/api/v1/auth/tokens", map[string]interface{}{"name": "script"}, "", "")
+		if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "CSRF_TOKEN_INVALID") {
+			t.Errorf("Expected 403 CSRF_TOKEN_INVALID, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("rejects_wrong_token", func(t *testing.T) {
+		other := loginUser(srv, "alice")
+		w := send("POST", "/api/v1/auth/tokens", map[string]interface{}{"name": "script"}, auth.CSRFToken(other), "")
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d with another session's token, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("safe_methods_need_no_token", func(t *testing.T) {
+		if w := send("GET", "/api/v1/auth/me", nil, "", ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	var apiToken string
+	t.Run("accepts_session_token", func(t *testing.T) {
+		w := send("POST", "/api/v1/auth/tokens", map[string]interface{}{"name": "script", "scopes": []string{"perfect_days:write"}}, auth.CSRFToken(session), "")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data struct {
+				Token string `json:"token"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		apiToken = response.Data.Token
+	})
+
+	t.Run("bearer_requests_are_exempt", func(t *testing.T) {
+		if apiToken == "" {
+			t.Skip("no API token")
+		}
+		w := send("DELETE", "/api/v1/perfect-days/missing", nil, "", apiToken)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d without a CSRF token, got %d: %s", http.StatusNotFound, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("login_returns_token", func(t *testing.T) {
+		reqBody, _ := json.Marshal(map[string]string{"username": "alice", "password": testPassword})
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		var sessionID, csrfCookie string
+		for _, cookie := range w.Result().Cookies() {
+			switch cookie.Name {
+			case cookies.Session:
+				sessionID = cookie.Value
+				if !cookie.HttpOnly {
+					t.Error("Expected the session cookie to be HttpOnly")
+				}
+			case cookies.CSRF:
+				csrfCookie = cookie.Value
+				if cookie.HttpOnly {
+					t.Error("Expected the CSRF cookie to be readable by scripts")
+				}
+			}
+		}
+		if csrfCookie == "" || !auth.CheckCSRFToken(sessionID, csrfCookie) {
+			t.Errorf("Expected a CSRF cookie matching the session, got %q", csrfCookie)
+		}
+		if !strings.Contains(w.Body.String(), `"csrf_token":"`+csrfCookie+`"`) {
+			t.Errorf("Expected the CSRF token in the body, got %s", w.Body.String())
+		}
+	})
+}
+
+func TestCookieAttributes(t *testing.T) {
+	srv := newTestServer(t, &config.Config{
+		DataDir:        t.TempDir(),
+		CookieSecure:   true,
+		CookieSameSite: "strict",
+	})
+	createTestUser(srv, "alice")
+
+	reqBody, _ := json.Marshal(map[string]string{"username": "alice", "password": testPassword})
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	for _, cookie := range w.Result().Cookies() {
+		if !cookie.Secure || cookie.SameSite != http.SameSiteStrictMode {
+			t.Errorf("Expected cookie %s to be Secure and SameSite=Strict, got %v", cookie.Name, cookie)
+		}
+	}
+
+	if _, err := cookies.NewPolicy(false, "none"); err == nil {
+		t.Error("Expected SameSite=None without Secure to be rejected")
+	}
+	if _, err := cookies.NewPolicy(true, "sideways"); err == nil {
+		t.Error("Expected an unknown SameSite mode to be rejected")
+	}
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	srv := newTestServer(t, &config.Config{
+		DataDir:        t.TempDir(),
+		AllowedOrigins: []string{"https://app.example.com"},
+	})
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("OPTIONS", "/api/v1/perfect-days", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("allowed_origin", func(t *testing.T) {
+		w := preflight("https://app.example.com")
+		if w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+			t.Errorf("Expected the origin to be allowed with credentials, got %v", w.Header())
+		}
+		if !strings.Contains(w.Header().Get("Access-Control-Allow-Headers"), "X-CSRF-Token") {
+			t.Errorf("Expected X-CSRF-Token to be an allowed header, got %q", w.Header().Get("Access-Control-Allow-Headers"))
+		}
+	})
+
+	t.Run("other_origin", func(t *testing.T) {
+		w := preflight("https://evil.example.com")
+		if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+			t.Errorf("Expected no CORS headers for another origin, got %v", w.Header())
+		}
+	})
+
+	t.Run("wildcard_has_no_credentials", func(t *testing.T) {
+		srv := newTestServer(t, &config.Config{DataDir: t.TempDir(), AllowedOrigins: []string{"*"}})
+		req := httptest.NewRequest("GET", "/api/v1/health", nil)
+		req.Header.Set("Origin", "https://anywhere.example.com")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+			t.Errorf("Expected a wildcard origin without credentials, got %v", w.Header())
+		}
+	})
+}
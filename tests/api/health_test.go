@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"perfect-day/internal/api/server"
 	"perfect-day/pkg/config"
 	"testing"
 )
@@ -16,7 +15,7 @@ func TestHealthEndpoint(t *testing.T) {
 	}
 
 	// Create server
-	srv := server.NewServer(cfg)
+	srv := newTestServer(t, cfg)
 
 	// Create test request
 	req, err := http.NewRequest("GET", "/api/v1/health", nil)
@@ -78,7 +77,7 @@ func TestVersionEndpoint(t *testing.T) {
 		DataDir: "/tmp/perfect-day-test",
 	}
 
-	srv := server.NewServer(cfg)
+	srv := newTestServer(t, cfg)
 
 	req, err := http.NewRequest("GET", "/api/v1/version", nil)
 	if err != nil {
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"perfect-day/pkg/config"
+	"strings"
+	"testing"
+)
+
+func TestOIDCLogin(t *testing.T) {
+	provider := newMockOIDCProvider(t, "perfect-day", "client-secret")
+	srv := newTestServer(t, &config.Config{
+		DataDir:          t.TempDir(),
+		OIDCIssuer:       provider.URL,
+		OIDCClientID:     "perfect-day",
+		OIDCClientSecret: "client-secret",
+		OIDCRedirectURL:  "http://localhost:8080/api/v1/auth/oidc/callback",
+		AuthRateLimit:    "off",
+	})
+	createTestUser(srv, "alice")
+
+	providerClient := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// startLogin runs the login up to the provider's redirect back to the
+	// API and returns the callback URL and the state cookie.
+	startLogin := func() (*url.URL, *http.Cookie) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/auth/oidc/login", nil))
+		if w.Code != http.StatusFound {
+			t.Fatalf("Expected status %d from login, got %d: %s", http.StatusFound, w.Code, w.Body.String())
+		}
+		var stateCookie *http.Cookie
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == "oidc_state" {
+				stateCookie = cookie
+			}
+		}
+		if stateCookie == nil {
+			t.Fatal("Expected a state cookie from login")
+		}
+
+		resp, err := providerClient.Get(w.Header().Get("Location"))
+		if err != nil {
+			t.Fatalf("Failed to reach the provider: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("Expected the provider to redirect back, got %d", resp.StatusCode)
+		}
+		callback, _ := url.Parse(resp.Header.Get("Location"))
+		return callback, stateCookie
+	}
+	callback := func(callbackURL *url.URL, stateCookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", callbackURL.RequestURI(), nil)
+		if stateCookie != nil {
+			req.AddCookie(stateCookie)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	login := func() *httptest.ResponseRecorder {
+		return callback(startLogin())
+	}
+	loggedInAs := func(w *httptest.ResponseRecorder) string {
+		var response struct {
+			Data struct {
+				User struct {
+					Username    string `json:"username"`
+					DisplayName string `json:"display_name"`
+				} `json:"user"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data.User.Username
+	}
+
+	t.Run("login_url_uses_pkce", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/auth/oidc/login", nil))
+		location, _ := url.Parse(w.Header().Get("Location"))
+		query := location.Query()
+		if !strings.HasPrefix(location.String(), provider.URL+"/authorize") {
+			t.Errorf("Expected a redirect to the provider, got %s", location)
+		}
+		if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+			t.Errorf("Expected an S256 code challenge, got %v", query)
+		}
+		if query.Get("state") == "" || query.Get("nonce") == "" || !strings.Contains(query.Get("scope"), "openid") {
+			t.Errorf("Expected state, nonce and the openid scope, got %v", query)
+		}
+	})
+
+	t.Run("first_login_creates_user", func(t *testing.T) {
+		provider.setIdentity(mockIdentity{Subject: "sub-1", PreferredUsername: "carol", Email: "carol@example.com", Name: "Carol C"})
+
+		w := login()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if loggedInAs(w) != "carol" {
+			t.Errorf("Expected a new user carol, got %s", w.Body.String())
+		}
+
+		var sessionCookie string
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == "session_id" {
+				sessionCookie = cookie.Value
+			}
+		}
+		req := httptest.NewRequest("GET", "/api/v1/auth/me", nil)
+		addSession(req, sessionCookie)
+		me := httptest.NewRecorder()
+		srv.ServeHTTP(me, req)
+		if me.Code != http.StatusOK || !strings.Contains(me.Body.String(), "Carol C") {
+			t.Errorf("Expected the session to authenticate carol, got %d: %s", me.Code, me.Body.String())
+		}
+	})
+
+	t.Run("next_login_reuses_user", func(t *testing.T) {
+		provider.setIdentity(mockIdentity{Subject: "sub-1", PreferredUsername: "carol-renamed"})
+
+		w := login()
+		if w.Code != http.StatusOK || loggedInAs(w) != "carol" {
+			t.Errorf("Expected carol to log in again, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("existing_local_user_is_not_taken_over", func(t *testing.T) {
+		provider.setIdentity(mockIdentity{Subject: "sub-2", PreferredUsername: "alice"})
+
+		w := login()
+		if w.Code != http.StatusOK || loggedInAs(w) != "alice-2" {
+			t.Errorf("Expected a separate user alice-2, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("sso_user_has_no_password_login", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(`{"username":"carol","password":"","new_password":"taken-over-now"}`))
+		req.Header.Set("Content-Type", "application/json")
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d claiming an SSO account with a password, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("state_must_match_cookie", func(t *testing.T) {
+		provider.setIdentity(mockIdentity{Subject: "sub-1"})
+		callbackURL, _ := startLogin()
+
+		if w := callback(callbackURL, nil); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "INVALID_STATE") {
+			t.Errorf("Expected 400 INVALID_STATE without the state cookie, got %d", w.Code)
+		}
+	})
+
+	t.Run("state_is_used_once", func(t *testing.T) {
+		provider.setIdentity(mockIdentity{Subject: "sub-1"})
+		callbackURL, stateCookie := startLogin()
+
+		if w := callback(callbackURL, stateCookie); w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w := callback(callbackURL, stateCookie); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d replaying a callback, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("provider_error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/auth/oidc/callback?error=access_denied", nil))
+		if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "access_denied") {
+			t.Errorf("Expected 401 with the provider error, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("rejects_invalid_id_tokens", func(t *testing.T) {
+		provider.setIdentity(mockIdentity{Subject: "sub-1"})
+		otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+		tests := []struct {
+			name   string
+			tamper func(claims map[string]interface{})
+			key    *rsa.PrivateKey
+		}{
+			{"wrong_audience", func(claims map[string]interface{}) { claims["aud"] = "another-client" }, nil},
+			{"wrong_issuer", func(claims map[string]interface{}) { claims["iss"] = "https://evil.example.com" }, nil},
+			{"wrong_nonce", func(claims map[string]interface{}) { claims["nonce"] = "replayed" }, nil},
+			{"expired", func(claims map[string]interface{}) { claims["exp"] = 1000 }, nil},
+			{"wrong_signature", nil, otherKey},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				provider.mu.Lock()
+				provider.tamper, provider.signingKey = tt.tamper, tt.key
+				provider.mu.Unlock()
+				defer func() {
+					provider.mu.Lock()
+					provider.tamper, provider.signingKey = nil, nil
+					provider.mu.Unlock()
+				}()
+
+				w := login()
+				if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "OIDC_LOGIN_FAILED") {
+					t.Errorf("Expected 401 OIDC_LOGIN_FAILED, got %d: %s", w.Code, w.Body.String())
+				}
+			})
+		}
+	})
+}
+
+func TestOIDCNotConfigured(t *testing.T) {
+	srv := setupTestServer(t)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/auth/oidc/login", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d without OIDC configured, got %d", http.StatusNotFound, w.Code)
+	}
+}
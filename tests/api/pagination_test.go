@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"perfect-day/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCursorPagination(t *testing.T) {
+	srv := setupTestServer(t)
+	createTestUser(srv, "testuser")
+
+	// Equal creation times, so only the ID orders them
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		pd, _ := models.NewPerfectDay(fmt.Sprintf("page-%d", i), fmt.Sprintf("Day %d", i), "", "testuser", "2025-01-15")
+		pd.CreatedAt = created
+		srv.Storage.PerfectDayStorage.Save(pd)
+	}
+
+	type page struct {
+		PerfectDays []models.PerfectDay `json:"perfect_days"`
+		Pagination  struct {
+			Total   int    `json:"total"`
+			HasMore bool   `json:"has_more"`
+			Next    string `json:"next"`
+			Prev    string `json:"prev"`
+		} `json:"pagination"`
+		// GET /users/:username/perfect-days has these at the top level
+		Total int    `json:"total"`
+		Next  string `json:"next"`
+		Prev  string `json:"prev"`
+	}
+	get := func(url string) (*httptest.ResponseRecorder, page, string) {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		var response struct {
+			Data  page `json:"data"`
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response.Data, response.Error.Code
+	}
+	ids := func(p page) string {
+		var ids []string
+		for _, pd := range p.PerfectDays {
+			ids = append(ids, pd.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	t.Run("follow_links", func(t *testing.T) {
+		var seen []string
+		url := "/api/v1/perfect-days?sort=created_at&order=asc&limit=2"
+		for url != "" {
+			rr, p, _ := get(url)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, url, rr.Code, rr.Body.String())
+			}
+			if p.Pagination.HasMore != (p.Pagination.Next != "") {
+				t.Errorf("Expected has_more to match the next link, got %+v", p.Pagination)
+			}
+			seen = append(seen, ids(p))
+			url = p.Pagination.Next
+		}
+		if got := strings.Join(seen, "|"); got != "page-1,page-2|page-3,page-4|page-5" {
+			t.Errorf("Expected every perfect day once in ID order, got %s", got)
+		}
+	})
+
+	t.Run("prev_link", func(t *testing.T) {
+		_, first, _ := get("/api/v1/perfect-days?sort=created_at&order=asc&limit=2")
+		_, second, _ := get(first.Pagination.Next)
+		if first.Pagination.Prev != "" || second.Pagination.Prev == "" {
+			t.Fatalf("Expected a prev link on the second page only, got %q and %q", first.Pagination.Prev, second.Pagination.Prev)
+		}
+		if _, p, _ := get(second.Pagination.Prev); ids(p) != "page-1,page-2" {
+			t.Errorf("Expected the prev link to lead back to the first page, got %s", ids(p))
+		}
+	})
+
+	t.Run("user_perfect_days", func(t *testing.T) {
+		rr, p, _ := get("/api/v1/users/testuser/perfect-days?limit=3")
+		if rr.Code != http.StatusOK || ids(p) != "page-5,page-4,page-3" || p.Total != 5 || p.Next == "" {
+			t.Fatalf("Expected the newest three by ID with a next link, got %d %s: %s", rr.Code, ids(p), rr.Body.String())
+		}
+		if _, p, _ := get(p.Next); ids(p) != "page-2,page-1" || p.Next != "" || p.Prev == "" {
+			t.Errorf("Expected the last two with only a prev link, got %s", ids(p))
+		}
+	})
+
+	t.Run("invalid_parameters", func(t *testing.T) {
+		_, first, _ := get("/api/v1/perfect-days?limit=2")
+		tests := []struct {
+			url  string
+			code string
+		}{
+			{"/api/v1/perfect-days?limit=101", "VALIDATION_ERROR"},
+			{"/api/v1/perfect-days?limit=0", "VALIDATION_ERROR"},
+			{"/api/v1/perfect-days?offset=-1", "VALIDATION_ERROR"},
+			{"/api/v1/perfect-days?cursor=forged", "INVALID_CURSOR"},
+			{strings.Replace(first.Pagination.Next, "limit=2", "limit=2&sort=title", 1), "INVALID_CURSOR"},
+			{"/api/v1/users/testuser/perfect-days?limit=1000", "VALIDATION_ERROR"},
+		}
+		for _, tt := range tests {
+			if rr, _, code := get(tt.url); rr.Code != http.StatusBadRequest || code != tt.code {
+				t.Errorf("Expected 400 %s for %s, got %d %s", tt.code, tt.url, rr.Code, code)
+			}
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"perfect-day/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPatchPerfectDay(t *testing.T) {
+	srv := setupTestServer(t)
+	createTestUser(srv, "testuser")
+	createTestUser(srv, "otheruser")
+	sessionID := loginUser(srv, "testuser")
+
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	pd, _ := models.NewPerfectDay("test-id-patch", "Tpyo Title", "A day", "testuser", "2025-01-15")
+	pd.CreatedAt = created
+	for _, activity := range []struct{ id, name, area string }{{"act-1", "Coffee", "Shibuya"}, {"act-2", "Walk", "Harajuku"}} {
+		a, _ := models.NewActivity(activity.id, activity.name, *models.NewCustomTextLocation(activity.name+" place", activity.area), "09:00", 60, "", "")
+		a.CreatedAt = created
+		pd.AddActivity(*a)
+	}
+	srv.Storage.PerfectDayStorage.Save(pd)
+
+	send := func(contentType, body, sessionID string) (*httptest.ResponseRecorder, models.PerfectDay, string) {
+		req := httptest.NewRequest("PATCH", "/api/v1/perfect-days/test-id-patch", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		addSession(req, sessionID)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+
+		var response struct {
+			Data  models.PerfectDay `json:"data"`
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response.Data, response.Error.Code
+	}
+
+	t.Run("merge_patch", func(t *testing.T) {
+		rr, patched, _ := send("application/merge-patch+json", `{"title": "Typo Title", "description": null}`, sessionID)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		if patched.Title != "Typo Title" || patched.Description != "" || patched.Date != "2025-01-15" {
+			t.Errorf("Expected only the title and description to change, got %+v", patched)
+		}
+		if len(patched.Activities) != 2 || patched.Activities[0].ID != "act-1" || patched.Activities[1].ID != "act-2" {
+			t.Fatalf("Expected the activities to be untouched, got %+v", patched.Activities)
+		}
+		if !patched.CreatedAt.Equal(created) || !patched.Activities[0].CreatedAt.Equal(created) {
+			t.Errorf("Expected creation times to be kept, got %v and %v", patched.CreatedAt, patched.Activities[0].CreatedAt)
+		}
+		if patched.UpdatedBy != "testuser" || patched.Revision != 2 || rr.Header().Get("ETag") == "" {
+			t.Errorf("Expected a new revision by testuser with an ETag, got %+v", patched)
+		}
+	})
+
+	t.Run("json_patch", func(t *testing.T) {
+		patch := `[
+			{"op": "test", "path": "/activities/1/id", "value": "act-2"},
+			{"op": "replace", "path": "/activities/1/location/area", "value": "Ebisu"},
+			{"op": "remove", "path": "/activities/0"},
+			{"op": "add", "path": "/activities/-", "value": {"name": "Dinner", "location": {"type": "custom_text", "name": "Izakaya", "area": "Ebisu"}, "start_time": "19:00", "duration_minutes": 90}}
+		]`
+		rr, patched, _ := send("application/json-patch+json", patch, sessionID)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		if len(patched.Activities) != 2 || patched.Activities[0].ID != "act-2" || !patched.Activities[0].CreatedAt.Equal(created) {
+			t.Fatalf("Expected the walk to keep its ID and creation time, got %+v", patched.Activities)
+		}
+		if dinner := patched.Activities[1]; dinner.ID == "" || dinner.ID == "act-1" || dinner.Name != "Dinner" {
+			t.Errorf("Expected a new activity with a new ID, got %+v", dinner)
+		}
+		if len(patched.Areas) != 1 || patched.Areas[0] != "Ebisu" {
+			t.Errorf("Expected areas to follow the activities, got %v", patched.Areas)
+		}
+	})
+
+	t.Run("failed_test_operation", func(t *testing.T) {
+		rr, _, code := send("application/json-patch+json", `[{"op": "test", "path": "/title", "value": "Old"}, {"op": "replace", "path": "/title", "value": "New"}]`, sessionID)
+		if rr.Code != http.StatusConflict || code != "PATCH_TEST_FAILED" {
+			t.Errorf("Expected 409 PATCH_TEST_FAILED, got %d %s", rr.Code, code)
+		}
+	})
+
+	t.Run("read_only_fields", func(t *testing.T) {
+		rr, _, code := send("application/merge-patch+json", `{"username": "otheruser", "areas": ["Nowhere"]}`, sessionID)
+		if rr.Code != http.StatusBadRequest || code != "VALIDATION_ERROR" || !strings.Contains(rr.Body.String(), `"field":"username"`) {
+			t.Errorf("Expected read-only fields to be rejected, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("invalid_result", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			patch string
+		}{
+			{"missing_title", `{"title": null}`},
+			{"bad_date", `{"date": "tomorrow"}`},
+			{"unknown_field", `{"mood": "happy"}`},
+			{"bad_activity", `[{"op": "replace", "path": "/activities/0/start_time", "value": "25:99"}]`},
+			{"bad_path", `[{"op": "remove", "path": "/activities/9"}]`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				contentType := "application/merge-patch+json"
+				if strings.HasPrefix(tt.patch, "[") {
+					contentType = "application/json-patch+json"
+				}
+				if rr, _, _ := send(contentType, tt.patch, sessionID); rr.Code != http.StatusBadRequest {
+					t.Errorf("Expected status %d, got %d: %s", http.StatusBadRequest, rr.Code, rr.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("unsupported_media_type", func(t *testing.T) {
+		rr, _, _ := send("text/plain", `title=New`, sessionID)
+		if rr.Code != http.StatusUnsupportedMediaType || rr.Header().Get("Accept-Patch") == "" {
+			t.Errorf("Expected 415 with Accept-Patch, got %d", rr.Code)
+		}
+	})
+
+	t.Run("stale_if_match", func(t *testing.T) {
+		req := httptest.NewRequest("PATCH", "/api/v1/perfect-days/test-id-patch", strings.NewReader(`{"title": "Stale"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", `"test-id-patch-1"`)
+		addSession(req, sessionID)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		if rr.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status %d, got %d", http.StatusPreconditionFailed, rr.Code)
+		}
+	})
+
+	t.Run("not_owner", func(t *testing.T) {
+		if rr, _, _ := send("application/merge-patch+json", `{"title": "Mine now"}`, loginUser(srv, "otheruser")); rr.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+}
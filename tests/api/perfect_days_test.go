@@ -7,28 +7,42 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"perfect-day/internal/api/server"
+	"perfect-day/pkg/auth"
 	"perfect-day/pkg/config"
 	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
 	"strings"
 	"testing"
 	"time"
 )
 
-func setupTestServer() *server.Server {
+func setupTestServer(t *testing.T) *server.Server {
 	cfg := &config.Config{
 		DataDir: "/tmp/perfect-day-test-" + time.Now().Format("20060102150405") + "-" + fmt.Sprintf("%d", time.Now().UnixNano()),
 	}
-	return server.NewServer(cfg)
+	return newTestServer(t, cfg)
 }
 
+// newTestServer creates a server that is closed when the test finishes.
+func newTestServer(t *testing.T, cfg *config.Config) *server.Server {
+	srv := server.NewServer(cfg)
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+// testPassword is the password of every user made by createTestUser.
+const testPassword = "correct-horse-battery"
+
 func createTestUser(srv *server.Server, username string) {
 	user, _ := models.NewUser(username, "Asia/Tokyo")
+	user.PasswordHash, _ = auth.HashPassword(testPassword)
 	srv.Storage.UserStorage.Save(user)
 }
 
 func loginUser(srv *server.Server, username string) string {
 	loginReq := map[string]string{
 		"username": username,
+		"password": testPassword,
 	}
 	reqBody, _ := json.Marshal(loginReq)
 	req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(reqBody))
@@ -46,8 +60,15 @@ func loginUser(srv *server.Server, username string) string {
 	return ""
 }
 
+// addSession authenticates req with the session cookie and the session's
+// CSRF token, as the browser client does.
+func addSession(req *http.Request, sessionID string) {
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	req.Header.Set("X-CSRF-Token", auth.CSRFToken(sessionID))
+}
+
 func TestCreatePerfectDay(t *testing.T) {
-	srv := setupTestServer()
+	srv := setupTestServer(t)
 	createTestUser(srv, "testuser")
 	sessionID := loginUser(srv, "testuser")
 
@@ -107,7 +128,7 @@ func TestCreatePerfectDay(t *testing.T) {
 			body, _ := json.Marshal(tt.requestBody)
 			req := httptest.NewRequest("POST", "/api/v1/perfect-days", bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
-			req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+			addSession(req, sessionID)
 
 			rr := httptest.NewRecorder()
 			srv.ServeHTTP(rr, req)
@@ -144,7 +165,7 @@ func TestCreatePerfectDay(t *testing.T) {
 }
 
 func TestGetPerfectDay(t *testing.T) {
-	srv := setupTestServer()
+	srv := setupTestServer(t)
 	createTestUser(srv, "testuser")
 
 	// Create a test perfect day
@@ -211,7 +232,7 @@ func TestGetPerfectDay(t *testing.T) {
 }
 
 func TestUpdatePerfectDay(t *testing.T) {
-	srv := setupTestServer()
+	srv := setupTestServer(t)
 	createTestUser(srv, "testuser")
 	sessionID := loginUser(srv, "testuser")
 
@@ -255,7 +276,7 @@ func TestUpdatePerfectDay(t *testing.T) {
 			body, _ := json.Marshal(tt.requestBody)
 			req := httptest.NewRequest("PUT", "/api/v1/perfect-days/"+tt.perfectDayID, bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
-			req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+			addSession(req, sessionID)
 
 			rr := httptest.NewRecorder()
 			srv.ServeHTTP(rr, req)
@@ -289,7 +310,7 @@ func TestUpdatePerfectDay(t *testing.T) {
 }
 
 func TestDeletePerfectDay(t *testing.T) {
-	srv := setupTestServer()
+	srv := setupTestServer(t)
 	createTestUser(srv, "testuser")
 	sessionID := loginUser(srv, "testuser")
 
@@ -319,7 +340,7 @@ func TestDeletePerfectDay(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest("DELETE", "/api/v1/perfect-days/"+tt.perfectDayID, nil)
-			req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+			addSession(req, sessionID)
 
 			rr := httptest.NewRecorder()
 			srv.ServeHTTP(rr, req)
@@ -342,7 +363,7 @@ func TestDeletePerfectDay(t *testing.T) {
 }
 
 func TestListPerfectDays(t *testing.T) {
-	srv := setupTestServer()
+	srv := setupTestServer(t)
 	createTestUser(srv, "testuser")
 	createTestUser(srv, "otheruser")
 
@@ -423,4 +444,303 @@ func TestListPerfectDays(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestPerfectDayConditionalRequests(t *testing.T) {
+	srv := setupTestServer(t)
+	createTestUser(srv, "testuser")
+	sessionID := loginUser(srv, "testuser")
+
+	pd, _ := models.NewPerfectDay("test-id-etag", "Original Title", "", "testuser", "2025-01-15")
+	srv.Storage.PerfectDayStorage.Save(pd)
+
+	send := func(method, ifMatch, ifNoneMatch string, body map[string]interface{}) *httptest.ResponseRecorder {
+		var reqBody *bytes.Buffer
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reqBody = bytes.NewBuffer(data)
+		} else {
+			reqBody = bytes.NewBuffer(nil)
+		}
+		req := httptest.NewRequest(method, "/api/v1/perfect-days/test-id-etag", reqBody)
+		req.Header.Set("Content-Type", "application/json")
+		addSession(req, sessionID)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send("GET", "", "", nil)
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Expected 200 with an ETag, got %d and %q", rr.Code, etag)
+	}
+
+	if rr := send("GET", "", etag, nil); rr.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for matching If-None-Match, got %d", rr.Code)
+	}
+
+	update := map[string]interface{}{
+		"title":      "Updated Title",
+		"date":       "2025-01-16",
+		"activities": []map[string]interface{}{},
+	}
+	rr = send("PUT", etag, "", update)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for matching If-Match, got %d. Response: %s", rr.Code, rr.Body.String())
+	}
+	newETag := rr.Header().Get("ETag")
+	if newETag == "" || newETag == etag {
+		t.Errorf("Expected a new ETag after update, got %q", newETag)
+	}
+
+	// A client still holding the old ETag must not overwrite the update.
+	rr = send("PUT", etag, "", update)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for stale If-Match, got %d", rr.Code)
+	}
+	if rr := send("DELETE", etag, "", nil); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for stale If-Match on delete, got %d", rr.Code)
+	}
+	if rr := send("GET", "", etag, nil); rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 for stale If-None-Match, got %d", rr.Code)
+	}
+
+	if rr := send("DELETE", newETag, "", nil); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 for matching If-Match on delete, got %d", rr.Code)
+	}
+}
+
+func TestPerfectDayRevisions(t *testing.T) {
+	srv := setupTestServer(t)
+	createTestUser(srv, "testuser")
+	sessionID := loginUser(srv, "testuser")
+
+	pd, _ := models.NewPerfectDay("test-id-history", "Original Title", "", "testuser", "2025-01-15")
+	srv.Storage.PerfectDayStorage.Save(pd)
+
+	send := func(method, path string, body map[string]interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, "/api/v1/perfect-days/test-id-history"+path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		addSession(req, sessionID)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response
+	}
+
+	rr, _ := send("PUT", "", map[string]interface{}{
+		"title":      "Accidental Edit",
+		"date":       "2025-01-15",
+		"activities": []map[string]interface{}{},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Update failed: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr, response := send("GET", "/revisions", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 listing revisions, got %d", rr.Code)
+	}
+	revisions := response["data"].(map[string]interface{})["revisions"].([]interface{})
+	if len(revisions) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(revisions))
+	}
+	latest := revisions[1].(map[string]interface{})
+	if latest["author"] != "testuser" {
+		t.Errorf("Expected author testuser, got %v", latest["author"])
+	}
+	changes := latest["changes"].([]interface{})
+	if len(changes) != 1 || changes[0].(map[string]interface{})["field"] != "title" {
+		t.Errorf("Expected a single title change, got %v", changes)
+	}
+
+	rr, response = send("GET", "/revisions/1", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for revision 1, got %d", rr.Code)
+	}
+	snapshot := response["data"].(map[string]interface{})["perfect_day"].(map[string]interface{})
+	if snapshot["title"] != "Original Title" {
+		t.Errorf("Expected original title in revision 1, got %v", snapshot["title"])
+	}
+
+	if rr, _ := send("GET", "/revisions/7", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown revision, got %d", rr.Code)
+	}
+	if rr, _ := send("GET", "/revisions/latest", nil); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid revision, got %d", rr.Code)
+	}
+
+	rr, response = send("POST", "/revisions/1/restore", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for restore, got %d %s", rr.Code, rr.Body.String())
+	}
+	restored := response["data"].(map[string]interface{})
+	if restored["title"] != "Original Title" || restored["revision"] != float64(3) {
+		t.Errorf("Expected original title at revision 3, got %v at %v", restored["title"], restored["revision"])
+	}
+
+	createTestUser(srv, "otheruser")
+	otherSession := loginUser(srv, "otheruser")
+	req := httptest.NewRequest("POST", "/api/v1/perfect-days/test-id-history/revisions/2/restore", nil)
+	addSession(req, otherSession)
+	other := httptest.NewRecorder()
+	srv.ServeHTTP(other, req)
+	if other.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 restoring another user's perfect day, got %d", other.Code)
+	}
+}
+
+func TestPerfectDayTrash(t *testing.T) {
+	srv := setupTestServer(t)
+	createTestUser(srv, "testuser")
+	sessionID := loginUser(srv, "testuser")
+
+	pd, _ := models.NewPerfectDay("test-id-trash", "Trash Day", "", "testuser", "2025-01-15")
+	srv.Storage.PerfectDayStorage.Save(pd)
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/perfect-days/test-id-trash"+path, nil)
+		addSession(req, sessionID)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := send("POST", "/restore"); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 restoring a live perfect day, got %d", rr.Code)
+	}
+
+	if rr := send("DELETE", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 for soft delete, got %d", rr.Code)
+	}
+	deleted, _ := srv.Storage.PerfectDayStorage.LoadByID("test-id-trash")
+	if deleted.DeletedAt == nil {
+		t.Error("Soft delete should record DeletedAt")
+	}
+
+	rr := send("POST", "/restore")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for restore, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := send("GET", ""); rr.Code != http.StatusOK {
+		t.Errorf("Restored perfect day should be visible, got %d", rr.Code)
+	}
+
+	send("DELETE", "")
+	if rr := send("DELETE", "?hard=true"); rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 for hard delete of a trashed perfect day, got %d", rr.Code)
+	}
+	if _, err := srv.Storage.PerfectDayStorage.LoadByID("test-id-trash"); err == nil {
+		t.Error("Hard deleted perfect day should be gone from storage")
+	}
+	if rr := send("POST", "/restore"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 restoring a purged perfect day, got %d", rr.Code)
+	}
+}
+
+func TestTrashRetentionSweep(t *testing.T) {
+	store := storage.NewMemoryStorage()
+
+	expired, _ := models.NewPerfectDay("expired-id", "Expired", "", "testuser", "2025-01-15")
+	expired.SoftDelete()
+	longAgo := time.Now().Add(-45 * 24 * time.Hour)
+	expired.DeletedAt = &longAgo
+	fresh, _ := models.NewPerfectDay("fresh-id", "Fresh", "", "testuser", "2025-01-16")
+	fresh.SoftDelete()
+	store.PerfectDayStorage.Save(expired)
+	store.PerfectDayStorage.Save(fresh)
+
+	srv := server.NewServerWithStorage(&config.Config{TrashRetention: "30d"}, store)
+	defer srv.Close()
+
+	if _, err := store.PerfectDayStorage.LoadByID("expired-id"); err == nil {
+		t.Error("Expired trash should be purged when the server starts")
+	}
+	if _, err := store.PerfectDayStorage.LoadByID("fresh-id"); err != nil {
+		t.Errorf("Recently deleted perfect day should be kept: %v", err)
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	srv := server.NewServer(&config.Config{DataDir: t.TempDir(), StorageBackend: storage.BackendSQLite, TrashRetention: "30d"})
+	if err := srv.Close(); err != nil {
+		t.Fatalf("Failed to close server: %v", err)
+	}
+	if err := srv.Close(); err != nil {
+		t.Errorf("Closing twice should do nothing, got %v", err)
+	}
+	if _, err := srv.Storage.UserStorage.List(); err == nil {
+		t.Error("Expected the server to close the storage it opened")
+	}
+
+	store, err := storage.NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open sqlite storage: %v", err)
+	}
+	defer store.Close()
+	shared := server.NewServerWithStorage(&config.Config{TrashRetention: "30d"}, store)
+	shared.Close()
+	if _, err := store.UserStorage.List(); err != nil {
+		t.Errorf("Expected storage passed in to stay open, got %v", err)
+	}
+}
+
+// editingPerfectDays saves an edit of every perfect day right after it is
+// loaded, as a client racing the request would.
+type editingPerfectDays struct {
+	storage.PerfectDayRepository
+}
+
+func (e editingPerfectDays) LoadByID(id string) (*models.PerfectDay, error) {
+	perfectDay, err := e.PerfectDayRepository.LoadByID(id)
+	if err == nil {
+		edited := *perfectDay
+		edited.Title = "Edited Meanwhile"
+		e.PerfectDayRepository.Save(&edited)
+	}
+	return perfectDay, err
+}
+
+func TestHardDeleteRevisionConflict(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	perfectDays := store.PerfectDayStorage
+	store.PerfectDayStorage = editingPerfectDays{perfectDays}
+	srv := server.NewServerWithStorage(&config.Config{}, store)
+	defer srv.Close()
+
+	createTestUser(srv, "testuser")
+	sessionID := loginUser(srv, "testuser")
+	pd, _ := models.NewPerfectDay("race-id", "Original Title", "", "testuser", "2025-01-15")
+	perfectDays.Save(pd)
+
+	for ifMatch, status := range map[string]int{`"race-id-1"`: http.StatusPreconditionFailed, "": http.StatusConflict} {
+		req := httptest.NewRequest("DELETE", "/api/v1/perfect-days/race-id?hard=true", nil)
+		addSession(req, sessionID)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+
+		if rr.Code != status {
+			t.Errorf("Expected %d for a hard delete racing an edit with If-Match %q, got %d: %s", status, ifMatch, rr.Code, rr.Body.String())
+		}
+	}
+
+	stored, err := perfectDays.LoadByID("race-id")
+	if err != nil || stored.Title != "Edited Meanwhile" {
+		t.Fatalf("Expected the concurrent edit to be kept, got %+v (%v)", stored, err)
+	}
+	if revisions, _ := perfectDays.ListRevisions("race-id"); len(revisions) != 3 {
+		t.Errorf("Expected the revision history to be kept, got %d revisions", len(revisions))
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"perfect-day/internal/api/server"
 	"perfect-day/pkg/config"
 	"perfect-day/pkg/models"
 	"strings"
@@ -22,7 +21,7 @@ func TestPlacesSearch(t *testing.T) {
 		DataDir: testDataDir,
 	}
 
-	testServer := server.NewServer(cfg)
+	testServer := newTestServer(t, cfg)
 
 	// Test 1: Search places without query (should fail)
 	t.Run("search_places_no_query", func(t *testing.T) {
@@ -98,7 +97,7 @@ func TestAreas(t *testing.T) {
 		DataDir: testDataDir,
 	}
 
-	testServer := server.NewServer(cfg)
+	testServer := newTestServer(t, cfg)
 
 	// Create test data for areas endpoint
 	user, _ := models.NewUser("testuser", "UTC")
@@ -167,7 +166,7 @@ func TestAreas(t *testing.T) {
 			DataDir: emptyDataDir,
 		}
 
-		emptyServer := server.NewServer(emptyCfg)
+		emptyServer := newTestServer(t, emptyCfg)
 
 		req := httptest.NewRequest("GET", "/api/v1/areas", nil)
 		w := httptest.NewRecorder()
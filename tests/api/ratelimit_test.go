@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"perfect-day/pkg/config"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRateLimiting(t *testing.T) {
+	srv := newTestServer(t, &config.Config{
+		DataDir:         t.TempDir(),
+		RateLimit:       "3/h",
+		PlacesRateLimit: "2/h",
+	})
+	createTestUser(srv, "alice")
+	createTestUser(srv, "bob")
+	alice := loginUser(srv, "alice")
+	bob := loginUser(srv, "bob")
+
+	get := func(path, sessionID, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if sessionID != "" {
+			addSession(req, sessionID)
+		}
+		if remoteAddr != "" {
+			req.RemoteAddr = remoteAddr
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("limits_per_user", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			w := get("/api/v1/auth/me", alice, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected request %d to pass, got %d", i+1, w.Code)
+			}
+			if w.Header().Get("RateLimit-Limit") != "3" || w.Header().Get("RateLimit-Remaining") != strconv.Itoa(2-i) {
+				t.Errorf("Unexpected rate limit headers %v", w.Header())
+			}
+		}
+
+		w := get("/api/v1/auth/me", alice, "")
+		if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "RATE_LIMITED") {
+			t.Fatalf("Expected 429 RATE_LIMITED, got %d: %s", w.Code, w.Body.String())
+		}
+		retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		if err != nil || retryAfter < 1 || retryAfter > 1200 {
+			t.Errorf("Expected Retry-After of at most one token's time, got %q", w.Header().Get("Retry-After"))
+		}
+
+		if w := get("/api/v1/auth/me", bob, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected other users to have their own limit, got %d", w.Code)
+		}
+	})
+
+	t.Run("limits_anonymous_clients_per_ip", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			get("/api/v1/perfect-days", "", "203.0.113.1:1234")
+		}
+		if w := get("/api/v1/perfect-days", "", "203.0.113.1:5678"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+		if w := get("/api/v1/perfect-days", "", "203.0.113.2:1234"); w.Code != http.StatusOK {
+			t.Errorf("Expected other addresses to have their own limit, got %d", w.Code)
+		}
+	})
+
+	t.Run("forwarded_for_is_ignored_without_trusted_proxies", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/perfect-days", nil)
+		req.RemoteAddr = "203.0.113.1:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.7")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected X-Forwarded-For not to escape the limit, got %d", w.Code)
+		}
+	})
+
+	t.Run("places_search_has_own_policy", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if w := get("/api/v1/places/search?q=cafe", "", "203.0.113.3:1234"); w.Code == http.StatusTooManyRequests {
+				t.Fatalf("Expected search %d to pass the limit", i+1)
+			}
+		}
+		w := get("/api/v1/places/search?q=cafe", "", "203.0.113.3:1234")
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("Expected the places limit of 2, got %d %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("health_is_not_limited", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			if w := get("/api/v1/health", "", ""); w.Code != http.StatusOK {
+				t.Fatalf("Expected health checks to pass, got %d", w.Code)
+			}
+		}
+	})
+}
+
+func TestLoginLockout(t *testing.T) {
+	srv := setupTestServer(t)
+	createTestUser(srv, "alice")
+	createTestUser(srv, "bob")
+
+	login := func(username, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"username": username, "password": password})
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 5; i++ {
+		if w := login("alice", "wrong-password"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected failed login %d to be refused with 401, got %d", i+1, w.Code)
+		}
+	}
+
+	w := login("alice", testPassword)
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "LOGIN_LOCKED") {
+		t.Fatalf("Expected 429 LOGIN_LOCKED even with the right password, got %d: %s", w.Code, w.Body.String())
+	}
+	if retryAfter, _ := strconv.Atoi(w.Header().Get("Retry-After")); retryAfter < 1 || retryAfter > 30 {
+		t.Errorf("Expected Retry-After within the first lockout, got %q", w.Header().Get("Retry-After"))
+	}
+
+	if w := login("bob", testPassword); w.Code != http.StatusOK {
+		t.Errorf("Expected other accounts to stay open, got %d", w.Code)
+	}
+}
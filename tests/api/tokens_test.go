@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"perfect-day/pkg/config"
+	"strings"
+	"testing"
+)
+
+func TestPersonalAPITokens(t *testing.T) {
+	srv := newTestServer(t, &config.Config{
+		DataDir:    t.TempDir(),
+		AdminUsers: []string{"alice"},
+	})
+	createTestUser(srv, "alice")
+	session := loginUser(srv, "alice")
+
+	// send makes a request with the session cookie, or with credential as a
+	// bearer token when it is one.
+	send := func(method, path string, body interface{}, credential string) *httptest.ResponseRecorder {
+		var reqBody *bytes.Buffer
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reqBody = bytes.NewBuffer(data)
+		} else {
+			reqBody = &bytes.Buffer{}
+		}
+		req := httptest.NewRequest(method, path, reqBody)
+		req.Header.Set("Content-Type", "application/json")
+		if strings.HasPrefix(credential, "pdt_") {
+			req.Header.Set("Authorization", "Bearer "+credential)
+		} else if credential != "" {
+			addSession(req, credential)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	createToken := func(scopes ...string) (string, string) {
+		w := send("POST", "/api/v1/auth/tokens", map[string]interface{}{"name": "script", "scopes": scopes}, session)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d creating a token, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data struct {
+				ID    string `json:"id"`
+				Token string `json:"token"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data.ID, response.Data.Token
+	}
+	newPerfectDay := map[string]interface{}{"title": "Scripted Day", "date": "2024-01-01", "activities": []interface{}{}}
+
+	t.Run("create_validates_scopes", func(t *testing.T) {
+		w := send("POST", "/api/v1/auth/tokens", map[string]interface{}{"name": "bad", "scopes": []string{"everything"}}, session)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an unknown scope, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("read_token", func(t *testing.T) {
+		_, token := createToken("perfect_days:read")
+
+		if w := send("GET", "/api/v1/auth/me", nil, token); w.Code != http.StatusOK {
+			t.Errorf("Expected the token to authenticate, got %d", w.Code)
+		}
+		if w := send("GET", "/api/v1/perfect-days", nil, token); w.Code != http.StatusOK {
+			t.Errorf("Expected the token to read perfect days, got %d", w.Code)
+		}
+		w := send("POST", "/api/v1/perfect-days", newPerfectDay, token)
+		if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "INSUFFICIENT_SCOPE") {
+			t.Errorf("Expected 403 INSUFFICIENT_SCOPE creating a perfect day, got %d", w.Code)
+		}
+		if w := send("GET", "/api/v1/admin/backup", nil, token); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d without the admin scope, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("write_token", func(t *testing.T) {
+		_, token := createToken("perfect_days:write")
+
+		if w := send("POST", "/api/v1/perfect-days", newPerfectDay, token); w.Code != http.StatusCreated {
+			t.Errorf("Expected the token to create a perfect day, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("admin_token", func(t *testing.T) {
+		_, token := createToken("admin")
+
+		if w := send("GET", "/api/v1/admin/backup", nil, token); w.Code != http.StatusOK {
+			t.Errorf("Expected the admin token to download a backup, got %d", w.Code)
+		}
+	})
+
+	t.Run("tokens_cannot_manage_credentials", func(t *testing.T) {
+		_, token := createToken("perfect_days:write", "admin")
+
+		if w := send("POST", "/api/v1/auth/tokens", map[string]interface{}{"name": "more", "scopes": []string{"admin"}}, token); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d creating a token with a token, got %d", http.StatusForbidden, w.Code)
+		}
+		if w := send("PUT", "/api/v1/auth/password", map[string]string{"current_password": testPassword, "new_password": "hijacked-password"}, token); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d changing the password with a token, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("list_and_revoke", func(t *testing.T) {
+		id, token := createToken("perfect_days:read")
+		send("GET", "/api/v1/auth/me", nil, token)
+
+		w := send("GET", "/api/v1/auth/tokens", nil, session)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if strings.Contains(w.Body.String(), token) {
+			t.Error("Listing must not reveal token secrets")
+		}
+		if !strings.Contains(w.Body.String(), id) {
+			t.Error("Expected the token to be listed")
+		}
+
+		if w := send("DELETE", "/api/v1/auth/tokens/"+id, nil, session); w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+		}
+		if w := send("GET", "/api/v1/auth/me", nil, token); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected the revoked token to be rejected, got %d", w.Code)
+		}
+		if w := send("GET", "/api/v1/perfect-days", nil, token); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected public routes to reject a revoked token, got %d", w.Code)
+		}
+		if w := send("GET", "/api/v1/perfect-days", nil, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected anonymous reads to keep working, got %d", w.Code)
+		}
+	})
+}
@@ -1,13 +1,14 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"perfect-day/internal/api/server"
 	"perfect-day/pkg/config"
 	"perfect-day/pkg/models"
+	"strings"
 	"testing"
 	"time"
 )
@@ -21,7 +22,7 @@ func TestUserProfile(t *testing.T) {
 		DataDir: testDataDir,
 	}
 
-	testServer := server.NewServer(cfg)
+	testServer := newTestServer(t, cfg)
 
 	// Create test users
 	user1, _ := models.NewUser("alice", "UTC")
@@ -75,7 +76,7 @@ func TestUserPerfectDays(t *testing.T) {
 		DataDir: testDataDir,
 	}
 
-	testServer := server.NewServer(cfg)
+	testServer := newTestServer(t, cfg)
 
 	// Create test user and perfect days
 	user, _ := models.NewUser("alice", "UTC")
@@ -154,4 +155,126 @@ func TestUserPerfectDays(t *testing.T) {
 			t.Errorf("Expected 1 perfect day in page, got %d", len(perfectDays))
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestUserManagement(t *testing.T) {
+	srv := setupTestServer(t)
+
+	send := func(method, path string, body interface{}, sessionID string) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			addSession(req, sessionID)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("signup", func(t *testing.T) {
+		w := send("POST", "/api/v1/users", map[string]string{"username": "carol", "password": testPassword, "timezone": "Europe/Paris"}, "")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		w = send("POST", "/api/v1/users", map[string]string{"username": "carol", "password": testPassword}, "")
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for a duplicate, got %d", http.StatusConflict, w.Code)
+		}
+
+		w = send("POST", "/api/v1/users", map[string]string{"username": "a!", "password": testPassword}, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an invalid username, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		w = send("POST", "/api/v1/users", map[string]string{"username": models.DeletedUsername, "password": testPassword}, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for the reserved username, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("update_profile", func(t *testing.T) {
+		createTestUser(srv, "dave")
+		createTestUser(srv, "erin")
+		session := loginUser(srv, "dave")
+
+		w := send("PATCH", "/api/v1/users/dave", map[string]string{"display_name": "Dave D.", "bio": "Coffee first"}, session)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		user, _ := srv.Storage.UserStorage.Load("dave")
+		if user.DisplayName != "Dave D." || user.Bio != "Coffee first" || user.Timezone != "Asia/Tokyo" {
+			t.Errorf("Expected only the given fields to change, got %+v", user)
+		}
+
+		w = send("GET", "/api/v1/users/dave", nil, "")
+		if !strings.Contains(w.Body.String(), "Coffee first") {
+			t.Error("Expected the bio in the public profile")
+		}
+
+		w = send("PATCH", "/api/v1/users/dave", map[string]string{"timezone": "Mars/Olympus"}, session)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an invalid timezone, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		w = send("PATCH", "/api/v1/users/erin", map[string]string{"bio": "hacked"}, session)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d changing another user, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("delete_cascades", func(t *testing.T) {
+		createTestUser(srv, "frank")
+		session := loginUser(srv, "frank")
+		pd, _ := models.NewPerfectDay("frank001-0000-0000-0000-000000000001", "Frank's Day", "", "frank", "2024-01-01")
+		srv.Storage.PerfectDayStorage.Save(pd)
+
+		if w := send("DELETE", "/api/v1/users/dave", nil, session); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d deleting another user, got %d", http.StatusForbidden, w.Code)
+		}
+
+		if w := send("DELETE", "/api/v1/users/frank", nil, session); w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+		}
+		if srv.Storage.UserStorage.Exists("frank") {
+			t.Error("The user should be deleted")
+		}
+		if _, err := srv.Storage.PerfectDayStorage.LoadByID(pd.ID); err == nil {
+			t.Error("The user's perfect days should be deleted")
+		}
+		if w := send("GET", "/api/v1/auth/me", nil, session); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected the deleted user to be logged out, got %d", w.Code)
+		}
+	})
+
+	t.Run("delete_anonymizes", func(t *testing.T) {
+		createTestUser(srv, "grace")
+		session := loginUser(srv, "grace")
+		pd, _ := models.NewPerfectDay("grace001-0000-0000-0000-000000000001", "Grace's Day", "", "grace", "2024-01-01")
+		srv.Storage.PerfectDayStorage.Save(pd)
+
+		if w := send("DELETE", "/api/v1/users/grace?perfect_days=keep", nil, session); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an unknown mode, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		if w := send("DELETE", "/api/v1/users/grace?perfect_days=anonymize", nil, session); w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+		}
+
+		kept, err := srv.Storage.PerfectDayStorage.LoadByID(pd.ID)
+		if err != nil {
+			t.Fatalf("Expected the perfect day to be kept: %v", err)
+		}
+		if kept.Username != models.DeletedUsername || kept.UpdatedBy != models.DeletedUsername {
+			t.Errorf("Expected the perfect day to be anonymized, got owner %s", kept.Username)
+		}
+		revisions, _ := srv.Storage.PerfectDayStorage.ListRevisions(pd.ID)
+		for _, revision := range revisions {
+			if revision.PerfectDay.Username == "grace" {
+				t.Error("History should not keep the former owner")
+			}
+		}
+	})
+}
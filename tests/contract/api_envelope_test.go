@@ -0,0 +1,178 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"perfect-day/internal/api/server"
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/config"
+	"perfect-day/pkg/models"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envelope is the shape every JSON response of the API must have.
+type envelope struct {
+	Data  json.RawMessage `json:"data"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"details"`
+	} `json:"error"`
+	Meta struct {
+		Timestamp string `json:"timestamp"`
+		Version   string `json:"version"`
+	} `json:"meta"`
+}
+
+var routeParam = regexp.MustCompile(`:[a-z_]+`)
+
+// nonEnvelopeRoutes respond with something else than an envelope when they
+// succeed.
+var nonEnvelopeRoutes = map[string]bool{
+	"/api/v1/admin/backup": true,
+	"/api/v1/openapi.json": true,
+	"/api/v1/docs":         true,
+}
+
+// newTestServer creates a server that is closed when the test finishes.
+func newTestServer(t *testing.T, cfg *config.Config) *server.Server {
+	srv := server.NewServer(cfg)
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func createUser(srv *server.Server, username string) {
+	user, _ := models.NewUser(username, "UTC")
+	user.PasswordHash, _ = auth.HashPassword("contract-password")
+	srv.Storage.UserStorage.Save(user)
+}
+
+// login returns the session ID of a new session of username.
+func login(t *testing.T, srv *server.Server, username string) string {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(`{"username":"`+username+`","password":"contract-password"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "session_id" {
+			return cookie.Value
+		}
+	}
+	t.Fatalf("Failed to log in: %d %s", w.Code, w.Body.String())
+	return ""
+}
+
+func TestAPIEnvelopeOnEveryRoute(t *testing.T) {
+	srv := newTestServer(t, &config.Config{
+		DataDir:       t.TempDir(),
+		AdminUsers:    []string{"admin"},
+		RateLimit:     "off",
+		AuthRateLimit: "off",
+	})
+	createUser(srv, "admin")
+
+	for _, route := range srv.Routes() {
+		path := routeParam.ReplaceAllString(route.Path, "missing")
+		for _, authenticated := range []bool{false, true} {
+			name := route.Method + " " + route.Path
+			if authenticated {
+				name += " as admin"
+			}
+			t.Run(name, func(t *testing.T) {
+				req := httptest.NewRequest(route.Method, path, bytes.NewBufferString("{}"))
+				req.Header.Set("Content-Type", "application/json")
+				if authenticated {
+					// Every route gets a fresh session, as some log out
+					session := login(t, srv, "admin")
+					req.AddCookie(&http.Cookie{Name: "session_id", Value: session})
+					req.Header.Set("X-CSRF-Token", auth.CSRFToken(session))
+				}
+				w := httptest.NewRecorder()
+				srv.ServeHTTP(w, req)
+
+				switch {
+				case w.Code == http.StatusNoContent || w.Code == http.StatusNotModified:
+					if w.Body.Len() != 0 {
+						t.Errorf("Expected no body with status %d, got %s", w.Code, w.Body.String())
+					}
+					return
+				case w.Code == http.StatusOK && nonEnvelopeRoutes[route.Path]:
+					return
+				}
+
+				if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+					t.Fatalf("Expected a JSON response, got %q with status %d", w.Header().Get("Content-Type"), w.Code)
+				}
+				var body envelope
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatalf("Response is not JSON: %v", err)
+				}
+				if _, err := time.Parse(time.RFC3339, body.Meta.Timestamp); err != nil || body.Meta.Version == "" {
+					t.Errorf("Expected meta with timestamp and version, got %s", w.Body.String())
+				}
+
+				if w.Code >= 400 {
+					if body.Error == nil || body.Error.Code == "" || body.Error.Message == "" || body.Data != nil {
+						t.Errorf("Expected an error with code and message and no data for status %d, got %s", w.Code, w.Body.String())
+					}
+					for _, detail := range body.Error.Details {
+						if detail.Message == "" {
+							t.Errorf("Expected every error detail to have a message, got %s", w.Body.String())
+						}
+					}
+				} else if body.Error != nil || body.Data == nil {
+					t.Errorf("Expected data and no error for status %d, got %s", w.Code, w.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestAPIValidationDetails(t *testing.T) {
+	srv := newTestServer(t, &config.Config{DataDir: t.TempDir()})
+	createUser(srv, "alice")
+	session := login(t, srv, "alice")
+
+	send := func(data string) envelope {
+		req := httptest.NewRequest("POST", "/api/v1/perfect-days", strings.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: session})
+		req.Header.Set("X-CSRF-Token", auth.CSRFToken(session))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		var body envelope
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != http.StatusBadRequest || body.Error == nil || body.Error.Code != "VALIDATION_ERROR" {
+			t.Fatalf("Expected 400 VALIDATION_ERROR, got %d: %s", w.Code, w.Body.String())
+		}
+		return body
+	}
+
+	t.Run("missing_fields", func(t *testing.T) {
+		body := send(`{"description":"no title or date"}`)
+		fields := map[string]string{}
+		for _, detail := range body.Error.Details {
+			fields[detail.Field] = detail.Message
+		}
+		if len(fields) != 2 || fields["title"] != "is required" || fields["date"] != "is required" {
+			t.Errorf("Expected title and date to be reported as required, got %v", fields)
+		}
+	})
+
+	t.Run("wrong_type", func(t *testing.T) {
+		body := send(`{"title":5,"date":"2025-01-15"}`)
+		if len(body.Error.Details) != 1 || body.Error.Details[0].Field != "title" || body.Error.Details[0].Message != "must be a string" {
+			t.Errorf("Expected title to be reported as not a string, got %+v", body.Error.Details)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package contract
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"perfect-day/internal/api/handlers"
+	"perfect-day/internal/api/openapi"
+	"perfect-day/pkg/config"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPICoversRoutes fails when a route is registered without being
+// described in handlers.APIRoutes, or described without being registered.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	srv := newTestServer(t, &config.Config{
+		DataDir:          t.TempDir(),
+		OIDCIssuer:       "http://127.0.0.1:1",
+		OIDCClientID:     "perfect-day",
+		OIDCClientSecret: "secret",
+		OIDCRedirectURL:  "http://localhost/api/v1/auth/oidc/callback",
+	})
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to parse the OpenAPI document: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" || len(doc.Servers) != 1 || doc.Servers[0].URL != "/api/v1" {
+		t.Fatalf("Unexpected OpenAPI document header: %s %+v", doc.OpenAPI, doc.Servers)
+	}
+
+	// Operations gin routes by another path, such as custom methods
+	ginPaths := map[string][]string{}
+	for _, route := range handlers.APIRoutes {
+		if route.GinPath != "" {
+			key := route.Method + " " + route.GinPath
+			ginPaths[key] = append(ginPaths[key], route.Path)
+		}
+	}
+
+	registered := map[string]bool{}
+	for _, route := range srv.Routes() {
+		path, ok := strings.CutPrefix(route.Path, "/api/v1")
+		if !ok {
+			continue
+		}
+		paths, ok := ginPaths[route.Method+" "+path]
+		if !ok {
+			paths = []string{path}
+		}
+		for _, path := range paths {
+			operation := route.Method + " " + openapi.Path(path)
+			registered[operation] = true
+			if _, ok := doc.Paths[openapi.Path(path)][strings.ToLower(route.Method)]; !ok {
+				t.Errorf("Route %s is missing from the OpenAPI document", operation)
+			}
+		}
+	}
+	for _, operation := range doc.Operations() {
+		if !registered[operation] {
+			t.Errorf("OpenAPI document describes %s, which is not registered", operation)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	srv := newTestServer(t, &config.Config{DataDir: t.TempDir()})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/openapi.json", nil))
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to parse the OpenAPI document: %v", err)
+	}
+
+	// Every reference resolves
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	var check func(value interface{})
+	check = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				if name, ok := strings.CutPrefix(ref, "#/components/schemas/"); ok && schemas[name] == nil {
+					t.Errorf("Reference %s does not resolve", ref)
+				}
+			}
+			for _, child := range v {
+				check(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				check(child)
+			}
+		}
+	}
+	check(doc)
+
+	// Request types are described with their validation
+	request := schemas["CreatePerfectDayRequest"].(map[string]interface{})
+	required, _ := json.Marshal(request["required"])
+	if string(required) != `["title","date"]` {
+		t.Errorf("Expected title and date to be required, got %s", required)
+	}
+	activity := schemas["CreateActivityRequest"].(map[string]interface{})
+	if activity["properties"].(map[string]interface{})["location"].(map[string]interface{})["$ref"] != "#/components/schemas/CreateLocationRequest" {
+		t.Errorf("Expected the activity location to refer to CreateLocationRequest, got %v", activity["properties"])
+	}
+	for _, name := range []string{"PerfectDay", "SearchResult", "UserProfile", "LoginResponse", "AuditEvent", "Error"} {
+		if schemas[name] == nil {
+			t.Errorf("Expected a %s schema", name)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/docs", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), "openapi.json") {
+		t.Errorf("Expected the docs page, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,46 @@
+package contract
+
+import (
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"testing"
+)
+
+func TestStorageMigrateHelp(t *testing.T) {
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	result := helper.ExecuteCommand("storage", "migrate", "--help")
+	result.AssertExitCode(t, 0)
+	result.AssertStdoutContains(t, "--from")
+	result.AssertStdoutContains(t, "--to")
+}
+
+func TestStorageMigrateFilesToSQLite(t *testing.T) {
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	files := storage.NewStorage(helper.tempDir)
+	user, _ := models.NewUser("testuser", "UTC")
+	files.UserStorage.Save(user)
+	pd, _ := models.NewPerfectDay("12345678-aaaa-bbbb-cccc-1234567890ab", "Migrated Day", "", "testuser", "2024-01-01")
+	files.PerfectDayStorage.Save(pd)
+
+	result := helper.ExecuteCommand("storage", "migrate", "--from", "files", "--to", "sqlite")
+	result.AssertExitCode(t, 0)
+	result.AssertStdoutContains(t, "Copied 1 users, 1 perfect days with 1 revisions")
+
+	t.Setenv("PERFECT_DAY_STORAGE", "sqlite")
+	result = helper.ExecuteCommand("list", "--user", "testuser")
+	result.AssertExitCode(t, 0)
+	result.AssertStdoutContains(t, "Migrated Day")
+}
+
+func TestStorageMigrateSameBackend(t *testing.T) {
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	result := helper.ExecuteCommand("storage", "migrate", "--from", "files", "--to", "files")
+	result.AssertExitCode(t, 1)
+	result.AssertStdoutContains(t, "must differ")
+}
@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminCommands(t *testing.T) {
+	tempDir := t.TempDir()
+	buildBinary(t, tempDir)
+	binaryPath := filepath.Join(tempDir, "perfect-day")
+
+	store := storage.NewStorage(tempDir)
+	for _, username := range []string{"root", "alice"} {
+		user, _ := models.NewUser(username, "UTC")
+		store.UserStorage.Save(user)
+	}
+	trashed, _ := models.NewPerfectDay("admin001-0000-0000-0000-000000000001", "Trashed Trip", "", "alice", "2024-01-01")
+	trashed.SoftDelete()
+	live, _ := models.NewPerfectDay("admin002-0000-0000-0000-000000000002", "Live Trip", "", "alice", "2024-01-02")
+	store.PerfectDayStorage.Save(trashed)
+	store.PerfectDayStorage.Save(live)
+
+	runAdmin := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, append([]string{"admin"}, args...)...)
+		cmd.Env = append(os.Environ(), "PERFECT_DAY_DATA_DIR="+tempDir, "ADMIN_USERS=root")
+		cmd.Stdin = strings.NewReader("y\n")
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "current_user"), []byte("alice"), 0644)
+	output, err := runAdmin("users")
+	if err == nil || !strings.Contains(output, "require the admin role") {
+		t.Errorf("Expected admin commands to be refused to a user, got: %s", output)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "current_user"), []byte("root"), 0644)
+	output, err = runAdmin("set-role", "alice", "moderator")
+	if err != nil {
+		t.Fatalf("Set role failed: %v\n%s", err, output)
+	}
+	output, err = runAdmin("users")
+	if err != nil {
+		t.Fatalf("Users failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Found 2 users") || !strings.Contains(output, "moderator") {
+		t.Errorf("Expected both users with alice as a moderator, got: %s", output)
+	}
+
+	output, err = runAdmin("trash")
+	if err != nil || !strings.Contains(output, "Trashed Trip") {
+		t.Errorf("Expected alice's deleted perfect day in the trash, got: %v\n%s", err, output)
+	}
+
+	output, err = runAdmin("restore", "admin001")
+	if err != nil || !strings.Contains(output, "'Trashed Trip' of alice has been restored") {
+		t.Errorf("Expected the perfect day to be restored, got: %v\n%s", err, output)
+	}
+	output, err = runAdmin("purge", "admin002")
+	if err != nil || !strings.Contains(output, "permanently deleted") {
+		t.Errorf("Expected the perfect day to be purged, got: %v\n%s", err, output)
+	}
+
+	restored, _ := store.PerfectDayStorage.LoadByID(trashed.ID)
+	if restored == nil || restored.IsDeleted {
+		t.Error("Trashed Trip should be restored")
+	}
+	if _, err := store.PerfectDayStorage.LoadByID(live.ID); err == nil {
+		t.Error("Live Trip should have been purged")
+	}
+}
+
+func TestAuditCommand(t *testing.T) {
+	tempDir := t.TempDir()
+	buildBinary(t, tempDir)
+	binaryPath := filepath.Join(tempDir, "perfect-day")
+
+	store := storage.NewStorage(tempDir)
+	for _, username := range []string{"root", "alice"} {
+		user, _ := models.NewUser(username, "UTC")
+		store.UserStorage.Save(user)
+	}
+	now := time.Now().UTC()
+	store.AuditStorage.Append(&models.AuditEvent{ID: "e1", Time: now.Add(-48 * time.Hour), Actor: "alice", Action: models.AuditLoginFailure, IP: "192.0.2.7"})
+	store.AuditStorage.Append(&models.AuditEvent{ID: "e2", Time: now.Add(-time.Hour), Actor: "alice", Action: models.AuditPerfectDayDelete, Target: "perfect_day:pd-1", IP: "192.0.2.7"})
+	store.AuditStorage.Append(&models.AuditEvent{ID: "e3", Time: now, Actor: "root", Action: models.AuditLoginSuccess, IP: "127.0.0.1"})
+
+	runAudit := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, append([]string{"audit"}, args...)...)
+		cmd.Env = append(os.Environ(), "PERFECT_DAY_DATA_DIR="+tempDir, "ADMIN_USERS=root")
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "current_user"), []byte("alice"), 0644)
+	if output, err := runAudit(); err == nil || !strings.Contains(output, "require the admin role") {
+		t.Errorf("Expected the audit log to be refused to a user, got: %s", output)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "current_user"), []byte("root"), 0644)
+	output, err := runAudit("--actor", "alice", "--from", "1d")
+	if err != nil {
+		t.Fatalf("Audit failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "perfect_day.delete") || strings.Contains(output, "login.failure") || strings.Contains(output, "login.success") {
+		t.Errorf("Expected only alice's recent delete, got: %s", output)
+	}
+
+	output, err = runAudit("--action", "login.failure")
+	if err != nil || !strings.Contains(output, "192.0.2.7") || strings.Contains(output, "perfect_day.delete") {
+		t.Errorf("Expected only the failed login, got: %v\n%s", err, output)
+	}
+
+	if output, err := runAudit("--from", "someday"); err == nil {
+		t.Errorf("Expected an invalid --from to fail, got: %s", output)
+	}
+}
@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"testing"
+)
+
+func TestBackupCreateAndRestore(t *testing.T) {
+	tempDir := t.TempDir()
+	buildBinary(t, tempDir)
+	binaryPath := filepath.Join(tempDir, "perfect-day")
+	dataDir := filepath.Join(tempDir, "data")
+	archivePath := filepath.Join(tempDir, "backup.tar.gz")
+
+	store := storage.NewStorage(dataDir)
+	pd, _ := models.NewPerfectDay("backup01-0000-0000-0000-000000000001", "Before Backup", "", "alice", "2024-01-01")
+	store.PerfectDayStorage.Save(pd)
+
+	output, err := runCLIWithEnv(binaryPath, dataDir, "backup", "create", archivePath)
+	if err != nil {
+		t.Fatalf("Backup create failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Backed up") {
+		t.Errorf("Expected backup confirmation, got: %s", output)
+	}
+
+	pd.Title = "After Backup"
+	store.PerfectDayStorage.Save(pd)
+
+	cmd := exec.Command(binaryPath, "backup", "restore", archivePath)
+	cmd.Env = append(os.Environ(), "PERFECT_DAY_DATA_DIR="+dataDir)
+	cmd.Stdin = strings.NewReader("y\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Backup restore failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "The previous data was moved to") {
+		t.Errorf("Expected the previous data location, got: %s", out)
+	}
+
+	restored, err := store.PerfectDayStorage.LoadByID(pd.ID)
+	if err != nil {
+		t.Fatalf("Failed to load restored perfect day: %v", err)
+	}
+	if restored.Title != "Before Backup" {
+		t.Errorf("Expected 'Before Backup', got %q", restored.Title)
+	}
+
+	os.WriteFile(archivePath, []byte("not an archive"), 0644)
+	output, err = runCLIWithEnv(binaryPath, dataDir, "backup", "restore", archivePath)
+	if err == nil {
+		t.Errorf("Restoring a corrupt archive should fail, got: %s", output)
+	}
+}
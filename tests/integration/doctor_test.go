@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"testing"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	tempDir := t.TempDir()
+	buildBinary(t, tempDir)
+	binaryPath := filepath.Join(tempDir, "perfect-day")
+
+	store := storage.NewStorage(tempDir)
+	user, _ := models.NewUser("alice", "UTC")
+	store.UserStorage.Save(user)
+	pd, _ := models.NewPerfectDay("doctor01-0000-0000-0000-000000000001", "Fine Day", "", "alice", "2024-01-01")
+	store.PerfectDayStorage.Save(pd)
+
+	output, err := runCLIWithEnv(binaryPath, tempDir, "doctor")
+	if err != nil {
+		t.Fatalf("Doctor failed on a healthy store: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "No problems found") {
+		t.Errorf("Expected a clean report, got: %s", output)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "perfect-days", "alice", "broken.json"), []byte("{"), 0644)
+
+	output, err = runCLIWithEnv(binaryPath, tempDir, "doctor")
+	if err == nil {
+		t.Errorf("Doctor should exit with an error while problems remain, got: %s", output)
+	}
+	if !strings.Contains(output, "[unparseable] perfect-days/alice/broken.json") {
+		t.Errorf("Expected the unparseable file to be reported, got: %s", output)
+	}
+
+	output, err = runCLIWithEnv(binaryPath, tempDir, "doctor", "--fix")
+	if err != nil {
+		t.Fatalf("Doctor --fix failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "fixed: moved to quarantine") || !strings.Contains(output, "Quarantined files are in") {
+		t.Errorf("Expected the file to be quarantined, got: %s", output)
+	}
+
+	output, err = runCLIWithEnv(binaryPath, tempDir, "doctor")
+	if err != nil || !strings.Contains(output, "No problems found") {
+		t.Errorf("Expected a clean report after --fix, got: %v\n%s", err, output)
+	}
+}
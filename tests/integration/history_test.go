@@ -0,0 +1,58 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"testing"
+)
+
+func TestHistoryAndRevert(t *testing.T) {
+	tempDir := t.TempDir()
+	buildBinary(t, tempDir)
+	binaryPath := filepath.Join(tempDir, "perfect-day")
+
+	store := storage.NewStorage(tempDir)
+	pd, _ := models.NewPerfectDay("hist0001-0000-0000-0000-000000000001", "Morning Walk", "", "alice", "2024-01-01")
+	store.PerfectDayStorage.Save(pd)
+	pd.Title = "Oops"
+	pd.UpdatedBy = "alice"
+	store.PerfectDayStorage.Save(pd)
+
+	output, err := runCLIWithEnv(binaryPath, tempDir, "history", "hist0001")
+	if err != nil {
+		t.Fatalf("History failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Revision 2 (current) by alice") {
+		t.Errorf("Expected current revision line, got: %s", output)
+	}
+	if !strings.Contains(output, "~ title: Morning Walk -> Oops") {
+		t.Errorf("Expected title diff, got: %s", output)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "current_user"), []byte("alice"), 0644)
+
+	cmd := exec.Command(binaryPath, "revert", "hist0001", "1")
+	cmd.Env = append(os.Environ(), "PERFECT_DAY_DATA_DIR="+tempDir)
+	cmd.Stdin = strings.NewReader("y\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Revert failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "saved as revision 3") {
+		t.Errorf("Expected revert confirmation, got: %s", out)
+	}
+
+	reverted, _ := store.PerfectDayStorage.LoadByID(pd.ID)
+	if reverted.Title != "Morning Walk" || reverted.Revision != 3 {
+		t.Errorf("Expected Morning Walk at revision 3, got %s at %d", reverted.Title, reverted.Revision)
+	}
+
+	output, err = runCLIWithEnv(binaryPath, tempDir, "revert", "hist0001", "9")
+	if err == nil || !strings.Contains(output, "Revision 9") {
+		t.Errorf("Expected missing revision error, got: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"testing"
+)
+
+// runLogin runs the login command with the given lines on stdin.
+func runLogin(binaryPath, dataDir string, lines ...string) (string, error) {
+	cmd := exec.Command(binaryPath, "login")
+	cmd.Env = append(os.Environ(), "PERFECT_DAY_DATA_DIR="+dataDir)
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+func TestLoginWithPassword(t *testing.T) {
+	tempDir := t.TempDir()
+	buildBinary(t, tempDir)
+	binaryPath := filepath.Join(tempDir, "perfect-day")
+	dataDir := filepath.Join(tempDir, "data")
+
+	output, err := runLogin(binaryPath, dataDir, "alice", "UTC", "correct-horse", "correct-horse")
+	if err != nil {
+		t.Fatalf("Signup failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Your account has been created") {
+		t.Errorf("Expected account creation, got: %s", output)
+	}
+
+	user, _ := storage.NewStorage(dataDir).UserStorage.Load("alice")
+	if user == nil || !auth.CheckPassword(user.PasswordHash, "correct-horse") {
+		t.Fatal("Expected the password to be stored as a hash")
+	}
+
+	output, err = runLogin(binaryPath, dataDir, "alice", "wrong-horse")
+	if err == nil || !strings.Contains(output, "Invalid username or password") {
+		t.Errorf("Expected a wrong password to be rejected, got: %v\n%s", err, output)
+	}
+
+	output, err = runLogin(binaryPath, dataDir, "alice", "correct-horse")
+	if err != nil || !strings.Contains(output, "Welcome back, alice!") {
+		t.Errorf("Expected login to succeed, got: %v\n%s", err, output)
+	}
+}
+
+func TestLoginLegacyUserSetsPassword(t *testing.T) {
+	tempDir := t.TempDir()
+	buildBinary(t, tempDir)
+	binaryPath := filepath.Join(tempDir, "perfect-day")
+	dataDir := filepath.Join(tempDir, "data")
+
+	store := storage.NewStorage(dataDir)
+	legacy, _ := models.NewUser("legacy", "UTC")
+	store.UserStorage.Save(legacy)
+
+	output, err := runLogin(binaryPath, dataDir, "legacy", "first-password", "other-password")
+	if err == nil || !strings.Contains(output, "Passwords do not match") {
+		t.Errorf("Expected mismatched passwords to be rejected, got: %v\n%s", err, output)
+	}
+
+	output, err = runLogin(binaryPath, dataDir, "legacy", "first-password", "first-password")
+	if err != nil {
+		t.Fatalf("Setting the password failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "no password yet") {
+		t.Errorf("Expected to be asked for a password, got: %s", output)
+	}
+
+	user, _ := store.UserStorage.Load("legacy")
+	if !auth.CheckPassword(user.PasswordHash, "first-password") {
+		t.Error("Expected the new password to be stored")
+	}
+}
+
+func TestWhoamiAndLogout(t *testing.T) {
+	tempDir := t.TempDir()
+	buildBinary(t, tempDir)
+	binaryPath := filepath.Join(tempDir, "perfect-day")
+	dataDir := filepath.Join(tempDir, "data")
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Env = append(os.Environ(), "PERFECT_DAY_DATA_DIR="+dataDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	output, err := run("whoami")
+	if err == nil || !strings.Contains(output, "Not logged in") {
+		t.Errorf("Expected whoami to fail before login, got: %v\n%s", err, output)
+	}
+
+	if output, err := runLogin(binaryPath, dataDir, "alice", "Asia/Tokyo", "correct-horse", "correct-horse"); err != nil {
+		t.Fatalf("Signup failed: %v\n%s", err, output)
+	}
+
+	output, err = run("whoami")
+	if err != nil || !strings.Contains(output, "alice (Timezone: Asia/Tokyo)") {
+		t.Errorf("Expected whoami to show alice, got: %v\n%s", err, output)
+	}
+
+	output, err = run("logout")
+	if err != nil || !strings.Contains(output, "Logged out alice") {
+		t.Errorf("Expected logout to succeed, got: %v\n%s", err, output)
+	}
+
+	output, err = run("whoami")
+	if err == nil || !strings.Contains(output, "Not logged in") {
+		t.Errorf("Expected whoami to fail after logout, got: %v\n%s", err, output)
+	}
+
+	output, err = run("create")
+	if err == nil || !strings.Contains(output, "Please login first") {
+		t.Errorf("Expected commands to require login again, got: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,51 @@
+package integration
+
+import (
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"testing"
+)
+
+func TestShowByShortID(t *testing.T) {
+	tempDir := t.TempDir()
+	buildBinary(t, tempDir)
+	binaryPath := filepath.Join(tempDir, "perfect-day")
+
+	store := storage.NewStorage(tempDir)
+	pd1, _ := models.NewPerfectDay("abcd1234-0000-0000-0000-000000000001", "Morning Walk", "", "alice", "2024-01-01")
+	pd2, _ := models.NewPerfectDay("abcd1234-0000-0000-0000-000000000002", "Evening Jazz", "", "bob", "2024-01-02")
+	store.PerfectDayStorage.Save(pd1)
+	store.PerfectDayStorage.Save(pd2)
+
+	output, err := runCLIWithEnv(binaryPath, tempDir, "show", "abcd1234-0000-0000-0000-000000000002")
+	if err != nil {
+		t.Fatalf("Show by full ID failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Evening Jazz") {
+		t.Errorf("Expected Evening Jazz, got: %s", output)
+	}
+
+	output, err = runCLIWithEnv(binaryPath, tempDir, "show", "abcd1234")
+	if err == nil {
+		t.Error("Expected error for ambiguous short ID")
+	}
+	if !strings.Contains(output, "matches 2 perfect days") {
+		t.Errorf("Expected ambiguity message, got: %s", output)
+	}
+
+	output, err = runCLIWithEnv(binaryPath, tempDir, "show", "abcd1234-0000-0000-0000-0000000000")
+	if err == nil {
+		t.Error("Expected error for ambiguous longer prefix")
+	}
+
+	store.PerfectDayStorage.Delete("alice", pd1.ID, 0)
+	output, err = runCLIWithEnv(binaryPath, tempDir, "show", "abcd1234")
+	if err != nil {
+		t.Fatalf("Show by unique short ID failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Evening Jazz") {
+		t.Errorf("Expected Evening Jazz, got: %s", output)
+	}
+}
@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	tempDir := t.TempDir()
+	buildBinary(t, tempDir)
+	binaryPath := filepath.Join(tempDir, "perfect-day")
+	os.WriteFile(filepath.Join(tempDir, "current_user"), []byte("alice"), 0644)
+
+	store := storage.NewStorage(tempDir)
+	old, _ := models.NewPerfectDay("trash001-0000-0000-0000-000000000001", "Old Trip", "", "alice", "2024-01-01")
+	old.SoftDelete()
+	longAgo := time.Now().Add(-40 * 24 * time.Hour)
+	old.DeletedAt = &longAgo
+	recent, _ := models.NewPerfectDay("trash002-0000-0000-0000-000000000002", "Recent Trip", "", "alice", "2024-01-02")
+	recent.SoftDelete()
+	store.PerfectDayStorage.Save(old)
+	store.PerfectDayStorage.Save(recent)
+
+	output, err := runCLIWithEnv(binaryPath, tempDir, "trash", "list")
+	if err != nil {
+		t.Fatalf("Trash list failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Found 2 deleted perfect days") || !strings.Contains(output, "Old Trip") {
+		t.Errorf("Expected both deleted perfect days, got: %s", output)
+	}
+
+	cmd := exec.Command(binaryPath, "trash", "purge", "--older-than", "30d")
+	cmd.Env = append(os.Environ(), "PERFECT_DAY_DATA_DIR="+tempDir)
+	cmd.Stdin = strings.NewReader("y\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Trash purge failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "Purged 1 perfect days") {
+		t.Errorf("Expected one purged perfect day, got: %s", out)
+	}
+
+	output, err = runCLIWithEnv(binaryPath, tempDir, "restore", "trash002")
+	if err != nil {
+		t.Fatalf("Restore failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "'Recent Trip' has been restored") {
+		t.Errorf("Expected restore confirmation, got: %s", output)
+	}
+
+	if _, err := store.PerfectDayStorage.LoadByID(old.ID); err == nil {
+		t.Error("Old Trip should have been purged")
+	}
+	restored, _ := store.PerfectDayStorage.LoadByID(recent.ID)
+	if restored == nil || restored.IsDeleted {
+		t.Error("Recent Trip should be restored")
+	}
+
+	output, _ = runCLIWithEnv(binaryPath, tempDir, "trash", "list")
+	if !strings.Contains(output, "Trash is empty") {
+		t.Errorf("Expected empty trash, got: %s", output)
+	}
+}
@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"perfect-day/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestRepositoryAuditAppendAndQuery(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			start := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+			events := []*models.AuditEvent{
+				{ID: "e1", Time: start, Actor: "alice", Action: models.AuditLoginFailure, Target: "user:alice", IP: "10.0.0.1", Detail: "invalid credentials"},
+				{ID: "e2", Time: start.Add(time.Minute), Actor: "alice", Action: models.AuditLoginSuccess, Target: "user:alice", IP: "10.0.0.1", UserAgent: "curl/8.0"},
+				{ID: "e3", Time: start.Add(time.Hour), Actor: "bob", Action: models.AuditPerfectDayDelete, Target: "perfect_day:pd-1"},
+				{ID: "e4", Time: start.Add(24 * time.Hour), Actor: "alice", Action: models.AuditPerfectDayCreate, Target: "perfect_day:pd-2"},
+			}
+			for _, event := range events {
+				if err := store.AuditStorage.Append(event); err != nil {
+					t.Fatalf("Failed to append audit event: %v", err)
+				}
+			}
+
+			all, err := store.AuditStorage.Query(models.AuditFilter{})
+			if err != nil {
+				t.Fatalf("Failed to query audit events: %v", err)
+			}
+			if len(all) != 4 || all[0].ID != "e4" || all[3].ID != "e1" {
+				t.Fatalf("Expected all 4 events newest first, got %d", len(all))
+			}
+			if all[2].UserAgent != "curl/8.0" || all[2].IP != "10.0.0.1" || !all[2].Time.Equal(events[1].Time) {
+				t.Errorf("Queried event does not match: %+v", all[2])
+			}
+
+			tests := []struct {
+				name     string
+				filter   models.AuditFilter
+				expected []string
+			}{
+				{"actor", models.AuditFilter{Actor: "alice"}, []string{"e4", "e2", "e1"}},
+				{"action", models.AuditFilter{Action: models.AuditPerfectDayDelete}, []string{"e3"}},
+				{"from_inclusive", models.AuditFilter{From: start.Add(time.Minute)}, []string{"e4", "e3", "e2"}},
+				{"to_exclusive", models.AuditFilter{To: start.Add(time.Hour)}, []string{"e2", "e1"}},
+				{"combined", models.AuditFilter{Actor: "alice", From: start.Add(time.Second), To: start.Add(48 * time.Hour)}, []string{"e4", "e2"}},
+				{"limit", models.AuditFilter{Limit: 2}, []string{"e4", "e3"}},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					found, err := store.AuditStorage.Query(tt.filter)
+					if err != nil {
+						t.Fatalf("Failed to query audit events: %v", err)
+					}
+					var ids []string
+					for _, event := range found {
+						ids = append(ids, event.ID)
+					}
+					if len(ids) != len(tt.expected) {
+						t.Fatalf("Expected %v, got %v", tt.expected, ids)
+					}
+					for i := range ids {
+						if ids[i] != tt.expected[i] {
+							t.Fatalf("Expected %v, got %v", tt.expected, ids)
+						}
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := auth.HashPassword("correct-horse")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	if hash == "correct-horse" || !strings.HasPrefix(hash, "$2") {
+		t.Errorf("Expected a bcrypt hash, got %q", hash)
+	}
+
+	other, _ := auth.HashPassword("correct-horse")
+	if other == hash {
+		t.Error("Hashes of the same password should be salted differently")
+	}
+
+	if !auth.CheckPassword(hash, "correct-horse") {
+		t.Error("The right password should match")
+	}
+	if auth.CheckPassword(hash, "wrong-horse") {
+		t.Error("A wrong password should not match")
+	}
+	if auth.CheckPassword("", "") {
+		t.Error("An empty hash should never match")
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	if err := auth.ValidatePassword("short"); err == nil {
+		t.Error("Short passwords should be rejected")
+	}
+	if err := auth.ValidatePassword(strings.Repeat("x", 73)); err == nil {
+		t.Error("Passwords bcrypt would truncate should be rejected")
+	}
+	if err := auth.ValidatePassword("long-enough"); err != nil {
+		t.Errorf("Expected a valid password, got %v", err)
+	}
+}
+
+func TestRepositoryUserPasswordHash(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			user, _ := models.NewUser("alice", "UTC")
+			if user.HasPassword() {
+				t.Error("A new user should have no password")
+			}
+			user.PasswordHash, _ = auth.HashPassword("correct-horse")
+			store.UserStorage.Save(user)
+
+			loaded, err := store.UserStorage.Load("alice")
+			if err != nil {
+				t.Fatalf("Failed to load user: %v", err)
+			}
+			if !loaded.HasPassword() || !auth.CheckPassword(loaded.PasswordHash, "correct-horse") {
+				t.Error("The password hash should survive a round trip")
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package unit
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/backup"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"testing"
+)
+
+func TestBackupRoundTrip(t *testing.T) {
+	for _, backend := range []string{storage.BackendFiles, storage.BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			dataDir := t.TempDir()
+			store, err := storage.Open(backend, dataDir)
+			if err != nil {
+				t.Fatalf("Failed to open storage: %v", err)
+			}
+
+			user, _ := models.NewUser("alice", "UTC")
+			store.UserStorage.Save(user)
+			pd, _ := models.NewPerfectDay("backup01-0000-0000-0000-000000000001", "Backed Up", "", "alice", "2024-01-01")
+			store.PerfectDayStorage.Save(pd)
+			pd.Title = "Backed Up Twice"
+			store.PerfectDayStorage.Save(pd)
+
+			var archive bytes.Buffer
+			manifest, err := backup.Create(&archive, dataDir)
+			if err != nil {
+				t.Fatalf("Failed to create backup: %v", err)
+			}
+			if manifest.SchemaVersion != backup.SchemaVersion || len(manifest.Files) == 0 {
+				t.Errorf("Unexpected manifest: %+v", manifest)
+			}
+
+			// Changes after the backup must be undone by the restore.
+			pd.Title = "Changed Later"
+			store.PerfectDayStorage.Save(pd)
+			store.Close()
+			os.WriteFile(filepath.Join(dataDir, "config.json"), []byte(`{"google_places_api_key":"secret"}`), 0644)
+
+			_, previousDir, err := backup.Restore(bytes.NewReader(archive.Bytes()), dataDir)
+			if err != nil {
+				t.Fatalf("Failed to restore backup: %v", err)
+			}
+			if previousDir == "" {
+				t.Error("Expected the replaced data to be kept aside")
+			}
+			defer os.RemoveAll(previousDir)
+
+			if _, err := os.Stat(filepath.Join(dataDir, "config.json")); err != nil {
+				t.Error("config.json should be left in place")
+			}
+
+			restored, err := storage.Open(backend, dataDir)
+			if err != nil {
+				t.Fatalf("Failed to reopen storage: %v", err)
+			}
+			defer restored.Close()
+
+			loaded, err := restored.PerfectDayStorage.LoadByID(pd.ID)
+			if err != nil {
+				t.Fatalf("Failed to load restored perfect day: %v", err)
+			}
+			if loaded.Title != "Backed Up Twice" || loaded.Revision != 2 {
+				t.Errorf("Expected revision 2 'Backed Up Twice', got revision %d %q", loaded.Revision, loaded.Title)
+			}
+			if !restored.UserStorage.Exists("alice") {
+				t.Error("User should be restored")
+			}
+			revisions, _ := restored.PerfectDayStorage.ListRevisions(pd.ID)
+			if len(revisions) != 2 {
+				t.Errorf("Expected 2 restored revisions, got %d", len(revisions))
+			}
+		})
+	}
+}
+
+func TestBackupSkipsConfig(t *testing.T) {
+	dataDir := t.TempDir()
+	os.WriteFile(filepath.Join(dataDir, "config.json"), []byte(`{}`), 0644)
+	os.WriteFile(filepath.Join(dataDir, "current_user"), []byte("alice"), 0644)
+
+	var archive bytes.Buffer
+	manifest, err := backup.Create(&archive, dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+
+	for _, entry := range manifest.Files {
+		if entry.Path == "config.json" || entry.Path == storage.LockFileName {
+			t.Errorf("%s should not be archived", entry.Path)
+		}
+	}
+	if len(manifest.Files) != 1 || manifest.Files[0].Path != "current_user" {
+		t.Errorf("Expected only current_user, got %+v", manifest.Files)
+	}
+}
+
+func TestBackupRejectsCorruptArchive(t *testing.T) {
+	manifest := backup.Manifest{
+		SchemaVersion: backup.SchemaVersion,
+		Files: []backup.FileEntry{
+			{Path: "current_user", Size: 5, SHA256: strings.Repeat("0", 64)},
+		},
+	}
+	archive := writeTestArchive(t, manifest, map[string]string{"current_user": "alice"})
+
+	dataDir := t.TempDir()
+	os.WriteFile(filepath.Join(dataDir, "current_user"), []byte("bob"), 0644)
+
+	_, _, err := backup.Restore(bytes.NewReader(archive), dataDir)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Expected checksum mismatch, got %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dataDir, "current_user"))
+	if string(data) != "bob" {
+		t.Error("A failed restore must not touch the data directory")
+	}
+}
+
+func TestBackupRejectsUnsafePaths(t *testing.T) {
+	manifest := backup.Manifest{
+		SchemaVersion: backup.SchemaVersion,
+		Files:         []backup.FileEntry{{Path: "../escape", Size: 1}},
+	}
+	archive := writeTestArchive(t, manifest, map[string]string{"../escape": "x"})
+
+	if _, err := backup.Verify(bytes.NewReader(archive)); err == nil || !strings.Contains(err.Error(), "unsafe path") {
+		t.Errorf("Expected unsafe path error, got %v", err)
+	}
+}
+
+func TestBackupRejectsNewerSchema(t *testing.T) {
+	manifest := backup.Manifest{SchemaVersion: backup.SchemaVersion + 1}
+	archive := writeTestArchive(t, manifest, nil)
+
+	if _, err := backup.Verify(bytes.NewReader(archive)); err == nil || !strings.Contains(err.Error(), "schema version") {
+		t.Errorf("Expected schema version error, got %v", err)
+	}
+}
+
+// writeTestArchive builds an archive by hand so tests can tamper with it.
+func writeTestArchive(t *testing.T, manifest backup.Manifest, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	manifestData, _ := json.Marshal(manifest)
+	entries := []struct{ name, content string }{{backup.ManifestName, string(manifestData)}}
+	for name, content := range files {
+		entries = append(entries, struct{ name, content string }{name, content})
+	}
+
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write archive: %v", err)
+		}
+		tw.Write([]byte(entry.content))
+	}
+	tw.Close()
+	gz.Close()
+
+	return buf.Bytes()
+}
@@ -0,0 +1,190 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	helperDataDirEnv = "PERFECT_DAY_HELPER_DATA_DIR"
+	helperWriterEnv  = "PERFECT_DAY_HELPER_WRITER"
+	recordsPerWriter = 25
+)
+
+// TestHelperStorageWriter is not a real test: it is re-executed as a child
+// process by TestConcurrentSavesFromProcesses to act as a second writer.
+func TestHelperStorageWriter(t *testing.T) {
+	dataDir := os.Getenv(helperDataDirEnv)
+	if dataDir == "" {
+		t.Skip("helper process only")
+	}
+	writeRecords(t, storage.NewStorage(dataDir), os.Getenv(helperWriterEnv))
+}
+
+func writeRecords(t *testing.T, store *storage.Storage, writer string) {
+	user, _ := models.NewUser("user-"+writer, "UTC")
+	if err := store.UserStorage.Save(user); err != nil {
+		t.Errorf("writer %s: failed to save user: %v", writer, err)
+	}
+
+	shared, _ := models.NewPerfectDay("shared", "Shared Day", "", "shared", "2024-01-01")
+	for i := 0; i < recordsPerWriter; i++ {
+		pd, _ := models.NewPerfectDay(fmt.Sprintf("%s-%03d", writer, i), "Day "+strconv.Itoa(i), "", "user-"+writer, "2024-01-01")
+		location := models.NewCustomTextLocation("Somewhere", "Area "+writer)
+		activity, _ := models.NewActivity("act", "Activity", *location, "09:00", 30, strings.Repeat("x", 2048), "")
+		pd.AddActivity(*activity)
+		if err := store.PerfectDayStorage.Save(pd); err != nil {
+			t.Errorf("writer %s: failed to save %s: %v", writer, pd.ID, err)
+		}
+
+		// Every writer also updates one shared record, reloading it whenever
+		// another writer got there first.
+		for {
+			shared.Description = writer + strconv.Itoa(i)
+			err := store.PerfectDayStorage.Save(shared)
+			if err == nil {
+				break
+			}
+			if !errors.Is(err, storage.ErrRevisionConflict) {
+				t.Errorf("writer %s: failed to save shared day: %v", writer, err)
+				break
+			}
+			if shared, err = store.PerfectDayStorage.LoadByID("shared"); err != nil {
+				t.Errorf("writer %s: failed to reload shared day: %v", writer, err)
+				break
+			}
+		}
+	}
+}
+
+func verifyStore(t *testing.T, dataDir string, writers int) {
+	store := storage.NewStorage(dataDir)
+
+	all, err := store.PerfectDayStorage.LoadAll(true)
+	if err != nil {
+		t.Fatalf("Failed to load all perfect days: %v", err)
+	}
+	expected := writers*recordsPerWriter + 1
+	if len(all) != expected {
+		t.Errorf("Expected %d readable perfect days, got %d", expected, len(all))
+	}
+
+	// No update of the shared record may have been lost.
+	shared, err := store.PerfectDayStorage.LoadByID("shared")
+	if err != nil {
+		t.Fatalf("Failed to load shared perfect day: %v", err)
+	}
+	if shared.Revision != writers*recordsPerWriter {
+		t.Errorf("Expected shared revision %d, got %d", writers*recordsPerWriter, shared.Revision)
+	}
+
+	// Every record must be reachable through the index without a rebuild.
+	for _, pd := range all {
+		if _, err := store.PerfectDayStorage.LoadByID(pd.ID); err != nil {
+			t.Errorf("Index lookup of %s failed: %v", pd.ID, err)
+		}
+	}
+
+	users, _ := store.UserStorage.List()
+	if len(users) != writers {
+		t.Errorf("Expected %d users, got %d", writers, len(users))
+	}
+
+	filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && strings.Contains(info.Name(), ".tmp-") {
+			t.Errorf("Temporary file left behind: %s", path)
+		}
+		return nil
+	})
+}
+
+func TestConcurrentSavesFromGoroutines(t *testing.T) {
+	tempDir := t.TempDir()
+	store := storage.NewStorage(tempDir)
+
+	const writers = 8
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(writer string) {
+			defer wg.Done()
+			writeRecords(t, store, writer)
+		}(strconv.Itoa(w))
+	}
+	wg.Wait()
+
+	verifyStore(t, tempDir, writers)
+}
+
+func TestConcurrentSavesFromProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns child processes")
+	}
+
+	tempDir := t.TempDir()
+
+	const writers = 4
+	cmds := make([]*exec.Cmd, writers)
+	for w := 0; w < writers; w++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHelperStorageWriter$")
+		cmd.Env = append(os.Environ(),
+			helperDataDirEnv+"="+tempDir,
+			helperWriterEnv+"=p"+strconv.Itoa(w),
+		)
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("Failed to start writer process: %v", err)
+		}
+		cmds[w] = cmd
+	}
+
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("Writer process failed: %v", err)
+		}
+	}
+
+	verifyStore(t, tempDir, writers)
+}
+
+func TestDataDirLockBlocksWriters(t *testing.T) {
+	tempDir := t.TempDir()
+	store := storage.NewStorage(tempDir)
+
+	lock, err := storage.LockDataDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to lock data dir: %v", err)
+	}
+
+	saved := make(chan error, 1)
+	go func() {
+		pd, _ := models.NewPerfectDay("blocked", "Blocked Day", "", "testuser", "2024-01-01")
+		saved <- store.PerfectDayStorage.Save(pd)
+	}()
+
+	select {
+	case <-saved:
+		t.Fatal("Save completed while the data directory was locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	lock.Unlock()
+
+	select {
+	case err := <-saved:
+		if err != nil {
+			t.Fatalf("Save failed after unlock: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Save did not complete after unlock")
+	}
+}
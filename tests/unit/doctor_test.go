@@ -0,0 +1,158 @@
+package unit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"testing"
+	"time"
+)
+
+// writeRawPerfectDay stores pd under username's directory exactly as given,
+// bypassing the checks Save applies.
+func writeRawPerfectDay(t *testing.T, dataDir, username, fileID string, pd *models.PerfectDay) string {
+	path := filepath.Join(dataDir, "perfect-days", username, fileID+".json")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, _ := json.Marshal(pd)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write perfect day: %v", err)
+	}
+	return path
+}
+
+func issueKinds(report *storage.DoctorReport) map[string]int {
+	kinds := make(map[string]int)
+	for _, issue := range report.Issues {
+		kinds[issue.Kind]++
+	}
+	return kinds
+}
+
+func TestDoctorHealthyStore(t *testing.T) {
+	store := storage.NewStorage(t.TempDir())
+	user, _ := models.NewUser("alice", "UTC")
+	store.UserStorage.Save(user)
+
+	pd, _ := models.NewPerfectDay("healthy1", "Fine Day", "", "alice", "2024-01-01")
+	activity, _ := models.NewActivity("act1", "Walk", *models.NewCustomTextLocation("Park", "Shibuya"), "09:00", 60, "", "")
+	pd.AddActivity(*activity)
+	store.PerfectDayStorage.Save(pd)
+
+	report, err := store.PerfectDayStorage.(*storage.PerfectDayStorage).Doctor(false)
+	if err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("Expected no issues, got %+v", report.Issues)
+	}
+	if report.Users != 1 || report.PerfectDays != 1 {
+		t.Errorf("Expected 1 user and 1 perfect day, got %d and %d", report.Users, report.PerfectDays)
+	}
+}
+
+func TestDoctorFindsAndFixesProblems(t *testing.T) {
+	dataDir := t.TempDir()
+	store := storage.NewStorage(dataDir)
+	user, _ := models.NewUser("alice", "UTC")
+	store.UserStorage.Save(user)
+
+	// Unparseable file
+	brokenPath := filepath.Join(dataDir, "perfect-days", "alice", "broken.json")
+	os.MkdirAll(filepath.Dir(brokenPath), 0755)
+	os.WriteFile(brokenPath, []byte("{not json"), 0644)
+
+	// Username and ID that do not match the file location, and stale areas
+	moved, _ := models.NewPerfectDay("other-id", "Moved", "", "bob", "2024-01-01")
+	activity, _ := models.NewActivity("act1", "Walk", *models.NewCustomTextLocation("Park", "Shibuya"), "09:00", 60, "", "")
+	moved.Activities = []models.Activity{*activity}
+	moved.Areas = []string{"Ginza"}
+	writeRawPerfectDay(t, dataDir, "alice", "moved", moved)
+
+	// Duplicate ID across users; the newer copy in carol's directory wins,
+	// and carol has no user file.
+	older, _ := models.NewPerfectDay("dup", "Older", "", "alice", "2024-01-01")
+	older.UpdatedAt = time.Now().Add(-time.Hour)
+	olderPath := writeRawPerfectDay(t, dataDir, "alice", "dup", older)
+	newer, _ := models.NewPerfectDay("dup", "Newer", "", "carol", "2024-01-01")
+	writeRawPerfectDay(t, dataDir, "carol", "dup", newer)
+
+	// Invalid date and time
+	invalid, _ := models.NewPerfectDay("invalid", "Invalid", "", "alice", "2024-01-01")
+	invalid.Date = "2024-13-40"
+	invalid.Activities = []models.Activity{{ID: "act2", Name: "Late", StartTime: "25:99", Duration: 30}}
+	writeRawPerfectDay(t, dataDir, "alice", "invalid", invalid)
+
+	fileStorage := store.PerfectDayStorage.(*storage.PerfectDayStorage)
+
+	report, err := fileStorage.Doctor(false)
+	if err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	kinds := issueKinds(report)
+	expected := map[string]int{
+		storage.IssueUnparseable:      1,
+		storage.IssueUsernameMismatch: 1,
+		storage.IssueIDMismatch:       1,
+		storage.IssueStaleAreas:       1,
+		storage.IssueDuplicateID:      1,
+		storage.IssueMissingUser:      1,
+		storage.IssueInvalidDate:      1,
+		storage.IssueInvalidTime:      1,
+	}
+	for kind, count := range expected {
+		if kinds[kind] != count {
+			t.Errorf("Expected %d %s issues, got %d (%+v)", count, kind, kinds[kind], report.Issues)
+		}
+	}
+	if report.Unfixed() != len(report.Issues) {
+		t.Error("A check without --fix must not fix anything")
+	}
+	if _, err := os.Stat(brokenPath); err != nil {
+		t.Error("A check without --fix must not move files")
+	}
+
+	report, err = fileStorage.Doctor(true)
+	if err != nil {
+		t.Fatalf("Doctor --fix failed: %v", err)
+	}
+	if report.QuarantineDir == "" {
+		t.Fatal("Expected a quarantine directory")
+	}
+	if _, err := os.Stat(filepath.Join(report.QuarantineDir, "perfect-days", "alice", "broken.json")); err != nil {
+		t.Error("Unparseable file should be quarantined")
+	}
+	if _, err := os.Stat(olderPath); !os.IsNotExist(err) {
+		t.Error("Older duplicate should be quarantined")
+	}
+
+	repaired, err := fileStorage.Load("alice", "moved")
+	if err != nil {
+		t.Fatalf("Failed to load repaired perfect day: %v", err)
+	}
+	if repaired.ID != "moved" || repaired.Username != "alice" {
+		t.Errorf("Expected ID and username from the file location, got %s/%s", repaired.Username, repaired.ID)
+	}
+	if len(repaired.Areas) != 1 || repaired.Areas[0] != "Shibuya" {
+		t.Errorf("Expected areas to be recomputed, got %v", repaired.Areas)
+	}
+	if repaired.Revision != 1 {
+		t.Errorf("Expected the repair to be saved as revision 1, got %d", repaired.Revision)
+	}
+
+	found, err := fileStorage.LoadByID("dup")
+	if err != nil || found.Title != "Newer" {
+		t.Errorf("Expected the index to point at the newer duplicate, got %v, %v", found, err)
+	}
+
+	// Only the problems that need a human remain.
+	report, err = fileStorage.Doctor(false)
+	if err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	kinds = issueKinds(report)
+	if len(report.Issues) != 3 || kinds[storage.IssueMissingUser] != 1 || kinds[storage.IssueInvalidDate] != 1 || kinds[storage.IssueInvalidTime] != 1 {
+		t.Errorf("Expected only missing user and invalid date/time issues, got %+v", report.Issues)
+	}
+}
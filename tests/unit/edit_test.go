@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"errors"
 	"perfect-day/pkg/models"
 	"perfect-day/pkg/storage"
 	"testing"
@@ -201,4 +202,69 @@ func TestActivityModification(t *testing.T) {
 	if endTime != "12:30" {
 		t.Errorf("Expected end time 12:30, got %s", endTime)
 	}
-}
\ No newline at end of file
+}
+
+func TestPerfectDayActivityEditing(t *testing.T) {
+	pd, _ := models.NewPerfectDay("test-id", "Test Day", "description", "testuser", "2023-12-01")
+	created := time.Date(2023, 11, 1, 9, 0, 0, 0, time.UTC)
+	for _, a := range []struct{ id, name, area, start string }{
+		{"act1", "Coffee", "Shibuya", "09:00"},
+		{"act2", "Lunch", "Shinjuku", "12:00"},
+		{"act3", "Walk", "Harajuku", "14:00"},
+	} {
+		activity, _ := models.NewActivity(a.id, a.name, *models.NewCustomTextLocation(a.name+" place", a.area), a.start, 60, "", "")
+		activity.CreatedAt = created
+		pd.AddActivity(*activity)
+	}
+
+	if _, err := pd.FindActivity("missing"); !errors.Is(err, models.ErrActivityNotFound) {
+		t.Errorf("Expected ErrActivityNotFound, got %v", err)
+	}
+
+	// Updating keeps the ID, creation time and position
+	updated, err := pd.UpdateActivity("act2", models.Activity{
+		ID:        "ignored",
+		Name:      "Long lunch",
+		Location:  *models.NewCustomTextLocation("Ramen shop", "Ebisu"),
+		StartTime: "12:30",
+		Duration:  120,
+	})
+	if err != nil {
+		t.Fatalf("Failed to update activity: %v", err)
+	}
+	if updated.ID != "act2" || !updated.CreatedAt.Equal(created) || pd.Activities[1].Name != "Long lunch" {
+		t.Errorf("Expected act2 to be updated in place, got %+v", pd.Activities[1])
+	}
+	if len(pd.Areas) != 3 || pd.Areas[0] != "Ebisu" {
+		t.Errorf("Expected areas to follow the update, got %v", pd.Areas)
+	}
+	if _, err := pd.UpdateActivity("act2", models.Activity{Name: "Lunch", StartTime: "noon", Duration: 60}); err == nil {
+		t.Error("Expected an invalid start time to be rejected")
+	}
+	if _, err := pd.UpdateActivity("missing", *updated); !errors.Is(err, models.ErrActivityNotFound) {
+		t.Errorf("Expected ErrActivityNotFound, got %v", err)
+	}
+
+	// Reordering needs every ID exactly once
+	for _, ids := range [][]string{{"act3", "act1"}, {"act3", "act3", "act1"}, {"act3", "act1", "missing"}} {
+		if err := pd.ReorderActivities(ids); err == nil {
+			t.Errorf("Expected reordering by %v to be rejected", ids)
+		}
+	}
+	if err := pd.ReorderActivities([]string{"act3", "act1", "act2"}); err != nil {
+		t.Fatalf("Failed to reorder activities: %v", err)
+	}
+	if pd.Activities[0].ID != "act3" || pd.Activities[1].ID != "act1" || pd.Activities[2].ID != "act2" {
+		t.Errorf("Expected order act3, act1, act2, got %+v", pd.Activities)
+	}
+
+	if err := pd.RemoveActivity("act1"); err != nil {
+		t.Fatalf("Failed to remove activity: %v", err)
+	}
+	if len(pd.Activities) != 2 || len(pd.Areas) != 2 {
+		t.Errorf("Expected 2 activities in 2 areas after removal, got %+v in %v", pd.Activities, pd.Areas)
+	}
+	if err := pd.RemoveActivity("act1"); !errors.Is(err, models.ErrActivityNotFound) {
+		t.Errorf("Expected ErrActivityNotFound, got %v", err)
+	}
+}
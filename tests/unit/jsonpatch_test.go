@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"perfect-day/pkg/jsonpatch"
+	"reflect"
+	"testing"
+)
+
+func assertJSONEqual(t *testing.T, expected string, actual []byte) {
+	t.Helper()
+	var want, got interface{}
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		t.Fatalf("Invalid expected JSON: %v", err)
+	}
+	if err := json.Unmarshal(actual, &got); err != nil {
+		t.Fatalf("Invalid result JSON: %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+// Examples from RFC 7396, appendix A
+func TestMergePatch(t *testing.T) {
+	tests := []struct{ doc, patch, expected string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		result, err := jsonpatch.MergePatch([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("Failed to merge %s into %s: %v", tt.patch, tt.doc, err)
+			continue
+		}
+		assertJSONEqual(t, tt.expected, result)
+	}
+}
+
+// Examples from RFC 6902, appendix A
+func TestJSONPatch(t *testing.T) {
+	tests := []struct{ name, doc, patch, expected string }{
+		{"add_member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add_element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"remove_member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove_element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move_member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move_element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"foo":["bar"]}`, `[{"op":"copy","from":"/foo/0","path":"/foo/-"}]`, `{"foo":["bar","bar"]}`},
+		{"test", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"add_nested", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{"append", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"escaped_path", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"replace","path":"/~1","value":1}]`, `{"/":1,"~1":10}`},
+		{"null_value", `{"foo":"bar"}`, `[{"op":"add","path":"/foo","value":null}]`, `{"foo":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := jsonpatch.Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Failed to apply patch: %v", err)
+			}
+			assertJSONEqual(t, tt.expected, result)
+		})
+	}
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+	tests := []struct{ name, doc, patch string }{
+		{"missing_member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`},
+		{"missing_parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{"index_out_of_range", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/5","value":"qux"}]`},
+		{"leading_zero", `{"foo":["bar","baz"]}`, `[{"op":"replace","path":"/foo/01","value":"qux"}]`},
+		{"missing_value", `{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`},
+		{"unknown_op", `{"foo":"bar"}`, `[{"op":"merge","path":"/foo","value":1}]`},
+		{"move_into_child", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`},
+		{"not_an_array", `{"foo":"bar"}`, `{"op":"remove","path":"/foo"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := jsonpatch.Apply([]byte(tt.doc), []byte(tt.patch)); err == nil {
+				t.Errorf("Expected patch %s to fail", tt.patch)
+			}
+		})
+	}
+
+	// A failed test fails the whole patch
+	_, err := jsonpatch.Apply([]byte(`{"baz":"qux"}`), []byte(`[{"op":"replace","path":"/baz","value":"x"},{"op":"test","path":"/baz","value":"qux"}]`))
+	if !errors.Is(err, jsonpatch.ErrTestFailed) {
+		t.Errorf("Expected ErrTestFailed, got %v", err)
+	}
+}
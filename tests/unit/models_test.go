@@ -206,6 +206,26 @@ func TestPerfectDaySoftDelete(t *testing.T) {
 	if !pd.UpdatedAt.After(initialUpdatedAt) {
 		t.Error("UpdatedAt should be updated after soft delete")
 	}
+	if pd.DeletedAt == nil || !pd.DeletionTime().Equal(*pd.DeletedAt) {
+		t.Error("DeletedAt should be set after soft delete")
+	}
+}
+
+func TestPerfectDayRestore(t *testing.T) {
+	pd, _ := models.NewPerfectDay("test-id", "Test Day", "description", "testuser", "2023-12-01")
+	pd.SoftDelete()
+
+	pd.Restore()
+	if pd.IsDeleted || pd.DeletedAt != nil {
+		t.Error("PerfectDay should not be deleted after Restore()")
+	}
+
+	// Records deleted before DeletedAt existed use their last update.
+	legacy, _ := models.NewPerfectDay("legacy-id", "Legacy Day", "", "testuser", "2023-12-01")
+	legacy.IsDeleted = true
+	if !legacy.DeletionTime().Equal(legacy.UpdatedAt) {
+		t.Error("DeletionTime should fall back to UpdatedAt")
+	}
 }
 
 func TestPerfectDaySearchableContent(t *testing.T) {
@@ -225,6 +245,63 @@ func TestPerfectDaySearchableContent(t *testing.T) {
 	}
 }
 
+func TestDiffPerfectDays(t *testing.T) {
+	old, _ := models.NewPerfectDay("test-id", "Coffee Day", "", "testuser", "2023-12-01")
+	location := models.NewCustomTextLocation("Blue Bottle", "Shibuya")
+	activity, _ := models.NewActivity("act1", "Coffee", *location, "10:00", 60, "", "")
+	old.AddActivity(*activity)
+
+	updated := *old
+	updated.Title = "Tea Day"
+	updated.Activities = []models.Activity{*activity, *activity}
+	updated.Activities[0].StartTime = "11:00"
+
+	changes := models.DiffPerfectDays(old, &updated)
+	fields := make(map[string]models.FieldChange)
+	for _, change := range changes {
+		fields[change.Field] = change
+	}
+
+	if len(changes) != 3 {
+		t.Errorf("Expected 3 changes, got %d: %v", len(changes), changes)
+	}
+	if fields["title"].Old != "Coffee Day" || fields["title"].New != "Tea Day" {
+		t.Errorf("Unexpected title change: %v", fields["title"])
+	}
+	if fields["activities[0].start_time"].New != "11:00" {
+		t.Errorf("Unexpected start time change: %v", fields["activities[0].start_time"])
+	}
+	if added := fields["activities[1]"]; added.Old != "" || added.New == "" {
+		t.Errorf("Expected added activity, got %v", added)
+	}
+
+	if len(models.DiffPerfectDays(old, old)) != 0 {
+		t.Error("Identical perfect days should have no changes")
+	}
+}
+
+func TestPerfectDayRestoreFrom(t *testing.T) {
+	snapshot, _ := models.NewPerfectDay("test-id", "Old Title", "Old description", "testuser", "2023-12-01")
+	location := models.NewCustomTextLocation("Park", "Ueno")
+	activity, _ := models.NewActivity("act1", "Walk", *location, "09:00", 30, "", "")
+	snapshot.AddActivity(*activity)
+
+	pd, _ := models.NewPerfectDay("test-id", "New Title", "", "testuser", "2023-12-02")
+	pd.Revision = 5
+
+	pd.RestoreFrom(snapshot)
+
+	if pd.Title != "Old Title" || pd.Date != "2023-12-01" || len(pd.Activities) != 1 {
+		t.Errorf("Content should be restored, got %+v", pd)
+	}
+	if len(pd.Areas) != 1 || pd.Areas[0] != "Ueno" {
+		t.Errorf("Areas should be recomputed, got %v", pd.Areas)
+	}
+	if pd.Revision != 5 {
+		t.Errorf("Revision should be kept, got %d", pd.Revision)
+	}
+}
+
 func containsIgnoreCase(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr ||
 		(len(substr) > 0 && indexIgnoreCase(s, substr) >= 0))
@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/oidc"
+	"testing"
+)
+
+func TestCodeChallengeS256(t *testing.T) {
+	// Example from RFC 7636, appendix B
+	challenge := oidc.CodeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if challenge != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("Unexpected code challenge %s", challenge)
+	}
+
+	first, _ := oidc.NewCodeVerifier()
+	second, _ := oidc.NewCodeVerifier()
+	if len(first) < 43 || len(first) > 128 || first == second {
+		t.Errorf("Expected distinct verifiers of 43-128 characters, got %q and %q", first, second)
+	}
+}
+
+func TestLoginWithOIDC(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			authService := auth.NewAuthService(store.UserStorage, store.SessionStorage, store.TokenStorage)
+
+			local, _ := models.NewUser("dana", "UTC")
+			store.UserStorage.Save(local)
+
+			claims := &oidc.Claims{Issuer: "https://sso.example.com", Subject: "1234", Email: "dana@example.com", Name: "Dana"}
+			user, session, err := authService.LoginWithOIDC(claims)
+			if err != nil {
+				t.Fatalf("Failed to log in: %v", err)
+			}
+			if user.Username != "dana-2" || user.DisplayName != "Dana" {
+				t.Errorf("Expected a new user dana-2 next to the local dana, got %+v", user)
+			}
+			if _, err := authService.ValidateSession(session.Token); err != nil {
+				t.Errorf("Expected a valid session: %v", err)
+			}
+
+			stored, err := store.UserStorage.Load("dana-2")
+			if err != nil || stored.OIDCIssuer != claims.Issuer || stored.OIDCSubject != claims.Subject || !stored.UsesSingleSignOn() {
+				t.Fatalf("Expected the identity to be stored, got %+v (%v)", stored, err)
+			}
+
+			again, _, err := authService.LoginWithOIDC(&oidc.Claims{Issuer: claims.Issuer, Subject: claims.Subject, PreferredUsername: "someone-else"})
+			if err != nil || again.Username != "dana-2" {
+				t.Errorf("Expected the identity to log in as dana-2 again, got %+v (%v)", again, err)
+			}
+
+			other, _, err := authService.LoginWithOIDC(&oidc.Claims{Issuer: "https://other.example.com", Subject: "1234", PreferredUsername: "a.b@c"})
+			if err != nil || other.Username != "a-b-c" {
+				t.Errorf("Expected the same subject at another issuer to be a new user a-b-c, got %+v (%v)", other, err)
+			}
+
+			if _, _, err := authService.Login("dana-2", ""); err != auth.ErrInvalidCredentials {
+				t.Errorf("Expected password login to fail for single sign-on users, got %v", err)
+			}
+			if err := authService.SetInitialPassword("dana-2", "new-password-123"); err == nil {
+				t.Error("Expected single sign-on users not to get a password")
+			}
+		})
+	}
+}
+
+func TestLoginWithOIDCSkipsReservedUsernames(t *testing.T) {
+	store := storageBackends(t)["memory"]
+	authService := auth.NewAuthService(store.UserStorage, store.SessionStorage, store.TokenStorage)
+	authService.ReserveUsernames([]string{"root"})
+
+	user, _, err := authService.LoginWithOIDC(&oidc.Claims{Issuer: "https://sso.example.com", Subject: "42", PreferredUsername: "root"})
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	if user.Username != "root-2" {
+		t.Errorf("Expected the reserved name root to be skipped, got %s", user.Username)
+	}
+	if _, _, err := authService.Signup("root", "UTC", "password-123"); err != auth.ErrUserExists {
+		t.Errorf("Expected signing up as a reserved name to fail, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
+	"testing"
+)
+
+func TestRoles(t *testing.T) {
+	for _, role := range models.Roles {
+		if err := models.ValidateRole(role); err != nil {
+			t.Errorf("Expected %q to be valid: %v", role, err)
+		}
+	}
+	if err := models.ValidateRole("owner"); err == nil {
+		t.Error("Expected an unknown role to be rejected")
+	}
+
+	if !models.RoleIncludes(models.RoleAdmin, models.RoleModerator) || !models.RoleIncludes(models.RoleModerator, models.RoleUser) {
+		t.Error("Expected each role to include the ones below it")
+	}
+	if models.RoleIncludes(models.RoleUser, models.RoleModerator) || models.RoleIncludes(models.RoleAdmin, "owner") {
+		t.Error("Expected a role not to include more privileged or unknown ones")
+	}
+
+	user, _ := models.NewUser("alice", "UTC")
+	if user.EffectiveRole() != models.RoleUser {
+		t.Errorf("Expected users without a role to count as %q, got %q", models.RoleUser, user.EffectiveRole())
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	policy := auth.NewPolicy([]string{"root"})
+
+	newUser := func(username, role string) *models.User {
+		user, _ := models.NewUser(username, "UTC")
+		user.Role = role
+		return user
+	}
+	owner := newUser("alice", "")
+	other := newUser("bob", models.RoleUser)
+	moderator := newUser("mod", models.RoleModerator)
+	admin := newUser("ada", models.RoleAdmin)
+	bootstrap := newUser("root", "")
+
+	if policy.Role(bootstrap) != models.RoleAdmin {
+		t.Errorf("Expected configured admin users to be admins, got %q", policy.Role(bootstrap))
+	}
+
+	live, _ := models.NewPerfectDay("policy01-0000-0000-0000-000000000001", "Live", "", "alice", "2024-01-01")
+	trashed, _ := models.NewPerfectDay("policy02-0000-0000-0000-000000000002", "Trashed", "", "alice", "2024-01-01")
+	trashed.SoftDelete()
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		action     string
+		perfectDay *models.PerfectDay
+		allowed    bool
+	}{
+		{"anonymous_reads", nil, auth.ActionRead, live, true},
+		{"anonymous_cannot_edit", nil, auth.ActionEdit, live, false},
+		{"owner_edits", owner, auth.ActionEdit, live, true},
+		{"owner_deletes", owner, auth.ActionDelete, live, true},
+		{"owner_restores", owner, auth.ActionRestore, trashed, true},
+		{"other_cannot_edit", other, auth.ActionEdit, live, false},
+		{"other_cannot_delete", other, auth.ActionDelete, live, false},
+		{"moderator_deletes", moderator, auth.ActionDelete, live, true},
+		{"moderator_cannot_edit", moderator, auth.ActionEdit, live, false},
+		{"moderator_cannot_purge", moderator, auth.ActionPurge, trashed, false},
+		{"moderator_cannot_restore", moderator, auth.ActionRestore, trashed, false},
+		{"admin_edits", admin, auth.ActionEdit, live, true},
+		{"admin_purges", admin, auth.ActionPurge, live, true},
+		{"admin_restores", admin, auth.ActionRestore, trashed, true},
+		{"bootstrap_admin_purges", bootstrap, auth.ActionPurge, trashed, true},
+		{"trashed_cannot_be_read", admin, auth.ActionRead, trashed, false},
+		{"trashed_cannot_be_edited", owner, auth.ActionEdit, trashed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allowed := policy.Can(tt.user, tt.action, tt.perfectDay); allowed != tt.allowed {
+				t.Errorf("Expected Can(%s) = %v, got %v", tt.action, tt.allowed, allowed)
+			}
+		})
+	}
+
+	if !policy.CanSee(owner, trashed) || !policy.CanSee(admin, trashed) {
+		t.Error("Expected the owner and admins to see trashed perfect days")
+	}
+	if policy.CanSee(moderator, trashed) || policy.CanSee(nil, trashed) {
+		t.Error("Expected trashed perfect days to be hidden from everyone else")
+	}
+}
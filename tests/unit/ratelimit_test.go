@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"perfect-day/internal/api/middleware"
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitPolicy(t *testing.T) {
+	def := middleware.RateLimitPolicy{Requests: 5, Period: time.Second}
+
+	tests := []struct {
+		value    string
+		expected middleware.RateLimitPolicy
+		wantErr  bool
+	}{
+		{"", def, false},
+		{"300/m", middleware.RateLimitPolicy{Requests: 300, Period: time.Minute}, false},
+		{"10/30s", middleware.RateLimitPolicy{Requests: 10, Period: 30 * time.Second}, false},
+		{"1000/h", middleware.RateLimitPolicy{Requests: 1000, Period: time.Hour}, false},
+		{"off", middleware.RateLimitPolicy{}, false},
+		{"300", middleware.RateLimitPolicy{}, true},
+		{"many/m", middleware.RateLimitPolicy{}, true},
+		{"10/fortnight", middleware.RateLimitPolicy{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			policy, err := middleware.ParseRateLimitPolicy(tt.value, def)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if !tt.wantErr && policy != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, policy)
+			}
+		})
+	}
+
+	if !(middleware.RateLimitPolicy{}).Disabled() {
+		t.Error("Expected the zero policy to be disabled")
+	}
+}
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	store := storageBackends(t)["memory"]
+	authService := auth.NewAuthService(store.UserStorage, store.SessionStorage, store.TokenStorage)
+	authService.SetLoginThrottle(auth.NewLoginThrottle(2, 300*time.Millisecond, 900*time.Millisecond))
+
+	user, _ := models.NewUser("alice", "UTC")
+	user.PasswordHash, _ = auth.HashPassword("correct-horse-battery")
+	store.UserStorage.Save(user)
+
+	lockedFor := func() time.Duration {
+		_, _, err := authService.Login("alice", "correct-horse-battery")
+		var locked *auth.LoginLockedError
+		if errors.As(err, &locked) {
+			return locked.RetryAfter
+		}
+		if err != nil {
+			t.Fatalf("Unexpected login error %v", err)
+		}
+		return 0
+	}
+
+	// A successful login forgets a failure
+	authService.Login("alice", "wrong")
+	if lockedFor() != 0 {
+		t.Fatal("Expected a single failure not to lock the account")
+	}
+
+	authService.Login("alice", "wrong")
+	authService.Login("alice", "wrong")
+	locked := lockedFor()
+	if locked <= 0 || locked > 300*time.Millisecond {
+		t.Fatalf("Expected the first lockout, got %s", locked)
+	}
+
+	// Every further failure doubles the lockout, up to the maximum
+	for _, max := range []time.Duration{600 * time.Millisecond, 900 * time.Millisecond} {
+		time.Sleep(locked + 10*time.Millisecond)
+		authService.Login("alice", "wrong")
+		previous := locked
+		if locked = lockedFor(); locked <= previous || locked > max {
+			t.Fatalf("Expected a lockout longer than %s up to %s, got %s", previous, max, locked)
+		}
+	}
+}
+
+func TestLoginThrottleBounded(t *testing.T) {
+	store := storageBackends(t)["memory"]
+	authService := auth.NewAuthService(store.UserStorage, store.SessionStorage, store.TokenStorage)
+	throttle := auth.NewLoginThrottle(2, time.Minute, time.Hour)
+	throttle.SetMaxTracked(3)
+	authService.SetLoginThrottle(throttle)
+
+	user, _ := models.NewUser("alice", "UTC")
+	user.PasswordHash, _ = auth.HashPassword("correct-horse-battery")
+	store.UserStorage.Save(user)
+
+	authService.Login("alice", "wrong")
+	authService.Login("alice", "wrong")
+	var locked *auth.LoginLockedError
+	if _, _, err := authService.Login("alice", "correct-horse-battery"); !errors.As(err, &locked) {
+		t.Fatalf("Expected alice to be locked, got %v", err)
+	}
+
+	// Failures for names nobody registered are tracked too, up to the limit
+	for i := 0; i < 5; i++ {
+		authService.Login(fmt.Sprintf("nobody-%d", i), "wrong")
+		if tracked := throttle.Tracked(); tracked > 3 {
+			t.Fatalf("Expected at most 3 tracked accounts, got %d", tracked)
+		}
+	}
+
+	// alice's failure is the oldest, so it was dropped first
+	if _, _, err := authService.Login("alice", "correct-horse-battery"); err != nil {
+		t.Errorf("Expected the oldest account to be forgotten, got %v", err)
+	}
+}
@@ -0,0 +1,392 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"sync"
+	"testing"
+	"time"
+)
+
+// storageBackends returns one fresh Storage per backend so behaviour can be
+// checked against every implementation of the repository interfaces.
+func storageBackends(t *testing.T) map[string]*storage.Storage {
+	sqliteStorage, err := storage.NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open sqlite storage: %v", err)
+	}
+	t.Cleanup(func() { sqliteStorage.Close() })
+
+	return map[string]*storage.Storage{
+		"file":   storage.NewStorage(t.TempDir()),
+		"memory": storage.NewMemoryStorage(),
+		"sqlite": sqliteStorage,
+	}
+}
+
+func TestRepositoryUserSaveAndLoad(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			user, _ := models.NewUser("testuser", "Asia/Tokyo")
+			if err := store.UserStorage.Save(user); err != nil {
+				t.Fatalf("Failed to save user: %v", err)
+			}
+
+			if !store.UserStorage.Exists("testuser") {
+				t.Error("User should exist after saving")
+			}
+			if store.UserStorage.Exists("nobody") {
+				t.Error("Unknown user should not exist")
+			}
+
+			loaded, err := store.UserStorage.Load("testuser")
+			if err != nil {
+				t.Fatalf("Failed to load user: %v", err)
+			}
+			if loaded.Timezone != "Asia/Tokyo" {
+				t.Errorf("Expected timezone Asia/Tokyo, got %s", loaded.Timezone)
+			}
+
+			if _, err := store.UserStorage.Load("nobody"); err == nil {
+				t.Error("Loading unknown user should return error")
+			}
+
+			other, _ := models.NewUser("another", "UTC")
+			store.UserStorage.Save(other)
+			users, err := store.UserStorage.List()
+			if err != nil {
+				t.Fatalf("Failed to list users: %v", err)
+			}
+			if len(users) != 2 || users[0].Username != "another" || users[1].Username != "testuser" {
+				t.Errorf("Expected users [another testuser], got %v", users)
+			}
+		})
+	}
+}
+
+func TestRepositoryUserCreate(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			const creators = 8
+			var wg sync.WaitGroup
+			errs := make([]error, creators)
+			for i := 0; i < creators; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					user, _ := models.NewUser("taken", "UTC")
+					user.PasswordHash = fmt.Sprintf("hash-%d", i)
+					errs[i] = store.UserStorage.Create(user)
+				}(i)
+			}
+			wg.Wait()
+
+			winner := -1
+			for i, err := range errs {
+				if err == nil {
+					if winner != -1 {
+						t.Fatalf("Expected exactly one Create to succeed, %d and %d both did", winner, i)
+					}
+					winner = i
+				} else if !errors.Is(err, storage.ErrUserExists) {
+					t.Errorf("Expected ErrUserExists, got %v", err)
+				}
+			}
+			if winner == -1 {
+				t.Fatal("Expected one Create to succeed")
+			}
+
+			loaded, err := store.UserStorage.Load("taken")
+			if err != nil {
+				t.Fatalf("Failed to load user: %v", err)
+			}
+			if want := fmt.Sprintf("hash-%d", winner); loaded.PasswordHash != want {
+				t.Errorf("Expected the first user to be kept with %s, got %s", want, loaded.PasswordHash)
+			}
+		})
+	}
+}
+
+func TestRepositoryPerfectDayLifecycle(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			pd1, _ := models.NewPerfectDay("id1", "Day 1", "", "alice", "2023-12-01")
+			pd2, _ := models.NewPerfectDay("id2", "Day 2", "", "alice", "2023-12-02")
+			pd3, _ := models.NewPerfectDay("id3", "Day 3", "", "bob", "2023-12-03")
+			location := models.NewCustomTextLocation("Cafe", "Shibuya")
+			activity, _ := models.NewActivity("act1", "Coffee", *location, "09:00", 60, "", "")
+			pd1.AddActivity(*activity)
+			pd2.SoftDelete()
+
+			for _, pd := range []*models.PerfectDay{pd1, pd2, pd3} {
+				if err := store.PerfectDayStorage.Save(pd); err != nil {
+					t.Fatalf("Failed to save perfect day: %v", err)
+				}
+			}
+
+			loaded, err := store.PerfectDayStorage.Load("alice", "id1")
+			if err != nil {
+				t.Fatalf("Failed to load perfect day: %v", err)
+			}
+			if len(loaded.Activities) != 1 || loaded.Areas[0] != "Shibuya" {
+				t.Errorf("Activities or areas not persisted: %+v", loaded)
+			}
+
+			aliceDays, _ := store.PerfectDayStorage.LoadAllByUser("alice", false)
+			if len(aliceDays) != 1 {
+				t.Errorf("Expected 1 non-deleted perfect day for alice, got %d", len(aliceDays))
+			}
+			aliceDays, _ = store.PerfectDayStorage.LoadAllByUser("alice", true)
+			if len(aliceDays) != 2 {
+				t.Errorf("Expected 2 perfect days for alice including deleted, got %d", len(aliceDays))
+			}
+
+			allDays, _ := store.PerfectDayStorage.LoadAll(false)
+			if len(allDays) != 2 {
+				t.Errorf("Expected 2 non-deleted perfect days, got %d", len(allDays))
+			}
+
+			if err := store.PerfectDayStorage.Delete("bob", "id3", 0); err != nil {
+				t.Fatalf("Failed to delete perfect day: %v", err)
+			}
+			if _, err := store.PerfectDayStorage.Load("bob", "id3"); err == nil {
+				t.Error("Deleted perfect day should not load")
+			}
+			if err := store.PerfectDayStorage.Delete("bob", "missing", 0); err != nil {
+				t.Errorf("Deleting a missing perfect day should not error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryStorageReturnsCopies(t *testing.T) {
+	store := storage.NewMemoryStorage()
+
+	pd, _ := models.NewPerfectDay("id1", "Original", "", "alice", "2023-12-01")
+	store.PerfectDayStorage.Save(pd)
+
+	pd.Title = "Mutated after save"
+	loaded, _ := store.PerfectDayStorage.Load("alice", "id1")
+	if loaded.Title != "Original" {
+		t.Errorf("Stored perfect day changed without Save: %s", loaded.Title)
+	}
+
+	loaded.Title = "Mutated after load"
+	reloaded, _ := store.PerfectDayStorage.Load("alice", "id1")
+	if reloaded.Title != "Original" {
+		t.Errorf("Stored perfect day changed through loaded copy: %s", reloaded.Title)
+	}
+}
+
+func TestRepositoryLookupByID(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			pd1, _ := models.NewPerfectDay("abc12345-0001", "Day 1", "", "alice", "2023-12-01")
+			pd2, _ := models.NewPerfectDay("abc12345-0002", "Day 2", "", "bob", "2023-12-02")
+			pd3, _ := models.NewPerfectDay("def67890-0003", "Day 3", "", "bob", "2023-12-03")
+			pd3.SoftDelete()
+			store.PerfectDayStorage.Save(pd1)
+			store.PerfectDayStorage.Save(pd2)
+			store.PerfectDayStorage.Save(pd3)
+
+			loaded, err := store.PerfectDayStorage.LoadByID("abc12345-0002")
+			if err != nil {
+				t.Fatalf("Failed to load by ID: %v", err)
+			}
+			if loaded.Username != "bob" || loaded.Title != "Day 2" {
+				t.Errorf("Loaded wrong perfect day: %+v", loaded)
+			}
+
+			if _, err := store.PerfectDayStorage.LoadByID("missing"); !errors.Is(err, storage.ErrPerfectDayNotFound) {
+				t.Errorf("Expected ErrPerfectDayNotFound, got %v", err)
+			}
+
+			found, err := store.PerfectDayStorage.FindByIDPrefix("def6")
+			if err != nil {
+				t.Fatalf("Failed to find by prefix: %v", err)
+			}
+			if found.ID != "def67890-0003" || !found.IsDeleted {
+				t.Errorf("Prefix lookup returned wrong perfect day: %+v", found)
+			}
+
+			found, err = store.PerfectDayStorage.FindByIDPrefix("abc12345-0001")
+			if err != nil || found.ID != "abc12345-0001" {
+				t.Errorf("Full ID lookup failed: %v", err)
+			}
+
+			_, err = store.PerfectDayStorage.FindByIDPrefix("abc12345")
+			var ambiguous *storage.AmbiguousIDError
+			if !errors.As(err, &ambiguous) {
+				t.Fatalf("Expected AmbiguousIDError, got %v", err)
+			}
+			if len(ambiguous.Matches) != 2 {
+				t.Errorf("Expected 2 ambiguous matches, got %v", ambiguous.Matches)
+			}
+
+			if _, err := store.PerfectDayStorage.FindByIDPrefix("zzz"); !errors.Is(err, storage.ErrPerfectDayNotFound) {
+				t.Errorf("Expected ErrPerfectDayNotFound for unknown prefix, got %v", err)
+			}
+
+			store.PerfectDayStorage.Delete("alice", "abc12345-0001", 0)
+			found, err = store.PerfectDayStorage.FindByIDPrefix("abc12345")
+			if err != nil || found.ID != "abc12345-0002" {
+				t.Errorf("Prefix should be unambiguous after delete, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRepositoryRevisionConflict(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			pd, _ := models.NewPerfectDay("rev-id", "Day", "", "testuser", "2024-01-01")
+			if err := store.PerfectDayStorage.Save(pd); err != nil {
+				t.Fatalf("Failed to save perfect day: %v", err)
+			}
+			if pd.Revision != 1 {
+				t.Errorf("Expected revision 1 after first save, got %d", pd.Revision)
+			}
+
+			first, _ := store.PerfectDayStorage.LoadByID("rev-id")
+			second, _ := store.PerfectDayStorage.LoadByID("rev-id")
+
+			first.Title = "First"
+			if err := store.PerfectDayStorage.Save(first); err != nil {
+				t.Fatalf("Failed to save first edit: %v", err)
+			}
+			if first.Revision != 2 {
+				t.Errorf("Expected revision 2, got %d", first.Revision)
+			}
+
+			second.Title = "Second"
+			err := store.PerfectDayStorage.Save(second)
+			if !errors.Is(err, storage.ErrRevisionConflict) {
+				t.Fatalf("Expected ErrRevisionConflict, got %v", err)
+			}
+			if second.Revision != 1 {
+				t.Errorf("Rejected save should not change the revision, got %d", second.Revision)
+			}
+
+			loaded, _ := store.PerfectDayStorage.LoadByID("rev-id")
+			if loaded.Title != "First" || loaded.Revision != 2 {
+				t.Errorf("Expected first edit at revision 2, got %q at %d", loaded.Title, loaded.Revision)
+			}
+
+			// Deleting the copy of the second edit must not purge the first
+			if err := store.PerfectDayStorage.Delete("testuser", "rev-id", second.Revision); !errors.Is(err, storage.ErrRevisionConflict) {
+				t.Fatalf("Expected ErrRevisionConflict deleting a stale copy, got %v", err)
+			}
+			if revisions, _ := store.PerfectDayStorage.ListRevisions("rev-id"); len(revisions) != 2 {
+				t.Errorf("Expected the history to be kept, got %d revisions", len(revisions))
+			}
+			if err := store.PerfectDayStorage.Delete("testuser", "rev-id", first.Revision); err != nil {
+				t.Fatalf("Failed to delete the current revision: %v", err)
+			}
+			if _, err := store.PerfectDayStorage.LoadByID("rev-id"); err == nil {
+				t.Error("Expected the perfect day to be deleted")
+			}
+		})
+	}
+}
+
+func TestRepositoryRevisionHistory(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			pd, _ := models.NewPerfectDay("hist-id", "First", "", "testuser", "2024-01-01")
+			store.PerfectDayStorage.Save(pd)
+
+			pd.Title = "Second"
+			pd.UpdatedBy = "editor"
+			store.PerfectDayStorage.Save(pd)
+
+			pd.Title = "Third"
+			store.PerfectDayStorage.Save(pd)
+
+			revisions, err := store.PerfectDayStorage.ListRevisions("hist-id")
+			if err != nil {
+				t.Fatalf("Failed to list revisions: %v", err)
+			}
+			if len(revisions) != 3 {
+				t.Fatalf("Expected 3 revisions, got %d", len(revisions))
+			}
+			for i, revision := range revisions {
+				if revision.Revision != i+1 || revision.PerfectDay.Revision != i+1 {
+					t.Errorf("Expected revision %d, got %d", i+1, revision.Revision)
+				}
+			}
+			if revisions[0].Author != "testuser" || revisions[1].Author != "editor" {
+				t.Errorf("Unexpected authors %s, %s", revisions[0].Author, revisions[1].Author)
+			}
+
+			second, err := store.PerfectDayStorage.LoadRevision("hist-id", 2)
+			if err != nil {
+				t.Fatalf("Failed to load revision: %v", err)
+			}
+			if second.PerfectDay.Title != "Second" {
+				t.Errorf("Expected title Second, got %s", second.PerfectDay.Title)
+			}
+
+			if _, err := store.PerfectDayStorage.LoadRevision("hist-id", 9); !errors.Is(err, storage.ErrRevisionNotFound) {
+				t.Errorf("Expected ErrRevisionNotFound, got %v", err)
+			}
+
+			store.PerfectDayStorage.Delete("testuser", "hist-id", 0)
+			revisions, _ = store.PerfectDayStorage.ListRevisions("hist-id")
+			if len(revisions) != 0 {
+				t.Errorf("Expected no revisions after delete, got %d", len(revisions))
+			}
+		})
+	}
+}
+
+func TestRepositoryTrashPurge(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			old, _ := models.NewPerfectDay("old-id", "Old", "", "testuser", "2024-01-01")
+			old.SoftDelete()
+			longAgo := time.Now().Add(-60 * 24 * time.Hour)
+			old.DeletedAt = &longAgo
+			recent, _ := models.NewPerfectDay("recent-id", "Recent", "", "testuser", "2024-01-02")
+			recent.SoftDelete()
+			live, _ := models.NewPerfectDay("live-id", "Live", "", "testuser", "2024-01-03")
+			other, _ := models.NewPerfectDay("other-id", "Other", "", "otheruser", "2024-01-04")
+			other.SoftDelete()
+			other.DeletedAt = &longAgo
+			for _, pd := range []*models.PerfectDay{old, recent, live, other} {
+				store.PerfectDayStorage.Save(pd)
+			}
+
+			loaded, _ := store.PerfectDayStorage.LoadByID("old-id")
+			if loaded.DeletedAt == nil || !loaded.DeletedAt.Equal(longAgo) {
+				t.Errorf("DeletedAt should round-trip, got %v", loaded.DeletedAt)
+			}
+
+			deleted, err := storage.ListDeleted(store.PerfectDayStorage, "testuser")
+			if err != nil {
+				t.Fatalf("Failed to list trash: %v", err)
+			}
+			if len(deleted) != 2 || deleted[0].ID != "old-id" {
+				t.Errorf("Expected [old-id recent-id], got %d entries", len(deleted))
+			}
+
+			purged, err := storage.PurgeDeleted(store.PerfectDayStorage, "testuser", time.Now().Add(-30*24*time.Hour))
+			if err != nil {
+				t.Fatalf("Failed to purge: %v", err)
+			}
+			if len(purged) != 1 || purged[0].ID != "old-id" {
+				t.Errorf("Expected only old-id to be purged, got %d", len(purged))
+			}
+
+			if _, err := store.PerfectDayStorage.LoadByID("old-id"); !errors.Is(err, storage.ErrPerfectDayNotFound) {
+				t.Errorf("Purged perfect day should be gone, got %v", err)
+			}
+			for _, id := range []string{"recent-id", "live-id", "other-id"} {
+				if _, err := store.PerfectDayStorage.LoadByID(id); err != nil {
+					t.Errorf("%s should not be purged: %v", id, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", "schema", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func storedSchemaVersion(t *testing.T, path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	json.Unmarshal(data, &header)
+	return header.SchemaVersion
+}
+
+func TestSchemaMigrationUserV0(t *testing.T) {
+	user, version, err := storage.DecodeUser(readFixture(t, "v0/user.json"))
+	if err != nil {
+		t.Fatalf("Failed to decode user: %v", err)
+	}
+	if version != 0 {
+		t.Errorf("Expected stored version 0, got %d", version)
+	}
+	if user.Username != "legacy" || user.Timezone != "UTC" {
+		t.Errorf("Expected legacy user with UTC timezone, got %+v", user)
+	}
+}
+
+func TestSchemaMigrationPerfectDayV0(t *testing.T) {
+	pd, version, err := storage.DecodePerfectDay(readFixture(t, "v0/perfect_day.json"))
+	if err != nil {
+		t.Fatalf("Failed to decode perfect day: %v", err)
+	}
+	if version != 0 {
+		t.Errorf("Expected stored version 0, got %d", version)
+	}
+	if pd.Areas == nil || pd.Activities == nil {
+		t.Error("Null areas and activities should become empty lists")
+	}
+	if pd.DeletedAt != nil {
+		t.Error("A live perfect day should not get a deletion time")
+	}
+
+	deleted, _, err := storage.DecodePerfectDay(readFixture(t, "v0/perfect_day_deleted.json"))
+	if err != nil {
+		t.Fatalf("Failed to decode perfect day: %v", err)
+	}
+	expected := time.Date(2024, 1, 8, 12, 30, 0, 0, time.UTC)
+	if deleted.DeletedAt == nil || !deleted.DeletedAt.Equal(expected) {
+		t.Errorf("Expected deleted_at to be backfilled from updated_at, got %v", deleted.DeletedAt)
+	}
+	if len(deleted.Activities) != 1 || deleted.Activities[0].Location.Area != "Shibuya" {
+		t.Errorf("Activities should survive the migration, got %+v", deleted.Activities)
+	}
+}
+
+func TestSchemaMigrationRevisionV0(t *testing.T) {
+	revision, version, err := storage.DecodeRevision(readFixture(t, "v0/revision.json"))
+	if err != nil {
+		t.Fatalf("Failed to decode revision: %v", err)
+	}
+	if version != 0 || revision.Revision != 1 || revision.Author != "legacy" {
+		t.Errorf("Unexpected revision %+v at version %d", revision, version)
+	}
+	if revision.PerfectDay.DeletedAt == nil || revision.PerfectDay.Areas == nil {
+		t.Errorf("The snapshot should be migrated, got %+v", revision.PerfectDay)
+	}
+}
+
+func TestSchemaRejectsNewerDocuments(t *testing.T) {
+	data := []byte(`{"schema_version": 99, "username": "future", "timezone": "UTC"}`)
+	if _, _, err := storage.DecodeUser(data); !errors.Is(err, storage.ErrNewerSchema) {
+		t.Errorf("Expected ErrNewerSchema, got %v", err)
+	}
+}
+
+func TestSchemaVersionWrittenOnSave(t *testing.T) {
+	dataDir := t.TempDir()
+	store := storage.NewStorage(dataDir)
+
+	user, _ := models.NewUser("alice", "UTC")
+	store.UserStorage.Save(user)
+	pd, _ := models.NewPerfectDay("schema01", "Versioned", "", "alice", "2024-01-01")
+	store.PerfectDayStorage.Save(pd)
+
+	expected := map[string]int{
+		filepath.Join(dataDir, "users", "alice.json"):                    storage.UserSchemaVersion,
+		filepath.Join(dataDir, "perfect-days", "alice", "schema01.json"): storage.PerfectDaySchemaVersion,
+		filepath.Join(dataDir, "revisions", "schema01", "1.json"):        storage.PerfectDaySchemaVersion,
+	}
+	for path, want := range expected {
+		if version := storedSchemaVersion(t, path); version != want {
+			t.Errorf("Expected %s at schema version %d, got %d", path, want, version)
+		}
+	}
+}
+
+func TestStorageUpgradeRewritesOldDocuments(t *testing.T) {
+	dataDir := t.TempDir()
+	fixtures := map[string]string{
+		"v0/user.json":                "users/legacy.json",
+		"v0/perfect_day.json":         "perfect-days/legacy/legacy01-0000-0000-0000-000000000001.json",
+		"v0/perfect_day_deleted.json": "perfect-days/legacy/legacy02-0000-0000-0000-000000000002.json",
+		"v0/revision.json":            "revisions/legacy02-0000-0000-0000-000000000002/1.json",
+	}
+	for fixture, target := range fixtures {
+		path := filepath.Join(dataDir, filepath.FromSlash(target))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, readFixture(t, fixture), 0644)
+	}
+	os.WriteFile(filepath.Join(dataDir, "perfect-days", "legacy", "broken.json"), []byte("{"), 0644)
+
+	store := storage.NewStorage(dataDir)
+
+	// Old documents load before any upgrade.
+	loaded, err := store.PerfectDayStorage.Load("legacy", "legacy02-0000-0000-0000-000000000002")
+	if err != nil || loaded.DeletedAt == nil {
+		t.Fatalf("Expected the old perfect day to load migrated, got %v, %v", loaded, err)
+	}
+
+	result, err := store.PerfectDayStorage.(*storage.PerfectDayStorage).UpgradeDocuments()
+	if err != nil {
+		t.Fatalf("Upgrade failed: %v", err)
+	}
+	if result.Users != 1 || result.PerfectDays != 2 || result.Revisions != 1 {
+		t.Errorf("Expected 1 user, 2 perfect days and 1 revision upgraded, got %+v", result)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "perfect-days/legacy/broken.json" {
+		t.Errorf("Expected the broken file to be skipped, got %v", result.Skipped)
+	}
+
+	for _, target := range fixtures {
+		if version := storedSchemaVersion(t, filepath.Join(dataDir, filepath.FromSlash(target))); version != storage.PerfectDaySchemaVersion {
+			t.Errorf("Expected %s to be rewritten at the current version, got %d", target, version)
+		}
+	}
+
+	upgraded, _ := store.PerfectDayStorage.Load("legacy", "legacy02-0000-0000-0000-000000000002")
+	if upgraded.Revision != loaded.Revision || !upgraded.DeletedAt.Equal(*loaded.DeletedAt) {
+		t.Errorf("Upgrading must not change content, got %+v", upgraded)
+	}
+
+	result, _ = store.PerfectDayStorage.(*storage.PerfectDayStorage).UpgradeDocuments()
+	if result.Users+result.PerfectDays+result.Revisions != 0 {
+		t.Errorf("A second upgrade should have nothing to do, got %+v", result)
+	}
+}
+
+func TestSQLiteUpgradeRewritesOldSnapshots(t *testing.T) {
+	dataDir := t.TempDir()
+	store, err := storage.NewSQLiteStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite storage: %v", err)
+	}
+	pd, _ := models.NewPerfectDay("legacy02-0000-0000-0000-000000000002", "Deleted Legacy Day", "", "legacy", "2024-01-07")
+	store.PerfectDayStorage.Save(pd)
+	store.Close()
+
+	db, err := storage.OpenSQLite(filepath.Join(dataDir, storage.SQLiteFileName))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var revision struct {
+		PerfectDay json.RawMessage `json:"perfect_day"`
+	}
+	json.Unmarshal(readFixture(t, "v0/revision.json"), &revision)
+	if _, err := db.Exec("UPDATE perfect_day_revisions SET snapshot = ? WHERE perfect_day_id = ?", string(revision.PerfectDay), pd.ID); err != nil {
+		t.Fatalf("Failed to store old snapshot: %v", err)
+	}
+
+	sqliteStorage := storage.NewSQLitePerfectDayStorage(db)
+	revisions, err := sqliteStorage.ListRevisions(pd.ID)
+	if err != nil || len(revisions) != 1 || revisions[0].PerfectDay.DeletedAt == nil {
+		t.Fatalf("Expected the old snapshot to load migrated, got %v, %v", revisions, err)
+	}
+
+	result, err := sqliteStorage.UpgradeDocuments()
+	if err != nil {
+		t.Fatalf("Upgrade failed: %v", err)
+	}
+	if result.Revisions != 1 {
+		t.Errorf("Expected 1 upgraded revision, got %+v", result)
+	}
+
+	var snapshot string
+	db.QueryRow("SELECT snapshot FROM perfect_day_revisions WHERE perfect_day_id = ?", pd.ID).Scan(&snapshot)
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	json.Unmarshal([]byte(snapshot), &header)
+	if header.SchemaVersion != storage.PerfectDaySchemaVersion {
+		t.Errorf("Expected the snapshot at the current version, got %d", header.SchemaVersion)
+	}
+}
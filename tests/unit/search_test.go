@@ -1,9 +1,14 @@
 package unit
 
 import (
+	"errors"
+	"os"
 	"perfect-day/pkg/models"
 	"perfect-day/pkg/search"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func createTestPerfectDays() []*models.PerfectDay {
@@ -265,4 +270,135 @@ func TestGetUniqueAreas(t *testing.T) {
 			t.Errorf("Expected area %s at position %d, got %s", expected, i, areas[i])
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestSearchCursorPagination(t *testing.T) {
+	searchService := search.NewSearchService()
+
+	// Equal creation times are ordered by ID
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	var perfectDays []*models.PerfectDay
+	for _, id := range []string{"id3", "id1", "id5", "id2", "id4"} {
+		pd, _ := models.NewPerfectDay(id, "Day "+id, "", "alice", "2025-01-15")
+		pd.CreatedAt = created
+		perfectDays = append(perfectDays, pd)
+	}
+	ids := func(result *search.SearchResult) string {
+		var ids []string
+		for _, pd := range result.PerfectDays {
+			ids = append(ids, pd.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	criteria := search.SearchCriteria{SortBy: "created_at", SortOrder: "asc", Limit: 2}
+	first, err := searchService.Page(perfectDays, criteria)
+	if err != nil {
+		t.Fatalf("Failed to get first page: %v", err)
+	}
+	if ids(first) != "id1,id2" || first.PrevCursor != "" || first.NextCursor == "" {
+		t.Fatalf("Expected id1,id2 with only a next cursor, got %s", ids(first))
+	}
+
+	// A perfect day added before the cursor neither repeats nor shifts results
+	added, _ := models.NewPerfectDay("id0", "Day id0", "", "alice", "2025-01-15")
+	added.CreatedAt = created
+	perfectDays = append(perfectDays, added)
+
+	criteria.Cursor = first.NextCursor
+	second, err := searchService.Page(perfectDays, criteria)
+	if err != nil {
+		t.Fatalf("Failed to get second page: %v", err)
+	}
+	if ids(second) != "id3,id4" || second.PrevCursor == "" || second.NextCursor == "" {
+		t.Fatalf("Expected id3,id4 with both cursors, got %s", ids(second))
+	}
+
+	criteria.Cursor = second.NextCursor
+	last, _ := searchService.Page(perfectDays, criteria)
+	if ids(last) != "id5" || last.NextCursor != "" {
+		t.Errorf("Expected id5 without a next cursor, got %s", ids(last))
+	}
+
+	criteria.Cursor = second.PrevCursor
+	previous, _ := searchService.Page(perfectDays, criteria)
+	if ids(previous) != "id1,id2" || previous.PrevCursor == "" {
+		t.Errorf("Expected id1,id2 with a cursor back to id0, got %s", ids(previous))
+	}
+
+	// Cursors only work for the listing and service that issued them
+	invalid := map[string]string{
+		"tampered":      first.NextCursor[:len(first.NextCursor)-2] + "xx",
+		"garbage":       "not-a-cursor",
+		"other service": mustNextCursor(t, search.NewSearchService(), perfectDays, search.SearchCriteria{SortBy: "created_at", SortOrder: "asc", Limit: 2}),
+	}
+	for name, cursor := range invalid {
+		criteria.Cursor = cursor
+		if _, err := searchService.Page(perfectDays, criteria); !errors.Is(err, search.ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor for a %s cursor, got %v", name, err)
+		}
+	}
+	criteria.Cursor = first.NextCursor
+	criteria.SortOrder = "desc"
+	if _, err := searchService.Page(perfectDays, criteria); !errors.Is(err, search.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for another sort order, got %v", err)
+	}
+}
+
+func TestLoadCursorKey(t *testing.T) {
+	dataDir := t.TempDir()
+	key, err := search.LoadCursorKey(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create cursor key: %v", err)
+	}
+	again, err := search.LoadCursorKey(dataDir)
+	if err != nil || string(again) != string(key) {
+		t.Fatalf("Expected the same key on every load, got %v", err)
+	}
+
+	// Services sharing the key accept each other's cursors
+	perfectDays := createTestPerfectDays()
+	criteria := search.SearchCriteria{Limit: 1}
+	criteria.Cursor = mustNextCursor(t, search.NewSearchServiceWithCursorKey(key), perfectDays, criteria)
+	if _, err := search.NewSearchServiceWithCursorKey(again).Page(perfectDays, criteria); err != nil {
+		t.Errorf("Expected the cursor to be valid for another service with the key, got %v", err)
+	}
+}
+
+func TestLoadCursorKeyConcurrently(t *testing.T) {
+	dataDir := t.TempDir()
+
+	const loaders = 16
+	keys := make([][]byte, loaders)
+	errs := make([]error, loaders)
+	var wg sync.WaitGroup
+	for i := 0; i < loaders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			keys[i], errs[i] = search.LoadCursorKey(dataDir)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range keys {
+		if errs[i] != nil {
+			t.Fatalf("Failed to load cursor key: %v", errs[i])
+		}
+		if string(keys[i]) != string(keys[0]) {
+			t.Fatal("Expected every loader to agree on one key")
+		}
+	}
+	entries, _ := os.ReadDir(dataDir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the key file to be left, got %d entries", len(entries))
+	}
+}
+
+func mustNextCursor(t *testing.T, searchService *search.SearchService, perfectDays []*models.PerfectDay, criteria search.SearchCriteria) string {
+	result, err := searchService.Page(perfectDays, criteria)
+	if err != nil || result.NextCursor == "" {
+		t.Fatalf("Expected a next cursor, got %v", err)
+	}
+	return result.NextCursor
+}
@@ -0,0 +1,217 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestSession(token, username string, expiresAt time.Time) *models.Session {
+	return &models.Session{
+		ID:         models.SessionID(token),
+		Username:   username,
+		CreatedAt:  expiresAt.Add(-auth.SessionTTL),
+		LastSeenAt: expiresAt.Add(-auth.SessionTTL),
+		ExpiresAt:  expiresAt,
+		Token:      token,
+	}
+}
+
+func TestRepositorySessionSaveAndLoad(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			expiresAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+			session := newTestSession("secret-token", "alice", expiresAt)
+			if err := store.SessionStorage.Save(session); err != nil {
+				t.Fatalf("Failed to save session: %v", err)
+			}
+
+			loaded, err := store.SessionStorage.Load(session.ID)
+			if err != nil {
+				t.Fatalf("Failed to load session: %v", err)
+			}
+			if loaded.Username != "alice" || !loaded.ExpiresAt.Equal(expiresAt) {
+				t.Errorf("Loaded session does not match: %+v", loaded)
+			}
+			if loaded.Token != "" {
+				t.Error("The session token should not be stored")
+			}
+
+			if err := store.SessionStorage.Delete(session.ID); err != nil {
+				t.Fatalf("Failed to delete session: %v", err)
+			}
+			if _, err := store.SessionStorage.Load(session.ID); !errors.Is(err, storage.ErrSessionNotFound) {
+				t.Errorf("Expected ErrSessionNotFound after delete, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRepositorySessionListAndDeleteExpired(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			store.SessionStorage.Save(newTestSession("alice-new", "alice", now.Add(2*time.Hour)))
+			store.SessionStorage.Save(newTestSession("alice-old", "alice", now.Add(time.Hour)))
+			store.SessionStorage.Save(newTestSession("alice-expired", "alice", now.Add(-time.Minute)))
+			store.SessionStorage.Save(newTestSession("bob", "bob", now.Add(-time.Second)))
+
+			sessions, err := store.SessionStorage.ListByUser("alice")
+			if err != nil {
+				t.Fatalf("Failed to list sessions: %v", err)
+			}
+			if len(sessions) != 3 {
+				t.Fatalf("Expected 3 sessions for alice, got %d", len(sessions))
+			}
+			if sessions[0].ID != models.SessionID("alice-expired") || sessions[2].ID != models.SessionID("alice-new") {
+				t.Error("Sessions should be listed oldest first")
+			}
+
+			deleted, err := store.SessionStorage.DeleteExpired(now)
+			if err != nil {
+				t.Fatalf("Failed to delete expired sessions: %v", err)
+			}
+			if deleted != 2 {
+				t.Errorf("Expected 2 expired sessions, deleted %d", deleted)
+			}
+
+			sessions, _ = store.SessionStorage.ListByUser("alice")
+			if len(sessions) != 2 {
+				t.Errorf("Expected 2 sessions left for alice, got %d", len(sessions))
+			}
+			if sessions, _ := store.SessionStorage.ListByUser("bob"); len(sessions) != 0 {
+				t.Errorf("Expected bob's session to be evicted, got %d", len(sessions))
+			}
+		})
+	}
+}
+
+func TestRepositorySessionConcurrentAccess(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					session := newTestSession(fmt.Sprintf("token-%d", i), "alice", time.Now().Add(time.Hour))
+					for j := 0; j < 10; j++ {
+						session.LastSeenAt = time.Now()
+						if err := store.SessionStorage.Save(session); err != nil {
+							t.Errorf("Failed to save session: %v", err)
+							return
+						}
+						if _, err := store.SessionStorage.Load(session.ID); err != nil {
+							t.Errorf("Failed to load session: %v", err)
+							return
+						}
+						store.SessionStorage.ListByUser("alice")
+						store.SessionStorage.DeleteExpired(time.Now())
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			sessions, _ := store.SessionStorage.ListByUser("alice")
+			if len(sessions) != 8 {
+				t.Errorf("Expected 8 sessions, got %d", len(sessions))
+			}
+		})
+	}
+}
+
+func TestRepositorySessionTouch(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			session := newTestSession("touched", "alice", time.Now().Add(time.Minute))
+			store.SessionStorage.Save(session)
+
+			seenAt := time.Now().Truncate(time.Millisecond)
+			expiresAt := seenAt.Add(time.Hour)
+			if err := store.SessionStorage.Touch(session.ID, seenAt, expiresAt); err != nil {
+				t.Fatalf("Failed to touch session: %v", err)
+			}
+			loaded, err := store.SessionStorage.Load(session.ID)
+			if err != nil || !loaded.LastSeenAt.Equal(seenAt) || !loaded.ExpiresAt.Equal(expiresAt) || loaded.Username != "alice" {
+				t.Fatalf("Expected the session to be extended, got %+v (%v)", loaded, err)
+			}
+
+			// A request loaded the session, then it was logged out before the touch
+			store.SessionStorage.Delete(session.ID)
+			if err := store.SessionStorage.Touch(loaded.ID, time.Now(), time.Now().Add(time.Hour)); !errors.Is(err, storage.ErrSessionNotFound) {
+				t.Errorf("Expected ErrSessionNotFound touching a deleted session, got %v", err)
+			}
+			if _, err := store.SessionStorage.Load(session.ID); !errors.Is(err, storage.ErrSessionNotFound) {
+				t.Errorf("Expected the deleted session to stay deleted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthServiceSlidingExpiry(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	authService := auth.NewAuthService(store.UserStorage, store.SessionStorage, store.TokenStorage)
+
+	_, session, err := authService.Signup("alice", "UTC", "correct-horse")
+	if err != nil {
+		t.Fatalf("Failed to sign up: %v", err)
+	}
+
+	// Pretend the session was last used almost a full TTL ago
+	stored, _ := store.SessionStorage.Load(session.ID)
+	stored.LastSeenAt = time.Now().Add(-auth.SessionTTL + time.Minute)
+	stored.ExpiresAt = time.Now().Add(time.Minute)
+	store.SessionStorage.Save(stored)
+
+	if _, err := authService.ValidateSession(session.Token); err != nil {
+		t.Fatalf("Session should still be valid: %v", err)
+	}
+
+	stored, _ = store.SessionStorage.Load(session.ID)
+	if time.Until(stored.ExpiresAt) < auth.SessionTTL-time.Minute {
+		t.Errorf("Using the session should extend it, expires at %v", stored.ExpiresAt)
+	}
+
+	stored.ExpiresAt = time.Now().Add(-time.Second)
+	store.SessionStorage.Save(stored)
+	if _, err := authService.ValidateSession(session.Token); err == nil {
+		t.Error("Expired session should be rejected")
+	}
+	if _, err := store.SessionStorage.Load(session.ID); !errors.Is(err, storage.ErrSessionNotFound) {
+		t.Error("Expired session should be evicted when used")
+	}
+}
+
+func TestAuthServiceListSessions(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	authService := auth.NewAuthService(store.UserStorage, store.SessionStorage, store.TokenStorage)
+
+	authService.Signup("alice", "UTC", "correct-horse")
+	_, second, _ := authService.Login("alice", "correct-horse")
+	store.SessionStorage.Save(newTestSession("stale", "alice", time.Now().Add(-time.Minute)))
+
+	sessions, err := authService.ListSessions("alice")
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Errorf("Expected 2 active sessions, got %d", len(sessions))
+	}
+
+	if err := authService.Logout(second.Token); err != nil {
+		t.Fatalf("Failed to log out: %v", err)
+	}
+	if _, err := authService.ValidateSession(second.Token); err == nil {
+		t.Error("Logged out session should be rejected")
+	}
+
+	evicted, _ := authService.DeleteExpiredSessions()
+	if evicted != 1 {
+		t.Errorf("Expected 1 expired session to be evicted, got %d", evicted)
+	}
+}
@@ -0,0 +1,155 @@
+package unit
+
+import (
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStorageReopen(t *testing.T) {
+	tempDir := t.TempDir()
+
+	first, err := storage.NewSQLiteStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite storage: %v", err)
+	}
+
+	pd, _ := models.NewPerfectDay("id1", "Day 1", "desc", "alice", "2023-12-01")
+	coords := &models.Coordinates{Latitude: 35.6595, Longitude: 139.7005}
+	location := models.NewGooglePlaceLocation("place-1", "Hachiko", "Shibuya, Tokyo", "Shibuya", coords)
+	activity, _ := models.NewActivity("act1", "Meet", *location, "10:00", 30, "", "")
+	pd.AddActivity(*activity)
+	if err := first.PerfectDayStorage.Save(pd); err != nil {
+		t.Fatalf("Failed to save perfect day: %v", err)
+	}
+	first.Close()
+
+	// Reopening must not re-run migrations or lose data.
+	second, err := storage.NewSQLiteStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen sqlite storage: %v", err)
+	}
+	defer second.Close()
+
+	loaded, err := second.PerfectDayStorage.Load("alice", "id1")
+	if err != nil {
+		t.Fatalf("Failed to load perfect day: %v", err)
+	}
+	got := loaded.Activities[0].Location
+	if got.Type != models.GooglePlaceLocation || got.PlaceID != "place-1" || got.Coordinates == nil || got.Coordinates.Latitude != coords.Latitude {
+		t.Errorf("Location not round-tripped: %+v", got)
+	}
+	if !loaded.CreatedAt.Equal(pd.CreatedAt) {
+		t.Errorf("Expected created_at %v, got %v", pd.CreatedAt, loaded.CreatedAt)
+	}
+}
+
+func TestSQLiteStorageReplacesActivities(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open sqlite storage: %v", err)
+	}
+	defer store.Close()
+
+	pd, _ := models.NewPerfectDay("id1", "Day 1", "", "alice", "2023-12-01")
+	first := models.NewCustomTextLocation("Cafe", "Shibuya")
+	second := models.NewCustomTextLocation("Park", "Harajuku")
+	activity1, _ := models.NewActivity("act1", "Coffee", *first, "09:00", 60, "", "")
+	activity2, _ := models.NewActivity("act2", "Walk", *second, "11:00", 60, "", "")
+	pd.AddActivity(*activity1)
+	pd.AddActivity(*activity2)
+	store.PerfectDayStorage.Save(pd)
+
+	pd.Activities = pd.Activities[1:]
+	pd.UpdateAreas()
+	store.PerfectDayStorage.Save(pd)
+
+	loaded, _ := store.PerfectDayStorage.Load("alice", "id1")
+	if len(loaded.Activities) != 1 || loaded.Activities[0].ID != "act2" {
+		t.Errorf("Expected only act2 after update, got %+v", loaded.Activities)
+	}
+	if len(loaded.Areas) != 1 || loaded.Areas[0] != "Harajuku" {
+		t.Errorf("Expected areas [Harajuku], got %v", loaded.Areas)
+	}
+}
+
+func TestCopyFilesToSQLite(t *testing.T) {
+	tempDir := t.TempDir()
+	files := storage.NewStorage(tempDir)
+
+	user, _ := models.NewUser("alice", "UTC")
+	files.UserStorage.Save(user)
+	pd1, _ := models.NewPerfectDay("id1", "Day 1", "", "alice", "2023-12-01")
+	pd2, _ := models.NewPerfectDay("id2", "Day 2", "", "alice", "2023-12-02")
+	pd2.SoftDelete()
+	files.PerfectDayStorage.Save(pd1)
+	pd1.Title = "Day 1, edited"
+	files.PerfectDayStorage.Save(pd1)
+	files.PerfectDayStorage.Save(pd2)
+	files.SessionStorage.Save(newTestSession("alice-session", "alice", time.Now().Add(time.Hour)))
+	files.TokenStorage.Save(&models.APIToken{ID: models.APITokenID("alice-token"), Username: "alice", Name: "CI",
+		Scopes: []string{models.ScopePerfectDaysRead}, CreatedAt: time.Now()})
+	files.AuditStorage.Append(&models.AuditEvent{ID: "event-1", Time: time.Now(), Actor: "alice", Action: models.AuditLoginSuccess})
+
+	sqliteStorage, err := storage.NewSQLiteStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite storage: %v", err)
+	}
+	defer sqliteStorage.Close()
+
+	for i := 0; i < 2; i++ {
+		result, err := storage.Copy(sqliteStorage, files)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if result.Users != 1 || result.PerfectDays != 2 || result.Revisions != 3 || result.Sessions != 1 || result.Tokens != 1 {
+			t.Errorf("Expected 1 user, 2 perfect days with 3 revisions, 1 session and 1 token copied, got %+v", result)
+		}
+	}
+
+	all, _ := sqliteStorage.PerfectDayStorage.LoadAll(true)
+	if len(all) != 2 {
+		t.Errorf("Expected 2 perfect days after repeated copy, got %d", len(all))
+	}
+	if !sqliteStorage.UserStorage.Exists("alice") {
+		t.Error("User should exist in sqlite after copy")
+	}
+
+	copied, err := sqliteStorage.PerfectDayStorage.LoadByID("id1")
+	if err != nil || copied.Revision != 2 {
+		t.Fatalf("Expected id1 to keep revision 2, got %v %v", copied, err)
+	}
+	revisions, _ := sqliteStorage.PerfectDayStorage.ListRevisions("id1")
+	if len(revisions) != 2 || revisions[0].Revision != 1 || revisions[0].PerfectDay.Title != "Day 1" || revisions[1].PerfectDay.Title != "Day 1, edited" {
+		t.Errorf("Expected the history to be copied as it is, got %+v", revisions)
+	}
+
+	if _, err := sqliteStorage.SessionStorage.Load(models.SessionID("alice-session")); err != nil {
+		t.Errorf("Expected the session to be copied: %v", err)
+	}
+	if _, err := sqliteStorage.TokenStorage.Load(models.APITokenID("alice-token")); err != nil {
+		t.Errorf("Expected the API token to be copied: %v", err)
+	}
+	if events, _ := sqliteStorage.AuditStorage.Query(models.AuditFilter{}); len(events) != 1 || events[0].ID != "event-1" {
+		t.Errorf("Expected the audit event to be copied once, got %+v", events)
+	}
+
+	// And back into a fresh file layout
+	back := storage.NewStorage(t.TempDir())
+	if _, err := storage.Copy(back, sqliteStorage); err != nil {
+		t.Fatalf("Copy back failed: %v", err)
+	}
+	if revisions, _ := back.PerfectDayStorage.ListRevisions("id1"); len(revisions) != 2 || revisions[1].Revision != 2 {
+		t.Errorf("Expected the history to survive the round trip, got %+v", revisions)
+	}
+	if copied, err := back.PerfectDayStorage.LoadByID("id2"); err != nil || !copied.IsDeleted || copied.Revision != 1 {
+		t.Errorf("Expected the trashed perfect day at revision 1, got %+v %v", copied, err)
+	}
+}
+
+func TestOpenUnknownBackend(t *testing.T) {
+	if _, err := storage.Open("postgres", t.TempDir()); err == nil {
+		t.Error("Opening an unknown backend should fail")
+	}
+}
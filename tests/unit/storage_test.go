@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"perfect-day/pkg/models"
@@ -161,7 +162,7 @@ func TestPerfectDayStorageDelete(t *testing.T) {
 		t.Error("Perfect day file should exist before deletion")
 	}
 
-	if err := pdStorage.Delete("testuser", "test-id"); err != nil {
+	if err := pdStorage.Delete("testuser", "test-id", 0); err != nil {
 		t.Fatalf("Failed to delete perfect day: %v", err)
 	}
 
@@ -169,7 +170,7 @@ func TestPerfectDayStorageDelete(t *testing.T) {
 		t.Error("Perfect day file should not exist after deletion")
 	}
 
-	if err := pdStorage.Delete("testuser", "nonexistent"); err != nil {
+	if err := pdStorage.Delete("testuser", "nonexistent", 0); err != nil {
 		t.Errorf("Deleting non-existent file should not return error, got: %v", err)
 	}
 }
@@ -203,4 +204,47 @@ func TestStorageDefaultDataDir(t *testing.T) {
 	if filepath.Base(dataDir) != expectedSuffix {
 		t.Errorf("Expected data directory to end with %s, got %s", expectedSuffix, dataDir)
 	}
-}
\ No newline at end of file
+}
+
+func TestPerfectDayIndexRebuild(t *testing.T) {
+	tempDir := t.TempDir()
+	pdStorage := storage.NewPerfectDayStorage(tempDir)
+
+	pd, _ := models.NewPerfectDay("test-id", "Test Day", "", "testuser", "2023-12-01")
+	pdStorage.Save(pd)
+
+	indexPath := filepath.Join(tempDir, "perfect-days", "index.json")
+	if _, err := os.Stat(indexPath); err != nil {
+		t.Fatalf("Index file should exist after save: %v", err)
+	}
+
+	// Data written without an index (e.g. by an older version) is picked up
+	// when the index is missing.
+	os.Remove(indexPath)
+	other, _ := models.NewPerfectDay("other-id", "Other Day", "", "otheruser", "2023-12-02")
+	data, _ := json.Marshal(other)
+	os.MkdirAll(filepath.Join(tempDir, "perfect-days", "otheruser"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "perfect-days", "otheruser", "other-id.json"), data, 0644)
+
+	loaded, err := pdStorage.LoadByID("other-id")
+	if err != nil {
+		t.Fatalf("Failed to load by ID after index loss: %v", err)
+	}
+	if loaded.Username != "otheruser" {
+		t.Errorf("Expected otheruser, got %s", loaded.Username)
+	}
+
+	// A file removed by hand leaves a stale entry that is dropped on lookup.
+	os.Remove(filepath.Join(tempDir, "perfect-days", "testuser", "test-id.json"))
+	if _, err := pdStorage.LoadByID("test-id"); err == nil {
+		t.Error("Loading a removed perfect day should fail")
+	}
+
+	count, err := pdStorage.RebuildIndex()
+	if err != nil {
+		t.Fatalf("Failed to rebuild index: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 indexed perfect day, got %d", count)
+	}
+}
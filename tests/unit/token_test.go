@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"errors"
+	"perfect-day/pkg/auth"
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepositoryTokenSaveAndList(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			createdAt := time.Now().Truncate(time.Millisecond)
+			expiresAt := createdAt.Add(time.Hour)
+			first := &models.APIToken{
+				ID:        models.APITokenID("pdt_first"),
+				Username:  "alice",
+				Name:      "CI",
+				Scopes:    []string{models.ScopePerfectDaysRead, models.ScopeAdmin},
+				CreatedAt: createdAt,
+				ExpiresAt: &expiresAt,
+				Token:     "pdt_first",
+			}
+			second := &models.APIToken{
+				ID:        models.APITokenID("pdt_second"),
+				Username:  "alice",
+				Name:      "Backup script",
+				Scopes:    []string{models.ScopePerfectDaysWrite},
+				CreatedAt: createdAt.Add(time.Second),
+			}
+			other := &models.APIToken{
+				ID:        models.APITokenID("pdt_other"),
+				Username:  "bob",
+				Name:      "Bob's",
+				Scopes:    []string{models.ScopePerfectDaysRead},
+				CreatedAt: createdAt,
+			}
+			for _, token := range []*models.APIToken{second, first, other} {
+				if err := store.TokenStorage.Save(token); err != nil {
+					t.Fatalf("Failed to save token: %v", err)
+				}
+			}
+
+			loaded, err := store.TokenStorage.Load(first.ID)
+			if err != nil {
+				t.Fatalf("Failed to load token: %v", err)
+			}
+			if loaded.Name != "CI" || len(loaded.Scopes) != 2 || loaded.ExpiresAt == nil || !loaded.ExpiresAt.Equal(expiresAt) {
+				t.Errorf("Loaded token does not match: %+v", loaded)
+			}
+			if loaded.LastUsedAt != nil || loaded.Token != "" {
+				t.Error("Loaded token should have no last use and no secret")
+			}
+
+			tokens, err := store.TokenStorage.ListByUser("alice")
+			if err != nil {
+				t.Fatalf("Failed to list tokens: %v", err)
+			}
+			if len(tokens) != 2 || tokens[0].ID != first.ID {
+				t.Errorf("Expected alice's 2 tokens oldest first, got %d", len(tokens))
+			}
+
+			store.TokenStorage.Delete(first.ID)
+			if _, err := store.TokenStorage.Load(first.ID); !errors.Is(err, storage.ErrTokenNotFound) {
+				t.Errorf("Expected ErrTokenNotFound after delete, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRepositoryTokenTouch(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			token := &models.APIToken{
+				ID:        models.APITokenID("pdt_touched"),
+				Username:  "alice",
+				Name:      "CI",
+				Scopes:    []string{models.ScopePerfectDaysRead},
+				CreatedAt: time.Now(),
+			}
+			store.TokenStorage.Save(token)
+
+			usedAt := time.Now().Truncate(time.Millisecond)
+			if err := store.TokenStorage.Touch(token.ID, usedAt); err != nil {
+				t.Fatalf("Failed to touch token: %v", err)
+			}
+			loaded, err := store.TokenStorage.Load(token.ID)
+			if err != nil || loaded.LastUsedAt == nil || !loaded.LastUsedAt.Equal(usedAt) || loaded.Name != "CI" {
+				t.Fatalf("Expected the last use to be recorded, got %+v (%v)", loaded, err)
+			}
+
+			// A request loaded the token, then it was revoked before the touch
+			store.TokenStorage.Delete(token.ID)
+			if err := store.TokenStorage.Touch(loaded.ID, time.Now()); !errors.Is(err, storage.ErrTokenNotFound) {
+				t.Errorf("Expected ErrTokenNotFound touching a revoked token, got %v", err)
+			}
+			if _, err := store.TokenStorage.Load(token.ID); !errors.Is(err, storage.ErrTokenNotFound) {
+				t.Errorf("Expected the revoked token to stay revoked, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	token := &models.APIToken{Scopes: []string{models.ScopePerfectDaysWrite}}
+	if !token.HasScope(models.ScopePerfectDaysRead) {
+		t.Error("Write access should include read access")
+	}
+	if token.HasScope(models.ScopeAdmin) {
+		t.Error("Token should not have the admin scope")
+	}
+
+	if err := models.ValidateScopes(nil); err == nil {
+		t.Error("A token without scopes should be rejected")
+	}
+	if err := models.ValidateScopes([]string{"perfect_days:delete"}); err == nil {
+		t.Error("Unknown scopes should be rejected")
+	}
+}
+
+func TestAuthServiceTokens(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	authService := auth.NewAuthService(store.UserStorage, store.SessionStorage, store.TokenStorage)
+	authService.Signup("alice", "UTC", "correct-horse")
+
+	token, err := authService.CreateToken("alice", "CI", []string{models.ScopePerfectDaysRead}, 0)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	if !strings.HasPrefix(token.Token, models.APITokenPrefix) || token.ExpiresAt != nil {
+		t.Errorf("Expected a non-expiring %s token, got %+v", models.APITokenPrefix, token)
+	}
+
+	user, validated, err := authService.ValidateToken(token.Token)
+	if err != nil || user.Username != "alice" || validated.LastUsedAt == nil {
+		t.Fatalf("Expected the token to authenticate alice and record its use, got %v", err)
+	}
+	if _, _, err := authService.ValidateToken(token.ID); err == nil {
+		t.Error("The stored token ID must not work as a token")
+	}
+
+	if err := authService.RevokeToken("bob", token.ID); !errors.Is(err, storage.ErrTokenNotFound) {
+		t.Errorf("Other users should not be able to revoke the token, got %v", err)
+	}
+	if err := authService.RevokeToken("alice", token.ID); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if _, _, err := authService.ValidateToken(token.Token); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Error("Revoked token should be rejected")
+	}
+
+	expiring, _ := authService.CreateToken("alice", "Short lived", []string{models.ScopePerfectDaysRead}, time.Hour)
+	stored, _ := store.TokenStorage.Load(expiring.ID)
+	past := time.Now().Add(-time.Second)
+	stored.ExpiresAt = &past
+	store.TokenStorage.Save(stored)
+	if _, _, err := authService.ValidateToken(expiring.Token); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Error("Expired token should be rejected")
+	}
+
+	if _, err := authService.CreateToken("alice", " ", []string{models.ScopeAdmin}, 0); err == nil {
+		t.Error("A token without a name should be rejected")
+	}
+}
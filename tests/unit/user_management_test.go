@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"perfect-day/pkg/models"
+	"perfect-day/pkg/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserUpdateProfile(t *testing.T) {
+	user, _ := models.NewUser("alice", "UTC")
+
+	displayName, bio := "  Alice  ", "Morning person"
+	if err := user.UpdateProfile(nil, &displayName, &bio); err != nil {
+		t.Fatalf("Failed to update profile: %v", err)
+	}
+	if user.DisplayName != "Alice" || user.Bio != "Morning person" || user.Timezone != "UTC" {
+		t.Errorf("Unexpected profile: %+v", user)
+	}
+
+	timezone, longBio := "Asia/Tokyo", strings.Repeat("x", models.MaxBioLength+1)
+	if err := user.UpdateProfile(&timezone, nil, &longBio); err == nil {
+		t.Error("A bio over the limit should be rejected")
+	}
+	if user.Timezone != "UTC" {
+		t.Error("A rejected update should change nothing")
+	}
+
+	if _, err := models.NewUser(models.DeletedUsername, "UTC"); err == nil {
+		t.Error("The placeholder for deleted users should be reserved")
+	}
+}
+
+func TestRepositoryUserProfileAndDelete(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			user, _ := models.NewUser("alice", "UTC")
+			user.DisplayName = "Alice"
+			user.Bio = "Morning person"
+			store.UserStorage.Save(user)
+
+			loaded, err := store.UserStorage.Load("alice")
+			if err != nil {
+				t.Fatalf("Failed to load user: %v", err)
+			}
+			if loaded.DisplayName != "Alice" || loaded.Bio != "Morning person" {
+				t.Errorf("Profile fields were not stored: %+v", loaded)
+			}
+
+			if err := store.UserStorage.Delete("alice"); err != nil {
+				t.Fatalf("Failed to delete user: %v", err)
+			}
+			if store.UserStorage.Exists("alice") {
+				t.Error("User should not exist after delete")
+			}
+			if err := store.UserStorage.Delete("alice"); err == nil {
+				t.Error("Deleting a missing user should fail")
+			}
+		})
+	}
+}
+
+func TestDeleteUserCascade(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, username := range []string{"alice", "bob"} {
+				user, _ := models.NewUser(username, "UTC")
+				store.UserStorage.Save(user)
+				pd, _ := models.NewPerfectDay(username+"-day", "Day", "", username, "2024-01-01")
+				store.PerfectDayStorage.Save(pd)
+				store.SessionStorage.Save(newTestSession(username+"-session", username, time.Now().Add(time.Hour)))
+				store.TokenStorage.Save(&models.APIToken{ID: models.APITokenID(username + "-token"), Username: username, Name: "CI",
+					Scopes: []string{models.ScopePerfectDaysRead}, CreatedAt: time.Now()})
+			}
+			trashed, _ := models.NewPerfectDay("alice-trashed", "Trashed", "", "alice", "2024-01-02")
+			trashed.SoftDelete()
+			store.PerfectDayStorage.Save(trashed)
+
+			result, err := storage.DeleteUser(store, "alice", false)
+			if err != nil {
+				t.Fatalf("Failed to delete user: %v", err)
+			}
+			if result.PerfectDays != 2 || result.Sessions != 1 || result.Tokens != 1 {
+				t.Errorf("Unexpected result: %+v", result)
+			}
+
+			if days, _ := store.PerfectDayStorage.LoadAllByUser("alice", true); len(days) != 0 {
+				t.Errorf("Expected alice's perfect days to be deleted, %d left", len(days))
+			}
+			if days, _ := store.PerfectDayStorage.LoadAllByUser("bob", true); len(days) != 1 {
+				t.Error("Other users' perfect days should be untouched")
+			}
+			if sessions, _ := store.SessionStorage.ListByUser("bob"); len(sessions) != 1 {
+				t.Error("Other users' sessions should be untouched")
+			}
+
+			result, err = storage.DeleteUser(store, "bob", true)
+			if err != nil {
+				t.Fatalf("Failed to delete user: %v", err)
+			}
+			anonymized, err := store.PerfectDayStorage.LoadByID("bob-day")
+			if err != nil || anonymized.Username != models.DeletedUsername {
+				t.Fatalf("Expected bob's perfect day to be anonymized, got %v", err)
+			}
+			if anonymized.Revision != 1 {
+				t.Errorf("Expected a fresh history, got revision %d", anonymized.Revision)
+			}
+			revisions, _ := store.PerfectDayStorage.ListRevisions("bob-day")
+			if len(revisions) != 1 || revisions[0].Author != models.DeletedUsername || revisions[0].PerfectDay.Username != models.DeletedUsername {
+				t.Errorf("Expected only the anonymized revision to be kept, got %+v", revisions)
+			}
+			if days, _ := store.PerfectDayStorage.LoadAllByUser("bob", true); len(days) != 0 {
+				t.Errorf("Expected bob's perfect days to be handed over, %d left", len(days))
+			}
+
+			if fileStorage, ok := store.PerfectDayStorage.(*storage.PerfectDayStorage); ok {
+				report, err := fileStorage.Doctor(false)
+				if err != nil {
+					t.Fatalf("Doctor failed: %v", err)
+				}
+				for _, issue := range report.Issues {
+					t.Errorf("Anonymized perfect days should pass the doctor, got %s: %s", issue.Kind, issue.Message)
+				}
+			}
+		})
+	}
+}